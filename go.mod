@@ -10,6 +10,7 @@ require (
 	github.com/ggerganov/whisper.cpp/bindings/go v0.0.0-20240727173504-6739eb83c3ca
 	github.com/golangci/golangci-lint v1.60.3
 	github.com/gopxl/beep/v2 v2.0.3
+	github.com/gorilla/websocket v1.5.3
 	github.com/hbollon/go-edlib v1.6.0
 	github.com/lithammer/shortuuid/v3 v3.0.7
 	github.com/martinlindhe/unit v0.0.0-20230420213220-4adfd7d0a0d6
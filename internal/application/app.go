@@ -56,11 +56,9 @@ func NewApplication(ctx context.Context, config conf.Configuration) (Application
 
 	radios := make([]srs.Radio, 0, len(config.SRSFrequencies))
 	for _, radioFrequency := range config.SRSFrequencies {
-		radios = append(radios, srs.Radio{
-			Frequency:        radioFrequency.Frequency.Hertz(),
-			Modulation:       radioFrequency.Modulation,
-			ShouldRetransmit: true,
-		})
+		radio := radioFrequency.Radio()
+		radio.ShouldRetransmit = true
+		radios = append(radios, radio)
 	}
 
 	log.Info().
@@ -199,7 +197,13 @@ func (a *app) Run(ctx context.Context, cancel context.CancelFunc, wg *sync.WaitG
 		defer wg.Done()
 		log.Info().Msg("running SRS client")
 		if err := a.srsClient.Run(ctx, wg); err != nil {
-			if !errors.Is(err, context.Canceled) {
+			if errors.Is(err, simpleradio.ErrKicked) {
+				log.Error().Err(err).Msg("kicked from SRS server; check the server's ban list before restarting")
+				cancel()
+			} else if errors.Is(err, simpleradio.ErrPossiblyKicked) {
+				log.Error().Err(err).Msg("SRS server closed the connection; if this repeats after restarting, check the server's ban list")
+				cancel()
+			} else if !errors.Is(err, context.Canceled) {
 				log.Error().Err(err).Msg("error running SRS client")
 				cancel()
 			}
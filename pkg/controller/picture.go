@@ -18,7 +18,7 @@ func (c *controller) HandlePicture(request *brevity.PictureRequest) {
 }
 
 func (c *controller) broadcastPicture(logger *zerolog.Logger, forceBroadcast bool) {
-	if c.srsClient.ClientsOnFrequency() == 0 && !forceBroadcast {
+	if !c.srsClient.HasAudience() && !forceBroadcast {
 		logger.Debug().Msg("skipping PICTURE broadcast because no clients are on frequency")
 		return
 	}
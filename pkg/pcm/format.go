@@ -0,0 +1,108 @@
+package pcm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Encoding is the representation of each sample in raw PCM audio.
+type Encoding int
+
+const (
+	// EncodingF32 encodes each sample as a 32-bit IEEE 754 float in range -1, 1.
+	EncodingF32 Encoding = iota
+	// EncodingS16 encodes each sample as a signed 16-bit integer.
+	EncodingS16
+)
+
+// Format is an encoding and byte order of raw PCM audio. The zero value is F32LE.
+type Format struct {
+	// Encoding is the representation of each sample.
+	Encoding Encoding
+	// BigEndian is true if samples are big-endian. Otherwise, they are little-endian.
+	BigEndian bool
+}
+
+var (
+	// F32LE is 32-bit little-endian float PCM. This is the zero value of Format.
+	F32LE = Format{Encoding: EncodingF32}
+	// F32BE is 32-bit big-endian float PCM.
+	F32BE = Format{Encoding: EncodingF32, BigEndian: true}
+	// S16LE is 16-bit little-endian integer PCM.
+	S16LE = Format{Encoding: EncodingS16}
+	// S16BE is 16-bit big-endian integer PCM.
+	S16BE = Format{Encoding: EncodingS16, BigEndian: true}
+)
+
+// String returns the name of the format as used by tools such as FFmpeg, e.g. "s16le".
+func (f Format) String() string {
+	var s string
+	switch f.Encoding {
+	case EncodingF32:
+		s = "f32"
+	case EncodingS16:
+		s = "s16"
+	default:
+		return "unknown"
+	}
+	if f.BigEndian {
+		return s + "be"
+	}
+	return s + "le"
+}
+
+// Validate returns an error if the format's encoding is not one of the defined encodings.
+func (f Format) Validate() error {
+	if f.Encoding != EncodingF32 && f.Encoding != EncodingS16 {
+		return fmt.Errorf("PCM encoding %d is not defined", f.Encoding)
+	}
+	return nil
+}
+
+// ByteOrder returns the byte order of the format.
+func (f Format) ByteOrder() binary.AppendByteOrder {
+	if f.BigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// Encode converts F32LE samples in range -1, 1 to raw PCM bytes in this format. Samples outside that range are
+// clipped when converted to integers.
+func (f Format) Encode(in []float32) []byte {
+	switch f.Encoding {
+	case EncodingS16:
+		return S16ToBytes(F32toS16LE(clip(in)), f.ByteOrder())
+	default:
+		return F32ToBytes(in, f.ByteOrder())
+	}
+}
+
+// F32ToBytes converts a slice of float32 to raw bytes in the given byte order.
+func F32ToBytes(in []float32, order binary.AppendByteOrder) []byte {
+	out := make([]byte, 0, 4*len(in))
+	for _, f := range in {
+		out = order.AppendUint32(out, math.Float32bits(f))
+	}
+	return out
+}
+
+// S16ToBytes converts a slice of int16 to raw bytes in the given byte order.
+func S16ToBytes(in []int16, order binary.AppendByteOrder) []byte {
+	out := make([]byte, 0, 2*len(in))
+	for _, s := range in {
+		out = order.AppendUint16(out, uint16(s))
+	}
+	return out
+}
+
+// clip returns a copy of the samples limited to range -1, 1, so that they do not wrap around when converted to
+// integers.
+func clip(in []float32) []float32 {
+	out := make([]float32, len(in))
+	for i, f := range in {
+		out[i] = max(-1, min(1, f))
+	}
+	return out
+}
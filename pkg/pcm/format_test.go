@@ -0,0 +1,41 @@
+package pcm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatEncode(t *testing.T) {
+	t.Parallel()
+	samples := []float32{0.5, -1.5}
+	testCases := []struct {
+		format   Format
+		expected []byte
+	}{
+		{F32LE, []byte{0x00, 0x00, 0x00, 0x3F, 0x00, 0x00, 0xC0, 0xBF}},
+		{F32BE, []byte{0x3F, 0x00, 0x00, 0x00, 0xBF, 0xC0, 0x00, 0x00}},
+		{S16LE, []byte{0xFF, 0x3F, 0x01, 0x80}},
+		{S16BE, []byte{0x3F, 0xFF, 0x80, 0x01}},
+	}
+	for _, test := range testCases {
+		t.Run(test.format.String(), func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, test.format.Encode(samples))
+		})
+	}
+	assert.Equal(t, []float32{0.5, -1.5}, samples, "input should not be modified")
+	assert.Equal(t, F32toS16LEBytes([]float32{0.5, -0.5}), S16LE.Encode([]float32{0.5, -0.5}))
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, F32LE, Format{})
+	assert.Equal(t, "f32le", Format{}.String())
+	assert.Equal(t, "s16be", S16BE.String())
+	require.NoError(t, F32BE.Validate())
+	require.NoError(t, S16LE.Validate())
+	require.Error(t, Format{Encoding: Encoding(99)}.Validate())
+	assert.Equal(t, "unknown", Format{Encoding: Encoding(99)}.String())
+}
@@ -70,3 +70,41 @@ func S16LEBytesToF32LE(in []byte) []float32 {
 	}
 	return out
 }
+
+// Normalize scales F32LE PCM audio so that its loudest sample has the given peak amplitude. Silent audio is returned
+// unchanged.
+func Normalize(in []float32, peak float32) []float32 {
+	var loudest float32
+	for _, f := range in {
+		loudest = max(loudest, float32(math.Abs(float64(f))))
+	}
+	out := make([]float32, len(in))
+	if loudest == 0 {
+		copy(out, in)
+		return out
+	}
+	gain := peak / loudest
+	for i, f := range in {
+		out[i] = f * gain
+	}
+	return out
+}
+
+// Downmix converts interleaved multi-channel F32LE PCM audio to mono by averaging the channels of each sample. A
+// trailing incomplete sample is discarded. Mono audio is returned as a copy.
+func Downmix(in []float32, channels int) []float32 {
+	if channels <= 1 {
+		out := make([]float32, len(in))
+		copy(out, in)
+		return out
+	}
+	out := make([]float32, len(in)/channels)
+	for i := range out {
+		var sum float32
+		for _, f := range in[i*channels : (i+1)*channels] {
+			sum += f
+		}
+		out[i] = sum / float32(channels)
+	}
+	return out
+}
@@ -75,3 +75,18 @@ func TestS16toF32toS16RoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, []float32{0.25, -0.5, 0.125}, Normalize([]float32{0.5, -1, 0.25}, 0.5))
+	require.Equal(t, []float32{0.5, -1, 0.25}, Normalize([]float32{0.25, -0.5, 0.125}, 1))
+	require.Equal(t, []float32{0, 0}, Normalize([]float32{0, 0}, 1))
+}
+
+func TestDownmix(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, []float32{0.5, -0.25}, Downmix([]float32{0.5, -0.25}, 1))
+	require.Equal(t, []float32{0.5, 0, -0.75}, Downmix([]float32{1, 0, 0.5, -0.5, -0.5, -1}, 2))
+	require.Equal(t, []float32{0.25}, Downmix([]float32{0.5, 0, 1}, 2), "incomplete trailing sample should be dropped")
+	require.Empty(t, Downmix(nil, 2))
+}
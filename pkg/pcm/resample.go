@@ -0,0 +1,181 @@
+package pcm
+
+import "math"
+
+const (
+	// resamplerZeroCrossings is the number of zero crossings of the sinc filter on each side of its center. More zero
+	// crossings give a narrower transition band at the cost of more work per sample.
+	resamplerZeroCrossings = 32
+	// resamplerRolloff is the cutoff of the low-pass filter as a fraction of the lower of the two Nyquist frequencies.
+	// The cutoff is below the Nyquist frequency so that the transition band ends before it.
+	resamplerRolloff = 0.9
+	// resamplerTableResolution is the number of precomputed filter values per input sample. Values between them are
+	// linearly interpolated.
+	resamplerTableResolution = 512
+)
+
+// Resample converts F32LE PCM audio from one sample rate to another. See [Resampler].
+func Resample(in []float32, fromRate, toRate int) []float32 {
+	resampler := NewResampler(fromRate, toRate)
+	out := resampler.Write(in)
+	return append(out, resampler.Flush()...)
+}
+
+// Resampler converts a stream of F32LE PCM audio from one sample rate to another. It interpolates with a windowed sinc
+// filter whose cutoff is below the lower of the two Nyquist frequencies, so downsampling does not alias and upsampling
+// does not produce images. The filter's state is kept between writes, so a stream may be resampled in chunks of any
+// size without discontinuities at the chunk boundaries.
+//
+// The filter needs input samples on both sides of each output sample, so each write returns only the output samples
+// which can be computed so far. Flush returns the rest. A Resampler is not safe for concurrent use.
+type Resampler struct {
+	fromRate int
+	toRate   int
+	// halfWidth is the number of input samples on each side of an output sample which contribute to it.
+	halfWidth int
+	// table holds the filter's values at distances from its center in steps of 1/resamplerTableResolution input
+	// samples.
+	table []float64
+	// buffer holds the input samples which later output samples still need.
+	buffer []float32
+	// offset is the index in the stream of buffer[0].
+	offset int
+	// first is the first sample of the stream, which is repeated before the start of the stream.
+	first float32
+	// inputs is the number of input samples written.
+	inputs int
+	// outputs is the number of output samples returned.
+	outputs int
+}
+
+// NewResampler creates a Resampler which converts audio from fromRate to toRate.
+func NewResampler(fromRate, toRate int) *Resampler {
+	// The cutoff is relative to the input sample rate.
+	cutoff := resamplerRolloff * min(1, float64(toRate)/float64(fromRate))
+	halfWidth := int(math.Ceil(resamplerZeroCrossings / cutoff))
+	table := make([]float64, halfWidth*resamplerTableResolution+1)
+	for i := range table {
+		distance := float64(i) / resamplerTableResolution
+		table[i] = sinc(cutoff*distance) * blackman(distance/float64(halfWidth))
+	}
+	return &Resampler{
+		fromRate:  fromRate,
+		toRate:    toRate,
+		halfWidth: halfWidth,
+		table:     table,
+	}
+}
+
+// Write resamples the given samples and returns the output samples which can be computed from the samples written so
+// far.
+func (r *Resampler) Write(in []float32) []float32 {
+	if r.fromRate == r.toRate {
+		out := make([]float32, len(in))
+		copy(out, in)
+		return out
+	}
+	if len(in) == 0 {
+		return nil
+	}
+	if r.inputs == 0 {
+		r.first = in[0]
+	}
+	r.buffer = append(r.buffer, in...)
+	r.inputs += len(in)
+	return r.drain(r.inputs - r.halfWidth)
+}
+
+// Flush returns the remaining output samples, treating the last sample written as the end of the stream. Afterwards,
+// the Resampler may be reused for a new stream.
+func (r *Resampler) Flush() []float32 {
+	if r.fromRate == r.toRate || r.inputs == 0 {
+		return nil
+	}
+	// Repeat the last sample after the end of the stream.
+	last := r.buffer[len(r.buffer)-1]
+	for range r.halfWidth {
+		r.buffer = append(r.buffer, last)
+	}
+	total := int(int64(r.inputs) * int64(r.toRate) / int64(r.fromRate))
+	out := make([]float32, 0, max(0, total-r.outputs))
+	for r.outputs < total {
+		out = append(out, r.sample(r.time(r.outputs)))
+		r.outputs++
+	}
+	r.buffer = r.buffer[:0]
+	r.offset, r.inputs, r.outputs = 0, 0, 0
+	return out
+}
+
+// drain returns the output samples before the given position in the input stream, then discards the input samples
+// which no later output sample needs.
+func (r *Resampler) drain(limit int) []float32 {
+	var out []float32
+	for r.time(r.outputs) < float64(limit) {
+		out = append(out, r.sample(r.time(r.outputs)))
+		r.outputs++
+	}
+	if discard := int(r.time(r.outputs)) - r.halfWidth - r.offset; discard > 0 {
+		discard = min(discard, len(r.buffer))
+		r.buffer = append(r.buffer[:0], r.buffer[discard:]...)
+		r.offset += discard
+	}
+	return out
+}
+
+// time returns the position in the input stream of the output sample with the given index.
+func (r *Resampler) time(index int) float64 {
+	return float64(index) * float64(r.fromRate) / float64(r.toRate)
+}
+
+// sample computes the output sample at the given position in the input stream. The filter's weights are normalized so
+// that a constant signal is passed through unchanged.
+func (r *Resampler) sample(t float64) float32 {
+	center := int(math.Floor(t))
+	var sum, weights float64
+	for n := center - r.halfWidth + 1; n <= center+r.halfWidth; n++ {
+		weight := r.weight(math.Abs(t - float64(n)))
+		if weight == 0 {
+			continue
+		}
+		var value float32
+		if i := n - r.offset; n < 0 {
+			value = r.first
+		} else if i >= 0 && i < len(r.buffer) {
+			value = r.buffer[i]
+		}
+		sum += weight * float64(value)
+		weights += weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return float32(sum / weights)
+}
+
+// weight returns the filter's value at the given distance from its center, in input samples.
+func (r *Resampler) weight(distance float64) float64 {
+	position := distance * resamplerTableResolution
+	i := int(position)
+	if i >= len(r.table)-1 {
+		return 0
+	}
+	fraction := position - float64(i)
+	return r.table[i]*(1-fraction) + r.table[i+1]*fraction
+}
+
+// sinc is the normalized sinc function.
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman is the Blackman window, which is centered on 0 and reaches 0 at -1 and 1.
+func blackman(x float64) float64 {
+	if math.Abs(x) >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+}
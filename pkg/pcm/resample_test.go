@@ -0,0 +1,108 @@
+package pcm
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResample(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		fromRate       int
+		toRate         int
+		inLength       int
+		expectedLength int
+	}{
+		{16000, 16000, 1600, 1600},
+		{24000, 16000, 2400, 1600},
+		{48000, 16000, 4800, 1600},
+		{8000, 16000, 800, 1600},
+		{22050, 16000, 2205, 1600},
+	}
+	for _, test := range testCases {
+		t.Run(fmt.Sprintf("%d to %d", test.fromRate, test.toRate), func(t *testing.T) {
+			t.Parallel()
+			in := make([]float32, test.inLength)
+			for i := range in {
+				in[i] = 0.5
+			}
+			out := Resample(in, test.fromRate, test.toRate)
+			require.Len(t, out, test.expectedLength)
+			for _, f := range out {
+				require.InDelta(t, 0.5, f, 0.0001)
+			}
+		})
+	}
+}
+
+// tone returns a second of a sine wave with the given frequency and amplitude at the given sample rate.
+func tone(frequency float64, amplitude float32, sampleRate int) []float32 {
+	out := make([]float32, sampleRate)
+	for i := range out {
+		out[i] = amplitude * float32(math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate)))
+	}
+	return out
+}
+
+// rms returns the root mean square of the given samples, ignoring the given number of samples at each end.
+func rms(in []float32, margin int) float64 {
+	var sum float64
+	for _, f := range in[margin : len(in)-margin] {
+		sum += float64(f) * float64(f)
+	}
+	return math.Sqrt(sum / float64(len(in)-2*margin))
+}
+
+func TestResampleDoesNotAlias(t *testing.T) {
+	t.Parallel()
+	// 10 kHz is above the 8 kHz Nyquist frequency of 16 kHz audio, so it must be removed rather than folded to 6 kHz.
+	out := Resample(tone(10000, 1, 48000), 48000, 16000)
+	assert.Less(t, rms(out, 100), 0.001)
+}
+
+func TestResamplePreservesPassband(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		fromRate int
+		toRate   int
+	}{
+		{48000, 16000},
+		{16000, 48000},
+		{22050, 48000},
+	}
+	for _, test := range testCases {
+		t.Run(fmt.Sprintf("%d to %d", test.fromRate, test.toRate), func(t *testing.T) {
+			t.Parallel()
+			out := Resample(tone(1000, 0.5, test.fromRate), test.fromRate, test.toRate)
+			require.Len(t, out, test.toRate)
+			expected := tone(1000, 0.5, test.toRate)
+			for i := 100; i < len(out)-100; i++ {
+				require.InDelta(t, expected[i], out[i], 0.001)
+			}
+		})
+	}
+}
+
+func TestResamplerChunks(t *testing.T) {
+	t.Parallel()
+	in := tone(1000, 0.5, 24000)
+	expected := Resample(in, 24000, 16000)
+
+	resampler := NewResampler(24000, 16000)
+	var out []float32
+	for i := 0; i < len(in); i += 313 {
+		out = append(out, resampler.Write(in[i:min(i+313, len(in))])...)
+	}
+	out = append(out, resampler.Flush()...)
+	require.Len(t, out, len(expected))
+	for i := range out {
+		require.InDelta(t, expected[i], out[i], 1e-6)
+	}
+
+	// A flushed resampler starts a new stream.
+	assert.Equal(t, expected, append(resampler.Write(in), resampler.Flush()...))
+}
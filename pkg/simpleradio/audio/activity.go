@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/internal/callbacks"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+)
+
+// activityHoldTime is how long a radio is still considered active after a transmission ends. A new transmission from
+// the same origin within this time continues the same activity, so brief gaps in a transmitter's packets do not cause
+// a flurry of started and ended events.
+const activityHoldTime = 500 * time.Millisecond
+
+// TransmissionStarted is reported when a transmitter starts transmitting on one of the client's radios.
+type TransmissionStarted struct {
+	// Frequency is the frequency of the radio the transmission is received on.
+	Frequency unit.Frequency
+	// Origin is the GUID of the transmitter.
+	Origin types.GUID
+}
+
+// TransmissionEnded is reported when a transmitter stops transmitting on one of the client's radios.
+type TransmissionEnded struct {
+	// Frequency is the frequency of the radio the transmission was received on.
+	Frequency unit.Frequency
+	// Origin is the GUID of the transmitter.
+	Origin types.GUID
+	// Duration is the time from the first to the last packet of the transmission.
+	Duration time.Duration
+}
+
+// TransmissionStartedCallback is a callback function that is called when a transmitter starts transmitting on one of
+// the client's radios. A transmission heard on several radios is reported once for each radio. The callback is called
+// from the receiver goroutine, so it should return quickly.
+type TransmissionStartedCallback func(TransmissionStarted)
+
+// TransmissionEndedCallback is a callback function that is called when a transmitter stops transmitting on one of the
+// client's radios. It is called once the radio has been quiet for longer than the gap which ends a transmission plus a
+// short hold time, so that a transmitter which resumes immediately is not reported as stopping and starting again. It
+// is also called when a radio is removed while active. The callback is called from the receiver goroutine, or from
+// RemoveRadio, so it should return quickly.
+type TransmissionEndedCallback func(TransmissionEnded)
+
+// SetTransmissionStartedCallback implements [AudioClient.SetTransmissionStartedCallback].
+func (c *audioClient) SetTransmissionStartedCallback(callback TransmissionStartedCallback) {
+	c.transmissionStartedCallback.Store(&callback)
+}
+
+// SetTransmissionEndedCallback implements [AudioClient.SetTransmissionEndedCallback].
+func (c *audioClient) SetTransmissionEndedCallback(callback TransmissionEndedCallback) {
+	c.transmissionEndedCallback.Store(&callback)
+}
+
+// updateActivity reports the activity on the given radio which has started or ended since the last update.
+func (c *audioClient) updateActivity(radio types.Radio, r *receiver) {
+	ended, isEnded, started, isStarted := r.updateActivity(time.Now())
+	frequency := types.FrequencyFromHertz(radio.Frequency)
+	if callback := callbacks.Load(&c.transmissionEndedCallback); isEnded && callback != nil {
+		ended.Frequency = frequency
+		callback(ended)
+	}
+	if callback := callbacks.Load(&c.transmissionStartedCallback); isStarted && callback != nil {
+		started.Frequency = frequency
+		callback(started)
+	}
+}
+
+// endActivity reports the activity on a removed radio as ended.
+func (c *audioClient) endActivity(radio types.Radio, r *receiver) {
+	ended, isEnded := r.endActivity()
+	if callback := callbacks.Load(&c.transmissionEndedCallback); isEnded && callback != nil {
+		ended.Frequency = types.FrequencyFromHertz(radio.Frequency)
+		callback(ended)
+	}
+}
+
+// updateActivity updates the receiver's debounced activity at the given time. Activity ends when the receiver has been
+// quiet for longer than maxRxGap plus activityHoldTime, or when a different origin is heard. Activity starts when a
+// transmission is being received and the receiver is not already active for its origin. The returned events do not
+// have a frequency set.
+func (r *receiver) updateActivity(now time.Time) (ended TransmissionEnded, isEnded bool, started TransmissionStarted, isStarted bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.activeOrigin != "" {
+		if r.origin == r.activeOrigin {
+			r.activeLastPacket = r.lastPacket
+		}
+		isOriginChanged := r.origin != "" && r.origin != r.activeOrigin
+		isQuiet := now.Sub(r.activeLastPacket) > maxRxGap+activityHoldTime
+		if isOriginChanged || isQuiet {
+			ended, isEnded = r.endActivityLocked()
+		}
+	}
+	if r.activeOrigin == "" && r.origin != "" && r.deadline.After(now) {
+		r.activeOrigin = r.origin
+		r.activeSince = r.started
+		r.activeLastPacket = r.lastPacket
+		started, isStarted = TransmissionStarted{Origin: r.origin}, true
+	}
+	return
+}
+
+// endActivity ends the receiver's activity, if any.
+func (r *receiver) endActivity() (TransmissionEnded, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.endActivityLocked()
+}
+
+// endActivityLocked ends the receiver's activity, if any. The caller must hold the receiver's lock.
+func (r *receiver) endActivityLocked() (TransmissionEnded, bool) {
+	if r.activeOrigin == "" {
+		return TransmissionEnded{}, false
+	}
+	ended := TransmissionEnded{Origin: r.activeOrigin, Duration: r.activeLastPacket.Sub(r.activeSince)}
+	r.activeOrigin = ""
+	r.activeSince = time.Time{}
+	r.activeLastPacket = time.Time{}
+	return ended, true
+}
@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quietTime is long enough after the last packet for activity to end.
+const quietTime = maxRxGap + activityHoldTime + time.Millisecond
+
+func TestReceiverActivity(t *testing.T) {
+	t.Parallel()
+	alice, bob := types.NewGUID(), types.NewGUID()
+	r := &receiver{}
+
+	r.receive(&voice.VoicePacket{PacketID: 1, OriginGUID: []byte(alice)})
+	_, isEnded, started, isStarted := r.updateActivity(time.Now())
+	assert.False(t, isEnded)
+	require.True(t, isStarted)
+	assert.Equal(t, alice, started.Origin)
+
+	// Further packets continue the activity.
+	r.receive(&voice.VoicePacket{PacketID: 2, OriginGUID: []byte(alice)})
+	_, isEnded, _, isStarted = r.updateActivity(time.Now())
+	assert.False(t, isEnded)
+	assert.False(t, isStarted)
+
+	// A new transmission from the same origin within the hold time does not flap.
+	r.reset()
+	r.receive(&voice.VoicePacket{PacketID: 1, OriginGUID: []byte(alice)})
+	_, isEnded, _, isStarted = r.updateActivity(time.Now())
+	assert.False(t, isEnded)
+	assert.False(t, isStarted)
+
+	// Once the radio is quiet for long enough, the activity ends.
+	ended, isEnded, _, isStarted := r.updateActivity(time.Now().Add(quietTime))
+	require.True(t, isEnded)
+	assert.False(t, isStarted)
+	assert.Equal(t, alice, ended.Origin)
+	assert.GreaterOrEqual(t, ended.Duration, time.Duration(0))
+	_, isEnded, _, _ = r.updateActivity(time.Now().Add(quietTime))
+	assert.False(t, isEnded, "activity should only end once")
+
+	// A different origin ends the previous activity and starts its own, even within the hold time.
+	r.reset()
+	r.receive(&voice.VoicePacket{PacketID: 1, OriginGUID: []byte(alice)})
+	_, _, _, isStarted = r.updateActivity(time.Now())
+	require.True(t, isStarted)
+	r.reset()
+	r.receive(&voice.VoicePacket{PacketID: 1, OriginGUID: []byte(bob)})
+	ended, isEnded, started, isStarted = r.updateActivity(time.Now())
+	require.True(t, isEnded)
+	assert.Equal(t, alice, ended.Origin)
+	require.True(t, isStarted)
+	assert.Equal(t, bob, started.Origin)
+}
+
+func TestActivityCallbacks(t *testing.T) {
+	t.Parallel()
+	uhf := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	vhf := types.Radio{Frequency: 133000000, Modulation: types.ModulationAM}
+	c := &audioClient{
+		radios:             []types.Radio{uhf, vhf},
+		receivers:          map[types.Radio]*receiver{uhf: {}, vhf: {}},
+		frequencyTolerance: types.DefaultFrequencyTolerance,
+	}
+	var started []TransmissionStarted
+	var ended []TransmissionEnded
+	c.SetTransmissionStartedCallback(func(event TransmissionStarted) { started = append(started, event) })
+	c.SetTransmissionEndedCallback(func(event TransmissionEnded) { ended = append(ended, event) })
+
+	origin := types.NewGUID()
+	for _, radio := range c.radios {
+		c.receivers[radio].receive(&voice.VoicePacket{PacketID: 1, OriginGUID: []byte(origin)})
+		c.updateActivity(radio, c.receivers[radio])
+	}
+	assert.Equal(t, []TransmissionStarted{
+		{Frequency: 251 * unit.Megahertz, Origin: origin},
+		{Frequency: 133 * unit.Megahertz, Origin: origin},
+	}, started)
+	assert.Empty(t, ended)
+
+	// Removing an active radio ends its activity.
+	require.NoError(t, c.RemoveRadio(133*unit.Megahertz))
+	require.Len(t, ended, 1)
+	assert.Equal(t, 133*unit.Megahertz, ended[0].Frequency)
+	assert.Equal(t, origin, ended[0].Origin)
+}
@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+)
+
+// TransmittedOverCallback is a callback function that is called when the client transmits over an incoming transmission
+// because the clear channel timeout expired. The duration spent waiting for a clear channel and the GUID of the
+// colliding transmitter are provided. The GUID is empty if the colliding transmitter is not known.
+type TransmittedOverCallback func(waited time.Duration, origin types.GUID)
+
+// TransmissionReceivedCallback is a callback function that is called when a transmission has been received and decoded,
+// immediately before its audio is published to the receive channel. Callbacks are called in the same order that
+// transmissions are published, so the metadata can be matched to the audio from the receive channel.
+type TransmissionReceivedCallback func(TransmissionMetadata)
+
+// SetTransmissionReceivedCallback implements [AudioClient.SetTransmissionReceivedCallback].
+func (c *audioClient) SetTransmissionReceivedCallback(callback TransmissionReceivedCallback) {
+	c.transmissionReceivedCallback.Store(&callback)
+}
+
+// VoicePacketsCallback is a callback function that is called with the raw voice packets of each received transmission,
+// before they are decoded. Transmissions too short to be decoded are included. The callback is called from a separate
+// goroutine, and transmissions are dropped if the callback falls behind.
+type VoicePacketsCallback func([]voice.VoicePacket)
+
+// SetVoicePacketsCallback implements [AudioClient.SetVoicePacketsCallback].
+func (c *audioClient) SetVoicePacketsCallback(callback VoicePacketsCallback) {
+	c.voicePacketsCallback.Store(&callback)
+}
+
+// HotMicCallback is a callback function that is called when a single transmitter has held a radio channel for longer
+// than the configured hot mic threshold, which usually means their microphone is stuck keyed. It is called once per
+// transmission, with the radio, the transmitter's GUID and how long the transmission has lasted so far. The callback is
+// called from the receiver goroutine, so it should return quickly.
+type HotMicCallback func(radio types.Radio, origin types.GUID, duration time.Duration)
+
+// SetHotMicCallback implements [AudioClient.SetHotMicCallback].
+func (c *audioClient) SetHotMicCallback(callback HotMicCallback) {
+	c.hotMicCallback.Store(&callback)
+}
+
+// SetTransmittedOverCallback implements [AudioClient.SetTransmittedOverCallback].
+func (c *audioClient) SetTransmittedOverCallback(callback TransmittedOverCallback) {
+	c.transmittedOverCallback.Store(&callback)
+}
@@ -0,0 +1,41 @@
+package audio
+
+import (
+	"fmt"
+
+	"github.com/dharmab/skyeye/pkg/pcm"
+)
+
+// maxInputChannels is the largest number of interleaved channels accepted for transmission.
+const maxInputChannels = 2
+
+// validateInputChannels returns an error if audio with the given number of interleaved channels cannot be transmitted.
+func validateInputChannels(n int) error {
+	if n < 1 || n > maxInputChannels {
+		return fmt.Errorf("SRS voice is mono, so only mono or stereo audio can be transmitted, got %d channels", n)
+	}
+	return nil
+}
+
+// toMono downmixes audio with the configured number of input channels to mono.
+func (c *audioClient) toMono(sample []float32) Audio {
+	if c.inputChannels <= channels {
+		return sample
+	}
+	return pcm.Downmix(sample, c.inputChannels)
+}
+
+// toTransmitFormat downmixes audio with the configured number of input channels to mono, and resamples it from the
+// configured input sample rate to the SRS sample rate.
+func (c *audioClient) toTransmitFormat(sample []float32) Audio {
+	return toSampleRate(c.toMono(sample), c.inputSampleRate)
+}
+
+// toSampleRate resamples mono audio from the given sample rate to the SRS sample rate. Frequencies above the Nyquist
+// frequency of the SRS sample rate are filtered out rather than aliased. Zero means the SRS sample rate.
+func toSampleRate(mono Audio, inputRate int) Audio {
+	if inputRate == 0 || inputRate == sampleRate {
+		return mono
+	}
+	return pcm.Resample(mono, inputRate, sampleRate)
+}
@@ -0,0 +1,51 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToTransmitFormat(t *testing.T) {
+	t.Parallel()
+	const tone = 440
+	for _, rate := range []int{8000, 16000, 22050, 48000} {
+		t.Run(fmt.Sprintf("%dHz", rate), func(t *testing.T) {
+			t.Parallel()
+			client := &audioClient{inputChannels: channels, inputSampleRate: rate}
+			in := make([]float32, rate)
+			for i := range in {
+				in[i] = float32(math.Sin(2 * math.Pi * tone * float64(i) / float64(rate)))
+			}
+			out := client.toTransmitFormat(in)
+
+			// One second of input is one second of output, at the same pitch.
+			assert.InDelta(t, sampleRate, len(out), 2)
+			crossings := 0
+			for i := 1; i < len(out); i++ {
+				if (out[i-1] < 0) != (out[i] < 0) {
+					crossings++
+				}
+			}
+			assert.InDelta(t, 2*tone, crossings, 4)
+		})
+	}
+}
+
+func TestToTransmitFormatDoesNotAlias(t *testing.T) {
+	t.Parallel()
+	// 12 kHz is above the Nyquist frequency of the SRS sample rate, so it must be filtered out rather than folded into
+	// the voice band.
+	const rate = 48000
+	client := &audioClient{inputChannels: channels, inputSampleRate: rate}
+	in := make([]float32, rate)
+	for i := range in {
+		in[i] = float32(math.Sin(2 * math.Pi * 12000 * float64(i) / rate))
+	}
+	out := client.toTransmitFormat(in)
+	for _, f := range out[100 : len(out)-100] {
+		assert.InDelta(t, 0, f, 0.01)
+	}
+}
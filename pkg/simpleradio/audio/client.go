@@ -4,17 +4,35 @@ package audio
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"net"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/dharmab/skyeye/pkg/pcm"
 	"github.com/dharmab/skyeye/pkg/simpleradio/types"
 	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
 	"github.com/martinlindhe/unit"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// ErrCoalitionOverrideUnsupported is returned when a transmission is requested for a coalition other than the client's
+// own. The SRS protocol does not support per-transmission coalition scoping.
+var ErrCoalitionOverrideUnsupported = errors.New("SRS does not support transmitting to a coalition other than the client's own")
+
+// captureBufferSize is the number of captured transmissions which may be buffered for the consumer.
+const captureBufferSize = 16
+
+// voicePacketsBufferSize is the number of received transmissions which may be queued for the voice packets callback.
+const voicePacketsBufferSize = 16
+
 // Audio is a type alias for F32LE PCM data.
 type Audio []float32
 
@@ -22,88 +40,586 @@ type Audio []float32
 type AudioClient interface {
 	// Frequencies returns the SRS frequencies this client is configured to receive and transmit on in Hz.
 	Frequencies() []unit.Frequency
+	// Radios returns a copy of the radios this client is configured to receive and transmit on.
+	Radios() []types.Radio
+	// AddRadio starts receiving and transmitting on another radio, without disturbing transmissions being received on
+	// the existing radios. A transmission already in progress on the new radio's frequency is received from its next
+	// packet. An error is returned if the client already has the maximum number of radios or a radio on the same
+	// frequency.
+	AddRadio(types.Radio) error
+	// RemoveRadio stops receiving and transmitting on the radios tuned to the given frequency, and clears their mute,
+	// receive and idle fill settings. A transmission being received on a removed radio is discarded, although it is
+	// still received on any remaining radio which is hearing it. Transmissions already encoded are still sent on the
+	// radios they were encoded for. An error is returned if no radio is tuned to the frequency.
+	RemoveRadio(unit.Frequency) error
 	// Run executes the control loops of the SRS audio client. It should be called exactly once. When the context is canceled or if the client encounters a non-recoverable error, the client will close its resources.
-	Run(context.Context, *sync.WaitGroup) error
-	// Transmit queues the given audio to play on the audio client's SRS frequency.
+	// The given channel will be closed when the client is ready, after the first ping round-trip to the server. If the
+	// round-trip does not complete within the handshake timeout, Run returns an error wrapping [ErrHandshakeTimeout].
+	Run(context.Context, *sync.WaitGroup, chan<- any) error
+	// Transmit queues the given audio to play on the audio client's SRS frequency. If the client is configured with
+	// stereo input, the interleaved audio is downmixed to mono. If the client is configured with an input sample rate
+	// other than the SRS sample rate, the audio is resampled. It is safe to call from multiple goroutines. Each call
+	// is a single transmission which is encoded and sent in full before the next transmission begins, so concurrent
+	// transmissions never interleave. Transmissions are sent in the order the queue accepts them, which is FIFO for
+	// calls from the same goroutine. Transmit blocks until the queue accepts the audio.
 	Transmit(Audio)
-	// Receive returns a channel which receives audio from the audio client's SRS frequency.
+	// TransmitWithID is like Transmit, but de-duplicates transmissions by the given caller-supplied ID. If a
+	// transmission with the same ID is already queued or being transmitted, the audio is dropped. Once a transmission
+	// has been sent, its ID may be used again. An empty ID disables de-duplication.
+	TransmitWithID(id string, audio Audio)
+	// TransmitToCoalition queues the given audio to play on the audio client's SRS frequency, heard only by the given
+	// coalition. SRS voice packets carry no coalition information; the server scopes every transmission by the
+	// coalition the client declared over the data protocol. An override to any other coalition therefore returns
+	// [ErrCoalitionOverrideUnsupported] instead of transmitting.
+	TransmitToCoalition(Audio, coalitions.Coalition) error
+	// TransmitTone synthesizes the given tones, such as a beep or DTMF digits, and queues them as a single
+	// transmission on only the radios tuned to the given frequency. The transmission waits for a clear channel and
+	// respects mute like any other transmission. An error is returned if no radio is tuned to the frequency or the
+	// tones have no duration.
+	TransmitTone(unit.Frequency, []Tone) error
+	// TransmitSilence queues silence of the given duration, which keys up and holds the channel without speaking. A
+	// voice packet is sent for every frame, so peers see the client transmitting throughout. The silence continues the
+	// transmission queued before it, and the next transmission queued continues the silence, so that spoken segments
+	// separated by silence are sent as a single transmission without the usual pause or wait for a clear channel
+	// between them. If nothing was queued before it, the silence waits for a clear channel and respects mute like any
+	// other transmission. An error is returned if the duration is shorter than a single frame.
+	TransmitSilence(time.Duration) error
+	// Speak downmixes the given F32LE PCM audio to mono if needed, resamples it from the given sample rate to the SRS
+	// sample rate, optionally normalizes its volume, and queues it to play on the audio client's SRS frequency.
+	Speak(sample []float32, sampleRate int, normalize bool) error
+	// TransmitReader reads S16LE PCM audio at the given sample rate and with the configured number of input channels
+	// from the reader, and streams it to the audio client's SRS frequency as a single transmission, until the reader
+	// returns [io.EOF]. Each frame is sent as soon as it has been read, so a slow reader delays the transmission rather
+	// than splitting it. Queued transmissions wait until the stream ends. If the client is muted, the audio is read and discarded. TransmitReader returns early if
+	// the context is canceled between frames, but a blocked read is not interrupted.
+	TransmitReader(ctx context.Context, r io.Reader, sampleRate int) error
+	// ReleaseTransmissions ends the startup grace period, so that deferred transmissions are sent. It should be called
+	// once the client's peers have been synced. It is safe to call more than once.
+	ReleaseTransmissions()
+	// SetTransmitPermitted sets whether the SRS server permits the client to transmit. While transmission is not
+	// permitted, the client listens but does not transmit, as if muted. Transmission is permitted by default.
+	SetTransmitPermitted(bool)
+	// Reconnect replaces the UDP connection to the SRS server with a new connection to the same address, such as after
+	// a NAT mapping expired, and pings the server from it. Unless the client is configured to preserve receiver state,
+	// the transmissions being received and waiting to be decoded are discarded, so that packets from before the
+	// reconnection are not mixed with packets after it. If the new connection cannot be dialed, the existing connection
+	// is kept and an error is returned. An error is also returned if the client is closed.
+	Reconnect(context.Context) error
+	// SetGUID changes the GUID the client identifies itself with, such as after the data client reconnected with a new
+	// GUID. Packets sent afterwards carry the new GUID; call Reconnect afterwards so that the server associates the new
+	// GUID with a connection.
+	SetGUID(types.GUID)
+	// Suspend stops the client from transmitting and discards the transmissions which are queued but not yet encoded.
+	// Transmissions queued while suspended are discarded instead of sent. Receiving is unaffected. It is safe to call
+	// more than once.
+	Suspend()
+	// Resume undoes Suspend, so that new transmissions are sent again. Transmissions discarded while suspended are not
+	// restored.
+	Resume()
+	// SetMutedOn mutes or unmutes transmission on the radios tuned to the given frequency, while the client continues to
+	// transmit on its other radios. It takes effect from the next transmission. If the client is muted by its
+	// configuration, it does not transmit on any frequency regardless. If every radio is muted, transmissions are
+	// discarded. Receiving is unaffected unless the client is configured to suppress receiving while muted. An error is
+	// returned if no radio is tuned to the frequency.
+	SetMutedOn(unit.Frequency, bool) error
+	// SetReceiveEnabled enables or disables processing of audio received on the radios tuned to the given frequency.
+	// While disabled, transmissions heard only on those radios are dropped before decoding, which saves the CPU cost
+	// of Opus decoding and of any downstream processing such as speech recognition. The radios are still advertised
+	// to the SRS server, so peers see the client monitoring the frequency, and receiver state is still tracked so that
+	// the client waits for a clear channel before transmitting. Receive is enabled by default. An error is returned if
+	// no radio is tuned to the frequency.
+	SetReceiveEnabled(unit.Frequency, bool) error
+	// SetIdleFill sets the audio published for the given frequency while nothing is being received on it. Idle fill
+	// is published at the frame cadence, both to the receive channel and to receive streams on the frequency, so that
+	// consumers which expect a continuous stream stay fed. If idle fill is enabled on several frequencies, the receive
+	// channel receives a frame for each idle frequency. Idle fill frames are dropped if the consumer is not ready for
+	// them. By default, nothing is published while idle. An error is returned if no radio is tuned to the frequency.
+	SetIdleFill(unit.Frequency, IdleFill) error
+	// CaptureTransmissions returns a channel which receives a copy of the audio of each transmission, before it is
+	// encoded. Capture must be enabled in the client configuration; otherwise the returned channel is nil. If the
+	// consumer falls behind, captured transmissions are dropped rather than delaying transmission.
+	CaptureTransmissions() <-chan Audio
+	// Receive returns a channel which receives audio from the audio client's SRS frequency. If the consumer falls behind,
+	// received transmissions are buffered up to the configured receive buffer size, after which decoding blocks until
+	// the consumer catches up.
 	Receive() <-chan Audio
+	// ReceivePCM returns a channel which receives audio from the audio client's SRS frequency as raw PCM bytes in the
+	// configured receive format. It only receives audio if the receive format is not F32LE; otherwise, audio is
+	// published to Receive instead. It is buffered in the same way as Receive.
+	ReceivePCM() <-chan []byte
+	// ReceiveReader returns a stream of the audio received on the given frequency, as 16kHz mono S16LE PCM. Each
+	// received transmission is written to the stream in full, with no silence between transmissions. If the consumer
+	// falls behind, received transmissions are buffered up to a small limit, after which they are dropped for that
+	// stream. The stream returns [io.EOF] once the client is closed. Closing the stream discards unread audio.
+	ReceiveReader(unit.Frequency) io.ReadCloser
 	LastPing() time.Time
+	// CodecInfo returns the effective Opus codec parameters used by the client.
+	CodecInfo() CodecInfo
+	// CodecPanics returns the number of frames which were skipped because the Go bindings for the Opus codec panicked
+	// while encoding or decoding them. See [ErrCodecPanic].
+	CodecPanics() uint64
+	// Throughput returns the client's network throughput. The instantaneous rates are updated every few seconds.
+	Throughput() Throughput
+	// LocalAddr returns the local address of the UDP connection to the SRS server, including the ephemeral source port
+	// chosen when dialing. This is useful for diagnosing firewall and NAT issues.
+	LocalAddr() net.Addr
+	// Stats returns a snapshot of the client's packet counters, queue depth and throughput. It takes no locks except
+	// briefly to read the throughput, so it is cheap enough to poll frequently.
+	Stats() ClientStats
+	// SenderStats returns a snapshot of the decode statistics of the transmissions received from the sender with the
+	// given GUID. Statistics are forgotten once a sender has not been heard for 30 minutes, after which, as for an
+	// unknown sender, the zero value is returned.
+	SenderStats(types.GUID) SenderStats
+	// ReceiverStates returns a snapshot of the receiver state of each configured radio, in the configured order.
+	ReceiverStates() []ReceiverState
+	// SetTransmittedOverCallback sets the callback function to be called when the client transmits over an incoming transmission.
+	// Callbacks may be set or replaced at any time, including while the client is running.
+	SetTransmittedOverCallback(TransmittedOverCallback)
+	// SetTransmissionReceivedCallback sets the callback function to be called with the metadata of each received transmission.
+	SetTransmissionReceivedCallback(TransmissionReceivedCallback)
+	// SetVoicePacketsCallback sets the callback function to be called with the raw voice packets of each received transmission.
+	SetVoicePacketsCallback(VoicePacketsCallback)
+	// SetHotMicCallback sets the callback function to be called when a transmitter holds a channel longer than the hot mic threshold.
+	SetHotMicCallback(HotMicCallback)
+	// SetTransmissionStartedCallback sets the callback function to be called when a transmitter starts transmitting on one of the client's radios.
+	SetTransmissionStartedCallback(TransmissionStartedCallback)
+	// SetTransmissionEndedCallback sets the callback function to be called when a transmitter stops transmitting on one of the client's radios.
+	SetTransmissionEndedCallback(TransmissionEndedCallback)
+	// Shutdown stops accepting new transmissions, waits for queued and streaming transmissions to be sent, then closes
+	// the client. Transmissions which are still pending when the context is done are abandoned, and an error wrapping
+	// the context's error is returned. Transmissions attempted after Shutdown is called are dropped, and Speak and
+	// TransmitReader return [ErrShuttingDown].
+	Shutdown(context.Context) error
+	// Close stops the client and closes its UDP connection, without needing to cancel the context passed to Run. It is safe to call more than once.
+	Close() error
 }
 
 // audioClient implements AudioClient.
 type audioClient struct {
-	// guid is used to identify this client to the SRS server.
+	// guid is used to identify this client to the SRS server. It is replaced by SetGUID. Use currentGUID to read it.
 	guid types.GUID
-	// radio is the SRS radio this client will receive and transmit on.
+	// guidLock protects guid.
+	guidLock sync.RWMutex
+	// coalition is the coalition this client declared to the SRS server.
+	coalition coalitions.Coalition
+	// radios are the SRS radios this client will receive and transmit on. The slice is replaced rather than modified
+	// when radios are added or removed. Use radioList to read it.
 	radios []types.Radio
-	// connection is the UDP connection to the SRS server.
-	connection *net.UDPConn // todo move connection mgmt into Run()
+	// radiosLock protects radios and receivers.
+	radiosLock sync.RWMutex
+	// connection is the UDP connection to the SRS server. It is replaced by Reconnect. Use conn to read it.
+	connection types.AudioTransport // todo move connection mgmt into Run()
+	// isConnectionClosed is set once the connection is closed for good, after which Reconnect fails.
+	isConnectionClosed bool
+	// connectionLock protects connection and isConnectionClosed.
+	connectionLock sync.RWMutex
+	// openTransport opens the connection to the SRS server.
+	openTransport types.AudioTransportFactory
+	// network is the network passed to openTransport, such as "udp" or "udp4".
+	network string
+	// address is the address of the SRS server passed to openTransport.
+	address string
+	// udpReadBufferSize is the requested size of the UDP socket's receive buffer. Zero means the operating system
+	// default.
+	udpReadBufferSize int
+	// preserveReceivers keeps the receivers' state when the client reconnects.
+	preserveReceivers bool
+	// serverAddress is the address of the SRS server. Packets received from any other address are dropped.
+	serverAddress net.Addr
+	// strayPackets counts packets received from addresses other than serverAddress.
+	strayPackets atomic.Uint64
+	// throughput measures the bytes sent and received over connection.
+	throughput throughputMeter
+	// packets counts the packets sent and received over connection.
+	packets packetCounters
+	// codecPanics counts the frames which were skipped because the Opus codec panicked.
+	codecPanics atomic.Uint64
+	// decodeErrors counts the received frames which could not be decoded.
+	decodeErrors atomic.Uint64
+	// senders are the decode statistics of each sender heard within the sender statistics TTL. It is nil until a
+	// transmission is decoded.
+	senders map[types.GUID]*senderStats
+	// sendersLock protects senders.
+	sendersLock sync.Mutex
 	// rxChan is a channel where received audio is published. A read-only version is available publicly.
 	rxchan chan Audio
-	// txChan is a channel where audio to be transmitted is buffered.
-	txChan chan Audio
+	// rxPCMChan receives decoded audio encoded in receiveFormat, if receiveFormat is not F32LE.
+	rxPCMChan chan []byte
+	// receiveFormat is the PCM format of received audio.
+	receiveFormat pcm.Format
+	// captureCh is a channel where copies of transmitted audio are published, if capture is enabled.
+	captureCh chan Audio
+	// txChan is a channel where audio to be transmitted is buffered. It is consumed by a single encoder goroutine,
+	// which publishes each transmission whole to a single transmitter goroutine, so transmissions never interleave.
+	txChan chan transmission
+	// pendingTransmissions counts the transmissions which have been queued or are being streamed, and have not yet
+	// been sent or discarded.
+	pendingTransmissions atomic.Int64
+	// isShuttingDown is set by Shutdown, after which new transmissions are rejected.
+	isShuttingDown bool
+	// shutdownLock protects isShuttingDown, so that no transmission is counted as pending after Shutdown begins.
+	shutdownLock sync.RWMutex
+	// pendingIDs are the IDs of queued transmissions which have not yet been sent.
+	pendingIDs map[string]struct{}
+	// pendingIDsLock protects pendingIDs.
+	pendingIDsLock sync.Mutex
 
 	// lastPing tracks the last time a ping was received so we can tell when the server is (probably) restarted or offline.
-	lastPing time.Time
+	// It is stored atomically so that it can be polled while the receiver updates it, and as a [time.Time] so that it
+	// keeps its monotonic clock reading.
+	lastPing atomic.Pointer[time.Time]
+	// pingInterval is how often the client pings the SRS server.
+	pingInterval time.Duration
+	// handshakeTimeout is the maximum time to wait for the first ping round-trip. Zero or negative means wait
+	// indefinitely.
+	handshakeTimeout time.Duration
 
-	// receivers tracks the state of each radio we are listening to.
+	// receivers tracks the state of each radio we are listening to. The map is replaced rather than modified when
+	// radios are added or removed. Use receiverMap to read it.
 	receivers map[types.Radio]*receiver
 	// packetNumber is incremented for each voice packet transmitted.
 	packetNumber uint64
+	// packetNumberLock protects packetNumber, which is incremented by both queued and streamed transmissions.
+	packetNumberLock sync.Mutex
 
 	// busy indicates if there is a transmission in progress.
 	busy sync.Mutex
 
 	// mute suppresses audio transmission.
 	mute bool
+	// muteSuppressesReceive suppresses received audio on radios whose transmission is muted.
+	muteSuppressesReceive bool
+	// isTransmitForbidden suppresses audio transmission while the server does not permit the client to transmit.
+	isTransmitForbidden atomic.Bool
+	// isSuspended suppresses audio transmission while the client is suspended.
+	isSuspended atomic.Bool
+	// isNextContinued is set after silence is queued, so that the next queued transmission continues the silence.
+	isNextContinued atomic.Bool
+	// mutedRadios are the radios which are excluded from transmissions. It is nil until a radio is muted.
+	mutedRadios map[types.Radio]bool
+	// muteLock protects mutedRadios.
+	muteLock sync.RWMutex
+
+	// receiveLossRate is the fraction of received voice packets dropped to simulate packet loss.
+	receiveLossRate float64
+	// transmitLossRate is the fraction of transmitted voice packets dropped to simulate packet loss.
+	transmitLossRate float64
+	// receiveDisabledRadios are the radios whose received transmissions are not decoded. It is nil until a radio's
+	// receive is disabled.
+	receiveDisabledRadios map[types.Radio]bool
+	// receiveDisabledLock protects receiveDisabledRadios.
+	receiveDisabledLock sync.RWMutex
+
+	// random is the source of randomness for the pause between transmissions. If nil, the global source is used.
+	random *rand.Rand
+	// startupGrace is the maximum time to defer transmissions after Run starts. Zero or negative means transmissions
+	// are not deferred.
+	startupGrace time.Duration
+	// releasedCh is closed when the startup grace period ends.
+	releasedCh chan struct{}
+	// releaseOnce ensures releasedCh is only closed once.
+	releaseOnce sync.Once
+
+	// clearChannelTimeout is the maximum time to wait for a clear channel before transmitting. Zero means wait indefinitely.
+	clearChannelTimeout time.Duration
+	// transmittedOverCallback is called when the client transmits over an incoming transmission.
+	transmittedOverCallback atomic.Pointer[TransmittedOverCallback]
+	// transmissionReceivedCallback is called when a transmission is received.
+	transmissionReceivedCallback atomic.Pointer[TransmissionReceivedCallback]
+	// voicePacketsCallback is called with the raw voice packets of each received transmission.
+	voicePacketsCallback atomic.Pointer[VoicePacketsCallback]
+	// decoderResetThreshold is the number of consecutive missing voice packets after which the decoder is reset.
+	decoderResetThreshold int
+	// frequencyTolerance is the tolerance used to match received transmissions to this client's radios.
+	frequencyTolerance unit.Frequency
+	// hotMicThreshold is the duration after which a transmission is reported as a hot mic. Zero disables detection.
+	hotMicThreshold time.Duration
+	// hotMicCallback is called when a hot mic is detected.
+	hotMicCallback atomic.Pointer[HotMicCallback]
+	// transmissionStartedCallback is called when a transmitter starts transmitting on one of the client's radios.
+	transmissionStartedCallback atomic.Pointer[TransmissionStartedCallback]
+	// transmissionEndedCallback is called when a transmitter stops transmitting on one of the client's radios.
+	transmissionEndedCallback atomic.Pointer[TransmissionEndedCallback]
+	// voicePacketsCh queues received voice packets for voicePacketsCallback.
+	voicePacketsCh chan []voice.VoicePacket
+	// decodeCh queues received transmissions for the decoders.
+	decodeCh chan []voice.VoicePacket
+	// decodeOverflow selects what happens to a received transmission when decodeCh is full.
+	decodeOverflow types.DecodeOverflowPolicy
+	// decodeDrops counts the transmissions dropped because decodeCh was full.
+	decodeDrops atomic.Uint64
+	// captureDrops counts the captured transmissions dropped because captureCh was full.
+	captureDrops atomic.Uint64
+	// maxDecoders is the number of decoder goroutines.
+	maxDecoders int
+	// publishLock ensures only one decoder publishes a transmission at a time.
+	publishLock sync.Mutex
+
+	// closeCh is closed when Close is called, which stops Run.
+	closeCh chan struct{}
+	// closeOnce ensures Close only takes effect once.
+	closeOnce sync.Once
+	// teardownOnce ensures the connection is only closed once, whether by Close or by Run returning.
+	teardownOnce sync.Once
+
+	// pacingStrategy selects how the client waits between voice packets while transmitting.
+	pacingStrategy types.PacingStrategy
+	// frameLength is the duration of audio in each transmitted Opus frame.
+	frameLength time.Duration
+	// frameSize is the number of samples in each transmitted Opus frame.
+	frameSize int
+	// inputChannels is the number of interleaved channels in audio passed to the client for transmission.
+	inputChannels int
+	// inputSampleRate is the sample rate of audio passed to the client for transmission. Zero means the SRS sample rate.
+	inputSampleRate int
+	// fadeDuration is the duration of the fade-in and fade-out applied to each queued transmission. Zero disables fading.
+	fadeDuration time.Duration
+	// isDTXEnabled is true if Opus discontinuous transmission is enabled on the encoder.
+	isDTXEnabled bool
+	// codecInfo is the effective codec parameters, queried once when the client is created.
+	codecInfo CodecInfo
+
+	// idleFills are the idle fills of radios with idle fill enabled. It is nil until idle fill is enabled.
+	idleFills map[types.Radio]IdleFill
+	// idleFillsLock protects idleFills.
+	idleFillsLock sync.RWMutex
+
+	// streams are the open receive streams.
+	streams map[*receiveStream]struct{}
+	// streamsClosed is set when the client is closed, after which no new streams are published to.
+	streamsClosed bool
+	// streamsLock protects streams and streamsClosed.
+	streamsLock sync.RWMutex
+
+	// receiveLogger logs the receipt and decoding of transmissions.
+	receiveLogger zerolog.Logger
+	// transmitLogger logs the encoding and transmission of audio.
+	transmitLogger zerolog.Logger
+	// pingLogger logs UDP pings.
+	pingLogger zerolog.Logger
 }
 
 func NewClient(guid types.GUID, config types.ClientConfiguration) (AudioClient, error) {
-	log.Info().Str("protocol", "udp").Str("address", config.Address).Msg("connecting to SRS server")
-	address, err := net.ResolveUDPAddr("udp", config.Address)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve SRS server address %v: %w", config.Address, err)
+	frameLength := config.FrameLength
+	if frameLength == 0 {
+		frameLength = defaultFrameLength
+	}
+	if err := validateFrameLength(frameLength); err != nil {
+		return nil, fmt.Errorf("invalid frame length %v: %w", frameLength, err)
+	}
+	inputChannels := config.InputChannels
+	if inputChannels == 0 {
+		inputChannels = channels
+	}
+	if err := validateInputChannels(inputChannels); err != nil {
+		return nil, fmt.Errorf("invalid input channels: %w", err)
+	}
+	inputSampleRate := config.InputSampleRate
+	if inputSampleRate == 0 {
+		inputSampleRate = sampleRate
+	}
+	if inputSampleRate < 0 {
+		return nil, fmt.Errorf("input sample rate must be positive, got %d", inputSampleRate)
+	}
+	if config.SuppressReceiveWhenMuted && config.ListenAll {
+		return nil, errors.New("suppressing receive when muted cannot be combined with listen-all mode, which never transmits")
+	}
+	if config.FadeDuration < 0 {
+		return nil, fmt.Errorf("fade duration must not be negative, got %v", config.FadeDuration)
+	}
+	if err := validatePacketLoss(config.Debug); err != nil {
+		return nil, fmt.Errorf("invalid packet loss simulation: %w", err)
+	}
+	if config.Debug.SimulatePacketLoss {
+		log.Warn().
+			Float64("receiveLossRate", config.Debug.ReceiveLossRate).
+			Float64("transmitLossRate", config.Debug.TransmitLossRate).
+			Msg("simulating packet loss; this is for testing only and must not be used in production")
+	}
+	if err := validatePacingStrategy(config.PacingStrategy); err != nil {
+		return nil, fmt.Errorf("invalid pacing strategy: %w", err)
+	}
+	if config.UDPReadBufferSize < 0 {
+		return nil, fmt.Errorf("UDP read buffer size must not be negative, got %d", config.UDPReadBufferSize)
+	}
+	if config.ReceiveBufferSize < 0 {
+		return nil, fmt.Errorf("receive buffer size must not be negative, got %d", config.ReceiveBufferSize)
+	}
+	if err := config.ReceiveFormat.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid receive format: %w", err)
+	}
+	maxDecoders := config.MaxConcurrentDecoders
+	if maxDecoders == 0 {
+		maxDecoders = 1
+	}
+	if maxDecoders < 0 {
+		return nil, fmt.Errorf("max concurrent decoders must not be negative, got %d", config.MaxConcurrentDecoders)
 	}
-	connection, err := net.DialUDP("udp", nil, address)
+	decodeQueueSize := config.DecodeQueueSize
+	if decodeQueueSize == 0 {
+		decodeQueueSize = defaultDecodeQueueSize
+	}
+	if decodeQueueSize < 0 {
+		return nil, fmt.Errorf("decode queue size must not be negative, got %d", config.DecodeQueueSize)
+	}
+	if err := validateDecodeOverflow(config.DecodeOverflow); err != nil {
+		return nil, fmt.Errorf("invalid decode overflow policy: %w", err)
+	}
+	frequencyTolerance := config.FrequencyTolerance
+	if frequencyTolerance == 0 {
+		frequencyTolerance = types.DefaultFrequencyTolerance
+	}
+	if frequencyTolerance < 0 {
+		return nil, fmt.Errorf("frequency tolerance must not be negative, got %v", config.FrequencyTolerance)
+	}
+	if config.HotMicThreshold < 0 {
+		return nil, fmt.Errorf("hot mic threshold must not be negative, got %v", config.HotMicThreshold)
+	}
+	decoderResetThreshold := config.DecoderResetThreshold
+	if decoderResetThreshold == 0 {
+		decoderResetThreshold = defaultDecoderResetThreshold
+	}
+	if decoderResetThreshold < 0 {
+		return nil, fmt.Errorf("decoder reset threshold must not be negative, got %d", config.DecoderResetThreshold)
+	}
+	pingInterval := config.PingInterval
+	if pingInterval == 0 {
+		pingInterval = defaultPingInterval
+	}
+	if err := validatePingInterval(pingInterval); err != nil {
+		return nil, fmt.Errorf("invalid ping interval %v: %w", pingInterval, err)
+	}
+
+	if err := config.AddressFamily.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid address family: %w", err)
+	}
+	network := config.AddressFamily.Network("udp")
+	log.Info().Str("protocol", network).Str("address", config.Address).Msg("connecting to SRS server")
+	openTransport := config.AudioTransport
+	if openTransport == nil {
+		var dialer types.UDPDialer = types.NewDefaultDialer(config.ConnectionTimeout)
+		if config.UDPDialer != nil {
+			dialer = config.UDPDialer
+		}
+		openTransport = func(ctx context.Context, network, address string) (types.AudioTransport, error) {
+			return dialer.DialContext(ctx, network, address)
+		}
+	}
+	connection, err := dialUDP(context.Background(), openTransport, network, config.Address, config.UDPReadBufferSize)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to SRS server %v over UDP: %w", config.Address, err)
+		return nil, err
+	}
+	var captureCh chan Audio
+	if config.CaptureTransmissions {
+		captureCh = make(chan Audio, captureBufferSize)
 	}
 	receivers := make(map[types.Radio]*receiver, len(config.Radios))
 	for _, radio := range config.Radios {
 		receivers[radio] = &receiver{}
 	}
-	return &audioClient{
-		guid:         guid,
-		radios:       config.Radios,
-		connection:   connection,
-		txChan:       make(chan Audio),
-		rxchan:       make(chan Audio),
-		receivers:    receivers,
-		packetNumber: 1,
-		busy:         sync.Mutex{},
-		mute:         config.Mute,
-		lastPing:     time.Now(),
-	}, nil
+	client := &audioClient{
+		guid:                  guid,
+		coalition:             config.Coalition,
+		radios:                config.Radios,
+		connection:            connection,
+		openTransport:         openTransport,
+		network:               network,
+		address:               config.Address,
+		udpReadBufferSize:     config.UDPReadBufferSize,
+		preserveReceivers:     config.PreserveReceiversOnReconnect,
+		serverAddress:         connection.RemoteAddr(),
+		txChan:                make(chan transmission),
+		captureCh:             captureCh,
+		rxchan:                make(chan Audio, config.ReceiveBufferSize),
+		rxPCMChan:             make(chan []byte, config.ReceiveBufferSize),
+		receiveFormat:         config.ReceiveFormat,
+		receivers:             receivers,
+		packetNumber:          1,
+		busy:                  sync.Mutex{},
+		mute:                  config.Mute || config.ListenAll,
+		muteSuppressesReceive: config.SuppressReceiveWhenMuted,
+		pingInterval:          pingInterval,
+		handshakeTimeout:      config.HandshakeTimeout,
+		clearChannelTimeout:   config.ClearChannelTimeout,
+		startupGrace:          config.StartupGrace,
+		random:                config.Random,
+		receiveLossRate:       config.Debug.ReceiveLossRate,
+		transmitLossRate:      config.Debug.TransmitLossRate,
+		releasedCh:            make(chan struct{}),
+		hotMicThreshold:       config.HotMicThreshold,
+		frequencyTolerance:    frequencyTolerance,
+		decoderResetThreshold: decoderResetThreshold,
+		frameLength:           frameLength,
+		pacingStrategy:        config.PacingStrategy,
+		frameSize:             frameSizeOf(frameLength),
+		isDTXEnabled:          config.DiscontinuousTransmission,
+		inputChannels:         inputChannels,
+		inputSampleRate:       inputSampleRate,
+		fadeDuration:          config.FadeDuration,
+		voicePacketsCh:        make(chan []voice.VoicePacket, voicePacketsBufferSize),
+		decodeCh:              make(chan []voice.VoicePacket, decodeQueueSize),
+		decodeOverflow:        config.DecodeOverflow,
+		maxDecoders:           maxDecoders,
+		streams:               make(map[*receiveStream]struct{}),
+		closeCh:               make(chan struct{}),
+		receiveLogger:         config.LogLevels.Logger(types.SubsystemAudioReceive),
+		transmitLogger:        config.LogLevels.Logger(types.SubsystemAudioTransmit),
+		pingLogger:            config.LogLevels.Logger(types.SubsystemPing),
+	}
+	client.markPing(time.Now())
+	client.codecInfo = client.queryCodecInfo()
+	return client, nil
 }
 
 // Frequency implements [AudioClient.Frequency].
 func (c *audioClient) Frequencies() []unit.Frequency {
-	frequencies := make([]unit.Frequency, 0, len(c.radios))
-	for _, radio := range c.radios {
-		frequencies = append(frequencies, unit.Frequency(radio.Frequency)*unit.Hertz)
+	radios := c.radioList()
+	frequencies := make([]unit.Frequency, 0, len(radios))
+	for _, radio := range radios {
+		frequencies = append(frequencies, types.FrequencyFromHertz(radio.Frequency))
 	}
 	return frequencies
 }
 
+// Radios implements [AudioClient.Radios].
+func (c *audioClient) Radios() []types.Radio {
+	return slices.Clone(c.radioList())
+}
+
 // Run implements [AudioClient.Run].
-func (c *audioClient) Run(ctx context.Context, wg *sync.WaitGroup) error {
+func (c *audioClient) Run(ctx context.Context, wg *sync.WaitGroup, readyCh chan<- any) error {
 	defer func() {
 		if err := c.close(); err != nil {
 			log.Error().Err(err).Msg("error closing SRS client")
 		}
 	}()
 
+	codec := c.CodecInfo()
+	log.Info().
+		Int("sampleRate", codec.SampleRate).
+		Int("channels", codec.Channels).
+		Stringer("frameLength", codec.FrameLength).
+		Int("frameSize", codec.FrameSize).
+		Int("bitrate", codec.Bitrate).
+		Bool("dtx", codec.IsDTXEnabled).
+		Msg("using Opus codec")
+
+	// Stop the control loops if Close is called.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// End the startup grace period after its maximum duration, even if the client's peers have not been synced.
+	graceTimer := c.startGracePeriod()
+	defer graceTimer.Stop()
+	go func() {
+		select {
+		case <-c.closeCh:
+			log.Info().Msg("stopping SRS audio client because it was closed")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	// We need to send pings to the server to keep our connection alive. The server won't send us any audio until it receives a ping from us.
 	wg.Add(1)
 	go func() {
@@ -114,31 +630,33 @@ func (c *audioClient) Run(ctx context.Context, wg *sync.WaitGroup) error {
 	// udpPingRxChan is a channel for received ping packets.
 	udpPingRxChan := make(chan []byte, 0xF)
 
-	// Handle incoming pings - mostly for debugging. We don't need to echo them back.
+	// Handle incoming pings. We don't need to echo them back, but the first ping tells us the server has acknowledged us.
+	handshakeCh := make(chan any)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		c.receivePings(ctx, udpPingRxChan)
+		c.receivePings(ctx, udpPingRxChan, handshakeCh)
 	}()
 
 	// udpVoiceRxChan is a channel for received voice packets.
 	udpVoiceRxChan := make(chan []byte, 64*0xFFFFF) // TODO configurable packet buffer size
-	// voiceBytesRxChan is a channel for VoicePackets deserialized from UDP voice packets.
-	voiceBytesRxChan := make(chan []voice.VoicePacket, 0xFFFFF) // TODO configurable tranmission buffer size
 
 	// receive voice packets and decode them. This is the logic for receiving audio from the SRS server.
-	wg.Add(2)
-	go func() {
-		defer wg.Done()
-		c.receiveVoice(ctx, udpVoiceRxChan, voiceBytesRxChan)
-	}()
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		c.decodeVoice(ctx, voiceBytesRxChan)
+		c.receiveVoice(ctx, udpVoiceRxChan, c.decodeCh)
 	}()
+	for range c.maxDecoders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.decodeVoice(ctx, c.decodeCh)
+		}()
+	}
 
 	// voicePacketsTxChan is a channel for transmissions which are ready to send.
-	voicePacketsTxChan := make(chan []voice.VoicePacket, 3)
+	voicePacketsTxChan := make(chan transmission, 3)
 
 	// transmit queued audio. This is the logic for sending audio to the SRS server.
 	wg.Add(2)
@@ -151,6 +669,27 @@ func (c *audioClient) Run(ctx context.Context, wg *sync.WaitGroup) error {
 		c.transmit(ctx, voicePacketsTxChan)
 	}()
 
+	// Call the voice packets callback from its own goroutine, so a slow callback cannot stall the receiver.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.dispatchVoicePackets(ctx)
+	}()
+
+	// Publish idle fill between received transmissions.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.fillIdle(ctx)
+	}()
+
+	// Measure network throughput.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.measureThroughput(ctx)
+	}()
+
 	// Start listening for incoming UDP packets and routing them to receivePings and receiveVoice.
 	wg.Add(1)
 	go func() {
@@ -158,7 +697,10 @@ func (c *audioClient) Run(ctx context.Context, wg *sync.WaitGroup) error {
 		c.receiveUDP(ctx, udpPingRxChan, udpVoiceRxChan)
 	}()
 
-	// Sit and wait, until the context is canceled.
+	// Wait for the server to respond to our first ping, then sit and wait until the context is canceled.
+	if err := c.awaitHandshake(ctx, handshakeCh, readyCh); err != nil {
+		return err
+	}
 	<-ctx.Done()
 	return nil
 }
@@ -168,19 +710,125 @@ func (c *audioClient) Receive() <-chan Audio {
 	return c.rxchan
 }
 
+// ReceivePCM implements [AudioClient.ReceivePCM].
+func (c *audioClient) ReceivePCM() <-chan []byte {
+	return c.rxPCMChan
+}
+
+// isReceivePCM returns true if received audio is published to ReceivePCM rather than Receive.
+func (c *audioClient) isReceivePCM() bool {
+	return c.receiveFormat != pcm.F32LE
+}
+
+// CaptureTransmissions implements [AudioClient.CaptureTransmissions].
+func (c *audioClient) CaptureTransmissions() <-chan Audio {
+	return c.captureCh
+}
+
+// capture publishes a copy of the given transmission's audio to the capture channel, if capture is enabled and the
+// channel has room.
+func (c *audioClient) capture(audio Audio) {
+	if c.captureCh == nil {
+		return
+	}
+	select {
+	case c.captureCh <- slices.Clone(audio):
+	default:
+		c.captureDrops.Add(1)
+		c.transmitLogger.Debug().Msg("transmission capture consumer is falling behind, dropping captured transmission")
+	}
+}
+
 // Transmit implements [AudioClient.Transmit].
 func (c *audioClient) Transmit(sample Audio) {
-	c.txChan <- sample
+	if err := c.enqueue(transmission{audio: c.toTransmitFormat(sample)}); err != nil {
+		c.transmitLogger.Warn().Err(err).Msg("dropping transmission")
+	}
+}
+
+// TransmitSilence implements [AudioClient.TransmitSilence].
+func (c *audioClient) TransmitSilence(d time.Duration) error {
+	if d < c.frameLength {
+		return fmt.Errorf("silence must be at least one frame of %v, got %v", c.frameLength, d)
+	}
+	if err := c.enqueue(transmission{audio: Silence(d, sampleRate), continues: true}); err != nil {
+		return fmt.Errorf("failed to queue silence: %w", err)
+	}
+	c.isNextContinued.Store(true)
+	return nil
 }
 
-// close closes the UDP connection to the SRS server.
-func (c *audioClient) close() error {
-	if err := c.connection.Close(); err != nil {
-		return fmt.Errorf("error closing UDP connection to SRS: %w", err)
+// TransmitToCoalition implements [AudioClient.TransmitToCoalition].
+func (c *audioClient) TransmitToCoalition(sample Audio, coalition coalitions.Coalition) error {
+	if coalition != c.coalition {
+		return fmt.Errorf("cannot transmit to coalition %v as coalition %v: %w", coalition, c.coalition, ErrCoalitionOverrideUnsupported)
 	}
+	c.Transmit(sample)
 	return nil
 }
 
+// Speak implements [AudioClient.Speak].
+func (c *audioClient) Speak(sample []float32, inputRate int, normalize bool) error {
+	if inputRate <= 0 {
+		return fmt.Errorf("sample rate must be positive, got %d", inputRate)
+	}
+	audio := toSampleRate(c.toMono(sample), inputRate)
+	if normalize {
+		audio = pcm.Normalize(audio, normalizationPeak)
+	}
+	return c.enqueue(transmission{audio: audio})
+}
+
+// Close implements [AudioClient.Close].
+func (c *audioClient) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+	})
+	return c.close()
+}
+
+// close closes the UDP connection to the SRS server. It is called both by Close and when Run returns, so only the first
+// call closes the connection and later calls are no-ops.
+func (c *audioClient) close() (err error) {
+	c.teardownOnce.Do(func() {
+		c.closeStreams()
+		c.connectionLock.Lock()
+		defer c.connectionLock.Unlock()
+		c.isConnectionClosed = true
+		if closeErr := c.connection.Close(); closeErr != nil {
+			err = fmt.Errorf("error closing UDP connection to SRS: %w", closeErr)
+		}
+	})
+	return
+}
+
+// ReceiverStates implements [AudioClient.ReceiverStates].
+func (c *audioClient) ReceiverStates() []ReceiverState {
+	radios, receivers := c.radioList(), c.receiverMap()
+	states := make([]ReceiverState, 0, len(radios))
+	for _, radio := range radios {
+		if receiver, ok := receivers[radio]; ok {
+			states = append(states, receiver.state(radio))
+		}
+	}
+	return states
+}
+
+// LastPing implements [AudioClient.LastPing].
 func (c *audioClient) LastPing() time.Time {
-	return c.lastPing
+	if lastPing := c.lastPing.Load(); lastPing != nil {
+		return *lastPing
+	}
+	return time.Time{}
+}
+
+// markPing records the time a ping was received. The time should come from [time.Now] so that it carries a monotonic
+// clock reading.
+func (c *audioClient) markPing(received time.Time) {
+	c.lastPing.Store(&received)
+}
+
+// LocalAddr implements [AudioClient.LocalAddr].
+func (c *audioClient) LocalAddr() net.Addr {
+	return c.conn().LocalAddr()
 }
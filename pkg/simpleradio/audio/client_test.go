@@ -0,0 +1,251 @@
+package audio
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseWhileRunning(t *testing.T) {
+	t.Parallel()
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer server.Close()
+
+	client, err := NewClient(types.NewGUID(), types.ClientConfiguration{Address: server.LocalAddr().String()})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	runErr := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runErr <- client.Run(ctx, &wg, make(chan any))
+	}()
+
+	// Close concurrently from several goroutines while Run is also tearing down.
+	var closers sync.WaitGroup
+	closeErrs := make(chan error, 4)
+	for range 4 {
+		closers.Add(1)
+		go func() {
+			defer closers.Done()
+			closeErrs <- client.Close()
+		}()
+	}
+	cancel()
+	closers.Wait()
+	close(closeErrs)
+	for err := range closeErrs {
+		require.NoError(t, err)
+	}
+
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Run did not return after Close")
+	}
+	require.NoError(t, client.Close())
+}
+
+func TestRadios(t *testing.T) {
+	t.Parallel()
+	radios := []types.Radio{
+		{Frequency: 251000000, Modulation: types.ModulationAM},
+		{Frequency: 30000000, Modulation: types.ModulationFM, IsEncrypted: true, EncryptionKey: 3},
+	}
+	client := &audioClient{radios: slices.Clone(radios)}
+	got := client.Radios()
+	assert.Equal(t, radios, got)
+	got[0].Frequency = 133000000
+	assert.Equal(t, radios, client.Radios(), "returned radios should be a copy")
+}
+
+func TestTransmitToCoalition(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		coalition coalitions.Coalition
+		expected  error
+	}{
+		{coalition: coalitions.Blue, expected: nil},
+		{coalition: coalitions.Red, expected: ErrCoalitionOverrideUnsupported},
+		{coalition: coalitions.Neutrals, expected: ErrCoalitionOverrideUnsupported},
+	}
+	for _, test := range testCases {
+		t.Run(test.coalition.String(), func(t *testing.T) {
+			t.Parallel()
+			client := &audioClient{coalition: coalitions.Blue, txChan: make(chan transmission, 1)}
+			err := client.TransmitToCoalition(Audio{0.1, 0.2}, test.coalition)
+			require.ErrorIs(t, err, test.expected)
+			if test.expected == nil {
+				assert.Len(t, client.txChan, 1)
+			} else {
+				assert.Empty(t, client.txChan)
+			}
+		})
+	}
+}
+
+func TestTransmitSilence(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	client.txChan = make(chan transmission, 1)
+	require.Error(t, client.TransmitSilence(0))
+	require.Error(t, client.TransmitSilence(defaultFrameLength-time.Millisecond))
+
+	require.NoError(t, client.TransmitSilence(5*defaultFrameLength))
+	queued := <-client.txChan
+	assert.Equal(t, 5*defaultFrameLength, queued.audio.Duration(sampleRate))
+	for _, sample := range queued.audio {
+		require.Zero(t, sample)
+	}
+
+	// A packet is sent for every frame of silence, holding the channel for the whole duration.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packetCh := make(chan transmission, 1)
+	client.txChan <- queued
+	go client.encodeVoice(ctx, packetCh)
+	client.tx((<-packetCh).packets)
+	for range 5 {
+		<-packets
+	}
+	assert.Empty(t, packets)
+}
+
+func TestTransmitSilenceHoldsChannel(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	client.txChan = make(chan transmission, 4)
+	speech := make(Audio, 5*client.frameSize)
+	for i := range speech {
+		speech[i] = 0.25
+	}
+
+	// Silence joins the transmissions before and after it into a single transmission.
+	client.Transmit(speech)
+	require.NoError(t, client.TransmitSilence(3*defaultFrameLength))
+	client.Transmit(speech[:2*client.frameSize])
+	client.Transmit(speech[:client.frameSize])
+	queued := make([]transmission, 0, 4)
+	for range 4 {
+		queued = append(queued, <-client.txChan)
+	}
+	assert.False(t, queued[0].continues)
+	assert.True(t, queued[1].continues, "silence should continue the previous transmission")
+	assert.True(t, queued[2].continues, "the transmission after silence should continue it")
+	assert.False(t, queued[3].continues)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packetCh := make(chan transmission)
+	for _, q := range queued[:3] {
+		client.txChan <- q
+	}
+	go client.encodeVoice(ctx, packetCh)
+	go client.transmit(ctx, packetCh)
+
+	// The segments are sent back to back, without the pause between transmissions.
+	<-packets
+	first := time.Now()
+	for range 9 {
+		<-packets
+	}
+	elapsed := time.Since(first)
+	assert.Less(t, elapsed, 500*time.Millisecond, "segments should not be separated by the pause between transmissions")
+	assert.GreaterOrEqual(t, elapsed, 8*defaultFrameLength, "packets should be paced across the segments")
+}
+
+func TestCustomDialer(t *testing.T) {
+	t.Parallel()
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer server.Close()
+
+	var dialed string
+	dialer := dialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = network + " " + address
+		return (&net.Dialer{}).DialContext(ctx, network, server.LocalAddr().String())
+	})
+	c, err := NewClient(types.NewGUID(), types.ClientConfiguration{Address: "srs.example.com:5002", UDPDialer: dialer})
+	require.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, "udp srs.example.com:5002", dialed)
+	client := c.(*audioClient)
+	assert.True(t, client.isFromServer(server.LocalAddr()))
+}
+
+func TestCustomTransport(t *testing.T) {
+	t.Parallel()
+	dialer := &pipeDialer{t: t, written: make(chan []byte, 1)}
+	var dialed string
+	c, err := NewClient(types.NewGUID(), types.ClientConfiguration{
+		Address: "srs.example.com:5002",
+		AudioTransport: func(ctx context.Context, network, address string) (types.AudioTransport, error) {
+			dialed = network + " " + address
+			return dialer.DialContext(ctx, network, address)
+		},
+	})
+	require.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, "udp srs.example.com:5002", dialed)
+
+	// Reconnecting opens a new transport.
+	dialed = ""
+	require.NoError(t, c.Reconnect(context.Background()))
+	assert.Equal(t, "udp srs.example.com:5002", dialed)
+}
+
+// dialerFunc adapts a function to [types.UDPDialer].
+type dialerFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (f dialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
+
+func TestCodecInfoIsCached(t *testing.T) {
+	t.Parallel()
+	c, err := NewClient(types.NewGUID(), types.ClientConfiguration{
+		UDPDialer:                 &pipeDialer{t: t, written: make(chan []byte, 1)},
+		FrameLength:               20 * time.Millisecond,
+		DiscontinuousTransmission: true,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+	client := c.(*audioClient)
+	assert.Equal(t, client.queryCodecInfo(), c.CodecInfo())
+	assert.Equal(t, 20*time.Millisecond, c.CodecInfo().FrameLength)
+	assert.Positive(t, c.CodecInfo().Bitrate)
+}
+
+func TestLocalAddr(t *testing.T) {
+	t.Parallel()
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer server.Close()
+
+	c, err := NewClient(types.NewGUID(), types.ClientConfiguration{Address: server.LocalAddr().String()})
+	require.NoError(t, err)
+	defer c.Close()
+	local, ok := c.LocalAddr().(*net.UDPAddr)
+	require.True(t, ok)
+	assert.Positive(t, local.Port, "local address should include the ephemeral source port")
+
+	// The server sees packets arriving from the reported address.
+	_, err = c.(*audioClient).connection.Write([]byte("ping"))
+	require.NoError(t, err)
+	_, source, err := server.ReadFromUDP(make([]byte, 16))
+	require.NoError(t, err)
+	assert.Equal(t, local.String(), source.String())
+}
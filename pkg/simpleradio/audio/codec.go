@@ -0,0 +1,72 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CodecInfo describes the effective Opus codec parameters used by the audio client.
+type CodecInfo struct {
+	// SampleRate is the sample rate of transmitted and received audio in Hz.
+	SampleRate int
+	// Channels is the number of audio channels.
+	Channels int
+	// FrameLength is the duration of audio in each transmitted Opus frame.
+	FrameLength time.Duration
+	// FrameSize is the number of samples in each transmitted Opus frame.
+	FrameSize int
+	// Bitrate is the target bitrate of the Opus encoder in bits per second, as reported by the encoder. It is zero if
+	// the encoder could not be queried.
+	Bitrate int
+	// IsDTXEnabled is true if Opus discontinuous transmission is enabled.
+	IsDTXEnabled bool
+}
+
+// CodecInfo implements [AudioClient.CodecInfo].
+func (c *audioClient) CodecInfo() CodecInfo {
+	return c.codecInfo
+}
+
+// queryCodecInfo returns the client's codec parameters. Querying the bitrate creates an Opus encoder, so this is called
+// once when the client is created, and CodecInfo returns the result.
+func (c *audioClient) queryCodecInfo() CodecInfo {
+	info := CodecInfo{
+		SampleRate:   sampleRate,
+		Channels:     channels,
+		FrameLength:  c.frameLength,
+		FrameSize:    c.frameSize,
+		IsDTXEnabled: c.isDTXEnabled,
+	}
+	// Each transmission uses a fresh encoder, so query one configured the same way.
+	encoder, err := c.newEncoder()
+	if err != nil {
+		c.transmitLogger.Warn().Err(err).Msg("failed to create Opus encoder to query codec parameters")
+		return info
+	}
+	bitrate, err := encoder.Bitrate()
+	if err != nil {
+		c.transmitLogger.Warn().Err(err).Msg("failed to query Opus encoder bitrate")
+		return info
+	}
+	info.Bitrate = bitrate
+	return info
+}
+
+// ErrCodecPanic is returned when the Go bindings for the Opus codec panic while encoding or decoding a frame, such as
+// on a nil pointer or an out-of-range slice; the offending frame is skipped rather than stopping the client. Only
+// panics on the Go side of the bindings can be recovered. A fault inside libopus itself, which is C code, crashes the
+// process. libopus validates each packet before decoding it and rejects malformed packets with an error, which is
+// counted as a decode error rather than a panic.
+var ErrCodecPanic = errors.New("codec panicked")
+
+// codecPanicked counts a recovered codec panic and returns an error describing it.
+func (c *audioClient) codecPanicked(operation string, recovered any) error {
+	c.codecPanics.Add(1)
+	return fmt.Errorf("%w while trying to %s a frame: %v", ErrCodecPanic, operation, recovered)
+}
+
+// CodecPanics implements [AudioClient.CodecPanics].
+func (c *audioClient) CodecPanics() uint64 {
+	return c.codecPanics.Load()
+}
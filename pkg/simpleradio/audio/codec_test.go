@@ -0,0 +1,120 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/hraban/opus.v2"
+)
+
+func TestCodecInfo(t *testing.T) {
+	t.Parallel()
+	frameLength := 20 * time.Millisecond
+	c := &audioClient{frameLength: frameLength, frameSize: frameSizeOf(frameLength), isDTXEnabled: true}
+	info := c.queryCodecInfo()
+	assert.Equal(t, 16000, info.SampleRate)
+	assert.Equal(t, 1, info.Channels)
+	assert.Equal(t, frameLength, info.FrameLength)
+	assert.Equal(t, 320, info.FrameSize)
+	assert.Positive(t, info.Bitrate)
+	assert.True(t, info.IsDTXEnabled)
+}
+
+func TestCodecPanicRecovered(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{frameLength: defaultFrameLength, frameSize: frameSizeOf(defaultFrameLength)}
+
+	// A nil codec makes the Go bindings dereference a nil pointer. This is the kind of panic that can be recovered; a
+	// fault inside libopus cannot, which TestDecodeMalformedPackets shows does not happen for malformed input.
+	prefix := []float32{0.5}
+	out, err := c.decode(nil, []byte{0xFF, 0xFF, 0xFF}, prefix)
+	require.ErrorIs(t, err, ErrCodecPanic)
+	assert.Equal(t, prefix, out, "audio decoded before the panic should be kept")
+	_, err = c.encode(nil, make([]float32, c.frameSize))
+	require.ErrorIs(t, err, ErrCodecPanic)
+	assert.Equal(t, uint64(2), c.CodecPanics())
+
+	packets := newTestTransmission(t, c, 3)
+	assert.Empty(t, c.decodeTransmission(nil, packets))
+	assert.Equal(t, uint64(5), c.CodecPanics())
+}
+
+func TestDecodeVoiceSkipsBadPackets(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{
+		frameLength: defaultFrameLength,
+		frameSize:   frameSizeOf(defaultFrameLength),
+		rxchan:      make(chan Audio, 1),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	voicePacketsCh := make(chan []voice.VoicePacket)
+	go c.decodeVoice(ctx, voicePacketsCh)
+
+	// A crafted transmission of malformed Opus packets is skipped, and the decoder keeps running.
+	bad := []voice.VoicePacket{
+		voice.NewVoicePacket([]byte{0xFF, 0xFF, 0xFF, 0xFF}, nil, 0, 1, 0, nil, nil),
+		voice.NewVoicePacket([]byte{0x03}, nil, 0, 2, 0, nil, nil),
+	}
+	voicePacketsCh <- bad
+	voicePacketsCh <- newTestTransmission(t, c, 2)
+	select {
+	case audio := <-c.rxchan:
+		assert.Len(t, audio, 2*c.frameSize)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "decoder stopped after a bad transmission")
+	}
+	assert.Equal(t, uint64(2), c.Stats().DecodeErrors)
+}
+
+func TestDecodeMalformedPackets(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{frameLength: defaultFrameLength, frameSize: frameSizeOf(defaultFrameLength)}
+	valid := newTestTransmission(t, c, 1)[0].AudioBytes
+	malformed := [][]byte{
+		nil,
+		// A packet of an arbitrary number of frames, missing the frame count.
+		{0x03},
+		// A packet of an arbitrary number of frames, with zero frames.
+		{0x03, 0x00},
+		// A packet of more frames than fit in 120ms.
+		{0x0B, 0x3F, 0xFF, 0xFF},
+		// Two frames of different sizes, where the first frame's size exceeds the packet.
+		{0x02, 0xFB, 0xFF},
+		// A valid packet, truncated.
+		valid[:1],
+		valid[:len(valid)/2],
+		// A valid packet, followed by garbage up to the maximum UDP payload.
+		append(slices.Clone(valid), bytes.Repeat([]byte{0xFF}, maxPacketLength-len(valid))...),
+	}
+	random := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		packet := make([]byte, 1+random.IntN(maxPacketLength))
+		for i := range packet {
+			packet[i] = byte(random.Uint32())
+		}
+		malformed = append(malformed, packet)
+	}
+
+	decoder, err := opus.NewDecoder(sampleRate, channels)
+	require.NoError(t, err)
+	for _, packet := range malformed {
+		// Each packet is either rejected with an error or decoded as noise; none crash the process.
+		out, err := c.decode(decoder, packet, nil)
+		require.NotErrorIs(t, err, ErrCodecPanic)
+		assert.LessOrEqual(t, len(out), frameSizeOf(maxFrameLength))
+	}
+	assert.Zero(t, c.CodecPanics())
+
+	// The decoder still decodes valid packets afterwards.
+	out, err := c.decode(decoder, valid, nil)
+	require.NoError(t, err)
+	assert.Len(t, out, c.frameSize)
+}
@@ -6,8 +6,9 @@ import (
 
 	"gopkg.in/hraban/opus.v2"
 
+	"github.com/dharmab/skyeye/pkg/simpleradio/internal/callbacks"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
 	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
-	"github.com/rs/zerolog/log"
 )
 
 // decodeVoicePacket decodes a UDP voice packet message into a VoicePacket struct.
@@ -23,37 +24,146 @@ func decodeVoicePacket(b []byte) (p *voice.VoicePacket, err error) {
 	return
 }
 
-// deocdeVoice decodes incoming voice packets from voicePacketsCh into F32LE PCM audio data published to the client's rxChan.
+// defaultDecodeQueueSize is the default number of received transmissions which may wait for a decoder.
+const defaultDecodeQueueSize = 1024
+
+// validateDecodeOverflow returns an error if the given decode overflow policy is not recognized.
+func validateDecodeOverflow(policy types.DecodeOverflowPolicy) error {
+	switch policy {
+	case types.DecodeOverflowDrop, types.DecodeOverflowWait:
+		return nil
+	default:
+		return fmt.Errorf("unknown decode overflow policy %d", policy)
+	}
+}
+
+// queueDecode queues a received transmission for the decoders, following the client's decode overflow policy if the
+// queue is full. It is called by the receiveVoice goroutine, so under [types.DecodeOverflowWait] a full queue stalls
+// receiving until a decoder catches up or the context is cancelled.
+func (c *audioClient) queueDecode(ctx context.Context, out chan<- []voice.VoicePacket, voicePackets []voice.VoicePacket) {
+	if c.decodeOverflow == types.DecodeOverflowDrop {
+		select {
+		case out <- voicePackets:
+		default:
+			c.decodeDrops.Add(1)
+			c.receiveLogger.Warn().Int("queued", len(out)).Msg("dropping received transmission because the decode queue is full")
+		}
+		return
+	}
+	select {
+	case out <- voicePackets:
+	case <-ctx.Done():
+	}
+}
+
+// deocdeVoice decodes incoming voice packets from voicePacketsCh into F32LE PCM audio data published to the client's
+// rxChan. Several decoders may run concurrently on the same channel, up to the client's decoder limit.
 func (c *audioClient) decodeVoice(ctx context.Context, voicePacketsCh <-chan []voice.VoicePacket) {
 	for {
 		select {
 		case voicePackets := <-voicePacketsCh:
+			if c.isReceiveDisabled(voicePackets) {
+				c.receiveLogger.Trace().Msg("dropping transmission received on a radio with receive disabled")
+				continue
+			}
 			decoder, err := opus.NewDecoder(sampleRate, channels)
 			if err != nil {
-				log.Error().Err(err).Msg("failed to create Opus decoder")
+				c.receiveLogger.Error().Err(err).Msg("failed to create Opus decoder")
 				continue
 			}
-			txPCM := make([]float32, 0)
-			for _, vp := range voicePackets {
-				pcm, err := c.decode(decoder, vp.AudioBytes)
-				if err != nil {
-					log.Error().Err(err).Msg("failed to decode audio")
-				} else {
-					txPCM = append(txPCM, pcm...)
-				}
-			}
+			txPCM := c.decodeTransmission(decoder, voicePackets)
 
-			log.Trace().Int("len", len(txPCM)).Msg("decoded transmission PCM")
+			c.receiveLogger.Trace().Int("len", len(txPCM)).Msg("decoded transmission PCM")
 
 			if len(txPCM) > 0 {
-				log.Info().Int("len", len(txPCM)).Msg("publishing received audio to receiving channel")
-				c.rxchan <- txPCM
+				if !c.publishTransmission(ctx, voicePackets, txPCM) {
+					c.receiveLogger.Info().Msg("stopping voice decoder due to context cancellation")
+					return
+				}
 			} else {
-				log.Debug().Msg("decoded transmission PCM is empty")
+				c.receiveLogger.Debug().Msg("decoded transmission PCM is empty")
 			}
 		case <-ctx.Done():
-			log.Info().Msg("stopping voice decoder due to context cancellation")
+			c.receiveLogger.Info().Msg("stopping voice decoder due to context cancellation")
 			return
 		}
 	}
 }
+
+// publishTransmission calls the transmission received callback and publishes the decoded audio of a transmission to
+// the receive streams and to the receive channel, or to the PCM receive channel in the configured receive format. Only
+// one decoder publishes at a time, so the callback is called in the same order that transmissions are published. It
+// returns false if the context was cancelled before the audio was published.
+func (c *audioClient) publishTransmission(ctx context.Context, voicePackets []voice.VoicePacket, txPCM Audio) bool {
+	c.publishLock.Lock()
+	defer c.publishLock.Unlock()
+	metadata := newTransmissionMetadata(voicePackets)
+	c.receiveLogger.Info().
+		Int("len", len(txPCM)).
+		Int("receivedPackets", metadata.ReceivedPackets).
+		Int("expectedPackets", metadata.ExpectedPackets).
+		Float64("packetLossPercent", metadata.PacketLossPercent).
+		Msg("publishing received audio to receiving channel")
+	if callback := callbacks.Load(&c.transmissionReceivedCallback); callback != nil {
+		callback(metadata)
+	}
+	c.publishToStreams(voicePackets, txPCM)
+	if c.isReceivePCM() {
+		select {
+		case c.rxPCMChan <- c.receiveFormat.Encode(txPCM):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	select {
+	case c.rxchan <- txPCM:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// defaultDecoderResetThreshold is the default number of consecutive missing voice packets after which the decoder is
+// reset. This is one second of audio at the SRS default frame length.
+const defaultDecoderResetThreshold = 25
+
+// decodeTransmission decodes the audio in each of the given voice packets into a single F32LE PCM audio buffer, and
+// records it in the sender's statistics. If a run of missing packets exceeds the decoder reset threshold, the decoder's
+// state no longer matches the sender's, so a fresh decoder is used for the remaining packets.
+func (c *audioClient) decodeTransmission(decoder *opus.Decoder, voicePackets []voice.VoicePacket) []float32 {
+	txPCM := make([]float32, 0, len(voicePackets)*c.frameSize)
+	decodeErrors := 0
+	for i, vp := range voicePackets {
+		if i > 0 && c.isDesynced(voicePackets[i-1].PacketID, vp.PacketID) {
+			c.receiveLogger.Debug().
+				Uint64("previousPacketID", voicePackets[i-1].PacketID).
+				Uint64("packetID", vp.PacketID).
+				Msg("resetting decoder after gap in received packets")
+			if freshDecoder, err := opus.NewDecoder(sampleRate, channels); err != nil {
+				c.receiveLogger.Error().Err(err).Msg("failed to reset Opus decoder")
+			} else {
+				decoder = freshDecoder
+			}
+		}
+		var err error
+		txPCM, err = c.decode(decoder, vp.AudioBytes, txPCM)
+		if err != nil {
+			decodeErrors++
+			c.decodeErrors.Add(1)
+			c.receiveLogger.Error().Err(err).Msg("failed to decode audio")
+		}
+	}
+	c.recordSenderStats(voicePackets, txPCM, decodeErrors)
+	return txPCM
+}
+
+// isDesynced returns true if the number of packets missing between two consecutively received packets exceeds the
+// decoder reset threshold.
+func (c *audioClient) isDesynced(previous, next uint64) bool {
+	if next <= previous {
+		return false
+	}
+	missing := next - previous - 1
+	return missing > uint64(c.decoderResetThreshold)
+}
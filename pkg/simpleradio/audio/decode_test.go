@@ -0,0 +1,313 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/hraban/opus.v2"
+)
+
+// newTestTransmission returns the given number of voice packets containing an Opus-encoded 440Hz sine wave.
+func newTestTransmission(tb testing.TB, c *audioClient, count int) []voice.VoicePacket {
+	tb.Helper()
+	encoder, err := opus.NewEncoder(sampleRate, channels, opusApplicationVoIP)
+	require.NoError(tb, err)
+	packets := make([]voice.VoicePacket, 0, count)
+	for i := range count {
+		frame := make([]float32, c.frameSize)
+		for j := range frame {
+			t := float64(i*c.frameSize+j) / sampleRate
+			frame[j] = float32(0.5 * math.Sin(2*math.Pi*440*t))
+		}
+		b, err := c.encode(encoder, frame)
+		require.NoError(tb, err)
+		packets = append(packets, voice.NewVoicePacket(b, nil, 0, uint64(i), 0, nil, nil))
+	}
+	return packets
+}
+
+func TestDecodeTransmission(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{frameLength: defaultFrameLength, frameSize: frameSizeOf(defaultFrameLength)}
+	packets := newTestTransmission(t, c, 25)
+	decoder, err := opus.NewDecoder(sampleRate, channels)
+	require.NoError(t, err)
+	pcm := c.decodeTransmission(decoder, packets)
+	require.Len(t, pcm, 25*c.frameSize)
+}
+
+func TestIsDesynced(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{decoderResetThreshold: 5}
+	testCases := []struct {
+		previous uint64
+		next     uint64
+		expected bool
+	}{
+		{previous: 1, next: 2, expected: false},
+		{previous: 1, next: 7, expected: false},
+		{previous: 1, next: 8, expected: true},
+		{previous: 1, next: 100, expected: true},
+		{previous: 8, next: 1, expected: false},
+	}
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, c.isDesynced(test.previous, test.next), "%d -> %d", test.previous, test.next)
+	}
+}
+
+func TestDecodeTransmissionRecoversFromGap(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{frameLength: defaultFrameLength, frameSize: frameSizeOf(defaultFrameLength)}
+	packets := newTestTransmission(t, c, 50)
+	// Drop a run of 30 packets from the middle of the transmission.
+	received := append(slices.Clone(packets[:10]), packets[40:]...)
+
+	decodeAfterGap := func(threshold int) []float32 {
+		c.decoderResetThreshold = threshold
+		decoder, err := opus.NewDecoder(sampleRate, channels)
+		require.NoError(t, err)
+		pcm := c.decodeTransmission(decoder, received)
+		require.Len(t, pcm, 20*c.frameSize)
+		return pcm[10*c.frameSize:]
+	}
+
+	// After the decoder is reset, the packets after the gap decode exactly as if they were a new transmission.
+	decoder, err := opus.NewDecoder(sampleRate, channels)
+	require.NoError(t, err)
+	fresh := c.decodeTransmission(decoder, packets[40:])
+	assert.Equal(t, fresh, decodeAfterGap(25))
+	assert.NotEqual(t, fresh, decodeAfterGap(30), "decoder should not be reset for a gap within the threshold")
+}
+
+func TestDiscontinuousTransmission(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{frameLength: defaultFrameLength, frameSize: frameSizeOf(defaultFrameLength), isDTXEnabled: true}
+	encoder, err := c.newEncoder()
+	require.NoError(t, err)
+
+	// One second of tone, one second of silence, then one second of tone.
+	const toneFrames, silentFrames = 25, 25
+	packets := make([]voice.VoicePacket, 0, 2*toneFrames+silentFrames)
+	dtxFrames := 0
+	for i := range 2*toneFrames + silentFrames {
+		isSilent := i >= toneFrames && i < toneFrames+silentFrames
+		frame := make([]float32, c.frameSize)
+		if !isSilent {
+			for j := range frame {
+				t := float64(i*c.frameSize+j) / sampleRate
+				frame[j] = float32(0.5 * math.Sin(2*math.Pi*440*t))
+			}
+		}
+		b, err := c.encode(encoder, frame)
+		require.NoError(t, err)
+		if isSilent && len(b) <= 2 {
+			dtxFrames++
+		}
+		packets = append(packets, voice.NewVoicePacket(b, nil, 0, uint64(i), 0, nil, nil))
+	}
+	assert.Positive(t, dtxFrames, "silence should be encoded as minimal DTX frames")
+
+	// Every frame, including the minimal silent frames, decodes to a full frame of audio so timing is preserved.
+	decoder, err := opus.NewDecoder(sampleRate, channels)
+	require.NoError(t, err)
+	pcm := c.decodeTransmission(decoder, packets)
+	require.Len(t, pcm, len(packets)*c.frameSize)
+	peak := func(frames []float32) float32 {
+		var p float32
+		for _, f := range frames {
+			p = max(p, float32(math.Abs(float64(f))))
+		}
+		return p
+	}
+	silenceEnd := (toneFrames + silentFrames) * c.frameSize
+	assert.Less(t, peak(pcm[silenceEnd-5*c.frameSize:silenceEnd]), float32(0.05), "silence should decode as silence")
+	assert.Greater(t, peak(pcm[silenceEnd+c.frameSize:silenceEnd+2*c.frameSize]), float32(0.2), "tone should resume on time")
+}
+
+func BenchmarkDecodeTransmission(b *testing.B) {
+	c := &audioClient{frameLength: defaultFrameLength, frameSize: frameSizeOf(defaultFrameLength)}
+	packets := newTestTransmission(b, c, 50)
+	decoder, err := opus.NewDecoder(sampleRate, channels)
+	require.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		_ = c.decodeTransmission(decoder, packets)
+	}
+}
+
+// BenchmarkDecodeVoice measures the decoder loop, from a received transmission of speech to its published audio.
+func BenchmarkDecodeVoice(b *testing.B) {
+	for _, name := range fixtures {
+		audio := loadFixture(b, name)
+		b.Run(name, func(b *testing.B) {
+			client := newBenchmarkClient()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			packetCh := make(chan transmission)
+			go client.encodeVoice(ctx, packetCh)
+			client.txChan <- transmission{audio: audio}
+			packets := (<-packetCh).packets
+
+			voicePacketsCh := make(chan []voice.VoicePacket)
+			go client.decodeVoice(ctx, voicePacketsCh)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				voicePacketsCh <- packets
+				<-client.rxchan
+			}
+		})
+	}
+}
+
+func TestValidateDecodeOverflow(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, validateDecodeOverflow(types.DecodeOverflowWait))
+	require.NoError(t, validateDecodeOverflow(types.DecodeOverflowDrop))
+	require.Error(t, validateDecodeOverflow(types.DecodeOverflowPolicy(99)))
+}
+
+func TestQueueDecode(t *testing.T) {
+	t.Parallel()
+	packets := []voice.VoicePacket{voice.NewVoicePacket([]byte{0x01}, nil, 0, 1, 0, nil, nil)}
+
+	t.Run("drop", func(t *testing.T) {
+		t.Parallel()
+		c := &audioClient{decodeOverflow: types.DecodeOverflowDrop, decodeCh: make(chan []voice.VoicePacket, 1)}
+		c.queueDecode(context.Background(), c.decodeCh, packets)
+		c.queueDecode(context.Background(), c.decodeCh, packets)
+		stats := c.Stats()
+		assert.Equal(t, 1, stats.DecodeBacklog)
+		assert.Equal(t, uint64(1), stats.DecodeDrops)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+		c := &audioClient{decodeCh: make(chan []voice.VoicePacket, 1)}
+		c.queueDecode(context.Background(), c.decodeCh, packets)
+		c.queueDecode(context.Background(), c.decodeCh, packets)
+		assert.Equal(t, uint64(1), c.Stats().DecodeDrops, "transmissions should be dropped by default")
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		t.Parallel()
+		c := &audioClient{decodeOverflow: types.DecodeOverflowWait, decodeCh: make(chan []voice.VoicePacket, 1)}
+		c.queueDecode(context.Background(), c.decodeCh, packets)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		c.queueDecode(ctx, c.decodeCh, packets)
+		require.Error(t, ctx.Err(), "queueDecode should wait until the context is cancelled")
+		stats := c.Stats()
+		assert.Equal(t, 1, stats.DecodeBacklog)
+		assert.Zero(t, stats.DecodeDrops)
+	})
+}
+
+func TestConcurrentDecoders(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{
+		frameLength: defaultFrameLength,
+		frameSize:   frameSizeOf(defaultFrameLength),
+		rxchan:      make(chan Audio),
+		decodeCh:    make(chan []voice.VoicePacket, 16),
+	}
+	var received []int
+	c.SetTransmissionReceivedCallback(func(metadata TransmissionMetadata) {
+		received = append(received, metadata.ReceivedPackets)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for range 4 {
+		go c.decodeVoice(ctx, c.decodeCh)
+	}
+
+	// Each transmission has a different length, so its audio can be matched to its callback.
+	for i := range 16 {
+		c.decodeCh <- newTestTransmission(t, c, i+1)
+	}
+	published := make([]int, 0, 16)
+	for range 16 {
+		select {
+		case audio := <-c.rxchan:
+			published = append(published, len(audio)/c.frameSize)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "decoders did not publish every transmission")
+		}
+	}
+	c.publishLock.Lock()
+	defer c.publishLock.Unlock()
+	assert.Equal(t, published, received, "callbacks should be called in the order transmissions are published")
+	slices.Sort(published)
+	for i, count := range published {
+		assert.Equal(t, i+1, count)
+	}
+}
+
+// BenchmarkDecodeVoiceConcurrent measures decoding many simultaneous transmissions, as on a busy server, with
+// different numbers of decoders.
+func BenchmarkDecodeVoiceConcurrent(b *testing.B) {
+	const talkers = 16
+	for _, decoders := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("decoders=%d", decoders), func(b *testing.B) {
+			client := newBenchmarkClient()
+			packets := newTestTransmission(b, client, 25)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			voicePacketsCh := make(chan []voice.VoicePacket, talkers)
+			for range decoders {
+				go client.decodeVoice(ctx, voicePacketsCh)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				for range talkers {
+					voicePacketsCh <- packets
+				}
+				for range talkers {
+					<-client.rxchan
+				}
+			}
+		})
+	}
+}
+
+func TestReceiveFormat(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{
+		frameLength:   defaultFrameLength,
+		frameSize:     frameSizeOf(defaultFrameLength),
+		rxchan:        make(chan Audio, 1),
+		rxPCMChan:     make(chan []byte, 1),
+		receiveFormat: pcm.S16BE,
+	}
+	packets := newTestTransmission(t, c, 5)
+	decoder, err := opus.NewDecoder(sampleRate, channels)
+	require.NoError(t, err)
+	expected := pcm.S16BE.Encode(c.decodeTransmission(decoder, packets))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	voicePacketsCh := make(chan []voice.VoicePacket)
+	go c.decodeVoice(ctx, voicePacketsCh)
+	voicePacketsCh <- packets
+	select {
+	case b := <-c.ReceivePCM():
+		assert.Len(t, b, 2*5*c.frameSize)
+		assert.Equal(t, expected, b)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for received PCM")
+	}
+	assert.Empty(t, c.Receive(), "audio should only be published in the configured format")
+}
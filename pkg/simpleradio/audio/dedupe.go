@@ -0,0 +1,37 @@
+package audio
+
+// TransmitWithID implements [AudioClient.TransmitWithID].
+func (c *audioClient) TransmitWithID(id string, sample Audio) {
+	if id != "" && !c.reserveTransmissionID(id) {
+		c.transmitLogger.Warn().Str("id", id).Msg("dropping duplicate transmission")
+		return
+	}
+	if err := c.enqueue(transmission{id: id, audio: c.toTransmitFormat(sample)}); err != nil {
+		c.releaseTransmissionID(id)
+		c.transmitLogger.Warn().Err(err).Str("id", id).Msg("dropping transmission")
+	}
+}
+
+// reserveTransmissionID marks the given ID as pending. It returns false if the ID is already pending.
+func (c *audioClient) reserveTransmissionID(id string) bool {
+	c.pendingIDsLock.Lock()
+	defer c.pendingIDsLock.Unlock()
+	if _, ok := c.pendingIDs[id]; ok {
+		return false
+	}
+	if c.pendingIDs == nil {
+		c.pendingIDs = make(map[string]struct{})
+	}
+	c.pendingIDs[id] = struct{}{}
+	return true
+}
+
+// releaseTransmissionID marks the given ID as no longer pending, once its transmission has been sent or discarded.
+func (c *audioClient) releaseTransmissionID(id string) {
+	if id == "" {
+		return
+	}
+	c.pendingIDsLock.Lock()
+	defer c.pendingIDsLock.Unlock()
+	delete(c.pendingIDs, id)
+}
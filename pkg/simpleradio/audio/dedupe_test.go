@@ -0,0 +1,51 @@
+package audio
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransmitWithID(t *testing.T) {
+	t.Parallel()
+	client := &audioClient{
+		guid:         types.NewGUID(),
+		txChan:       make(chan transmission, 4),
+		packetNumber: 1,
+		frameLength:  defaultFrameLength,
+		frameSize:    frameSizeOf(defaultFrameLength),
+	}
+
+	// While a transmission is pending, transmissions with the same ID are dropped.
+	client.TransmitWithID("picture", make(Audio, client.frameSize))
+	client.TransmitWithID("picture", make(Audio, client.frameSize))
+	client.TransmitWithID("bogey dope", make(Audio, client.frameSize))
+	client.TransmitWithID("", make(Audio, client.frameSize))
+	client.TransmitWithID("", make(Audio, client.frameSize))
+	require.Len(t, client.txChan, 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packetCh := make(chan transmission, 4)
+	go client.encodeVoice(ctx, packetCh)
+
+	var ids []string
+	for range 4 {
+		queued := <-packetCh
+		assert.Len(t, queued.packets, 1)
+		ids = append(ids, queued.id)
+	}
+	assert.Equal(t, []string{"picture", "bogey dope", "", ""}, ids)
+
+	// The ID remains pending after encoding, until the transmission has been sent.
+	client.TransmitWithID("picture", make(Audio, client.frameSize))
+	assert.Empty(t, client.txChan)
+	assert.Empty(t, packetCh)
+
+	client.releaseTransmissionID("picture")
+	client.TransmitWithID("picture", make(Audio, client.frameSize))
+	assert.Equal(t, "picture", (<-packetCh).id)
+}
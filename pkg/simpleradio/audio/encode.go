@@ -4,46 +4,63 @@ import (
 	"context"
 
 	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
-	"github.com/rs/zerolog/log"
-	"gopkg.in/hraban/opus.v2"
+	"github.com/martinlindhe/unit"
 )
 
 // Mirror of OPUS_APPLICATION_VOIP from the Opus API.
 const opusApplicationVoIP = 2048
 
-// encodeVoice encodes audio from txChan and publishes an entire transmission's worth of voice packets to packetCh.
-func (c *audioClient) encodeVoice(ctx context.Context, packetCh chan<- []voice.VoicePacket) {
-	frequencyList := make([]voice.Frequency, 0, len(c.radios))
-	for _, radio := range c.radios {
-		frequencyList = append(frequencyList, voice.Frequency{
-			Frequency:  radio.Frequency,
-			Modulation: byte(radio.Modulation),
-			Encryption: 0,
-		})
-	}
+// transmission is a queued transmission. It is passed from the queue to the encoder, which encodes its audio into
+// voice packets, and then to the transmitter.
+type transmission struct {
+	// id identifies the transmission for de-duplication. It is empty if the transmission was queued without an ID.
+	id string
+	// audio is the transmission's mono audio at the SRS sample rate.
+	audio Audio
+	// frequency restricts the transmission to the radios tuned to this frequency. If zero, the transmission is sent on
+	// every radio.
+	frequency unit.Frequency
+	// continues is true if the transmission is a segment of the same logical transmission as the one queued before it,
+	// such as silence which holds the channel between spoken segments. See [audioClient.txSegments].
+	continues bool
+	// packets are the encoded voice packets. They are nil until the transmission is encoded.
+	packets []voice.VoicePacket
+}
+
+// encodeVoice encodes audio from txChan and publishes each transmission with an entire transmission's worth of voice
+// packets to packetCh.
+func (c *audioClient) encodeVoice(ctx context.Context, packetCh chan<- transmission) {
 	for {
 		select {
-		case audio := <-c.txChan:
-			log.Trace().Msg("encoding transmission from PCM data")
-			encoder, err := opus.NewEncoder(sampleRate, channels, opusApplicationVoIP)
+		case queued := <-c.txChan:
+			frequencyList := c.voiceFrequencies()
+			guid := []byte(c.currentGUID())
+			audio := queued.audio
+			if c.fadeDuration > 0 {
+				audio = audio.Fade(c.fadeDuration, c.fadeDuration, sampleRate)
+			}
+			c.capture(audio)
+			c.transmitLogger.Trace().Msg("encoding transmission from PCM data")
+			encoder, err := c.newEncoder()
 			if err != nil {
-				log.Error().Err(err).Msg("failed to create Opus encoder")
+				c.transmitLogger.Error().Err(err).Msg("failed to create Opus encoder")
+				c.finishTransmission(queued.id)
 				continue
 			}
 
 			txPackets := make([]voice.VoicePacket, 0)
-			for i := 0; i < len(audio); i += int(frameSize) {
-				logger := log.With().Int("index", i).Logger()
+			for i := 0; i < len(audio); i += c.frameSize {
+				logger := c.transmitLogger.With().Int("index", i).Logger()
 				var frameAudio []float32
 				// pad frame to frame size
-				if i+int(frameSize) < len(audio) {
-					frameAudio = audio[i : i+int(frameSize)]
+				if i+c.frameSize < len(audio) {
+					frameAudio = audio[i : i+c.frameSize]
 				} else {
 					frameAudio = audio[i:]
 				}
 				// Align audio to Opus frame size
-				if len(frameAudio) < int(frameSize) {
-					padding := make([]float32, int(frameSize)-len(frameAudio))
+				if len(frameAudio) < c.frameSize {
+					padding := make([]float32, c.frameSize-len(frameAudio))
 					frameAudio = append(frameAudio, padding...)
 				}
 				audioBytes, err := c.encode(encoder, frameAudio)
@@ -56,20 +73,42 @@ func (c *audioClient) encodeVoice(ctx context.Context, packetCh chan<- []voice.V
 					audioBytes,
 					frequencyList,
 					100000002,
-					c.packetNumber,
+					c.nextPacketNumber(),
 					0,
-					[]byte(c.guid),
-					[]byte(c.guid),
+					guid,
+					guid,
 				)
-				c.packetNumber++
-				// TODO transmission struct with attached text and trace id
 				txPackets = append(txPackets, vp)
 			}
-			log.Trace().Int("count", len(txPackets)).Msg("encoded transmission packets")
-			packetCh <- txPackets
+			c.transmitLogger.Trace().Int("count", len(txPackets)).Msg("encoded transmission packets")
+			queued.packets = txPackets
+			packetCh <- queued
 		case <-ctx.Done():
-			log.Info().Msg("stopping voice encoder due to context cancellation")
+			c.transmitLogger.Info().Msg("stopping voice encoder due to context cancellation")
 			return
 		}
 	}
 }
+
+// voiceFrequencies returns the frequencies of the client's radios, in the form used in voice packets.
+func (c *audioClient) voiceFrequencies() []voice.Frequency {
+	radios := c.radioList()
+	frequencies := make([]voice.Frequency, 0, len(radios))
+	for _, radio := range radios {
+		frequencies = append(frequencies, voice.Frequency{
+			Frequency:  radio.Frequency,
+			Modulation: byte(radio.Modulation),
+			Encryption: 0,
+		})
+	}
+	return frequencies
+}
+
+// nextPacketNumber returns the packet number for the next transmitted voice packet.
+func (c *audioClient) nextPacketNumber() uint64 {
+	c.packetNumberLock.Lock()
+	defer c.packetNumberLock.Unlock()
+	n := c.packetNumber
+	c.packetNumber++
+	return n
+}
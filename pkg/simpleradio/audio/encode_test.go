@@ -0,0 +1,169 @@
+package audio
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureTransmissions(t *testing.T) {
+	t.Parallel()
+	client := &audioClient{
+		guid:         types.NewGUID(),
+		txChan:       make(chan transmission),
+		captureCh:    make(chan Audio, 1),
+		packetNumber: 1,
+		frameLength:  defaultFrameLength,
+		frameSize:    frameSizeOf(defaultFrameLength),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packetCh := make(chan transmission, 3)
+	go client.encodeVoice(ctx, packetCh)
+
+	sample := Audio{0.1, 0.2, 0.3}
+	client.Transmit(sample)
+	<-packetCh
+	captured := <-client.CaptureTransmissions()
+	assert.Equal(t, sample, captured)
+	captured[0] = 1
+	assert.Equal(t, float32(0.1), sample[0], "captured audio should be a copy")
+
+	// When the consumer falls behind, captured transmissions are dropped without blocking transmission.
+	for range 3 {
+		client.Transmit(sample)
+		<-packetCh
+	}
+	assert.Len(t, client.CaptureTransmissions(), 1)
+	assert.EqualValues(t, 2, client.Stats().CaptureDrops)
+
+	assert.Nil(t, (&audioClient{}).CaptureTransmissions())
+}
+
+func TestFadeTransmissions(t *testing.T) {
+	t.Parallel()
+	client := &audioClient{
+		guid:         types.NewGUID(),
+		txChan:       make(chan transmission),
+		captureCh:    make(chan Audio, 1),
+		packetNumber: 1,
+		frameLength:  defaultFrameLength,
+		frameSize:    frameSizeOf(defaultFrameLength),
+		fadeDuration: 5 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packetCh := make(chan transmission, 1)
+	go client.encodeVoice(ctx, packetCh)
+
+	sample := make(Audio, 1600)
+	for i := range sample {
+		sample[i] = 0.5
+	}
+	client.Transmit(sample)
+	<-packetCh
+	captured := <-client.CaptureTransmissions()
+	assert.Equal(t, sample.Fade(5*time.Millisecond, 5*time.Millisecond, sampleRate), captured)
+	assert.Zero(t, captured[0])
+	assert.Zero(t, captured[len(captured)-1])
+	assert.InDelta(t, 0.5, captured[len(captured)/2], 1e-6)
+}
+
+func TestConcurrentTransmit(t *testing.T) {
+	t.Parallel()
+	client := &audioClient{
+		guid:         types.NewGUID(),
+		radios:       []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}},
+		txChan:       make(chan transmission),
+		packetNumber: 1,
+		frameLength:  defaultFrameLength,
+		frameSize:    frameSizeOf(defaultFrameLength),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packetCh := make(chan transmission)
+	go client.encodeVoice(ctx, packetCh)
+
+	// Transmission n is n frames long, so each transmission can be identified by its packet count.
+	const transmissions = 8
+	var wg sync.WaitGroup
+	for n := 1; n <= transmissions; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Transmit(make(Audio, n*client.frameSize))
+		}()
+	}
+
+	seen := make(map[int]bool)
+	nextPacketID := uint64(1)
+	for range transmissions {
+		packets := (<-packetCh).packets
+		require.NotContains(t, seen, len(packets), "transmission was split or interleaved")
+		seen[len(packets)] = true
+		for _, packet := range packets {
+			assert.Equal(t, nextPacketID, packet.PacketID, "packets from different transmissions were interleaved")
+			nextPacketID++
+		}
+	}
+	wg.Wait()
+	for n := 1; n <= transmissions; n++ {
+		assert.True(t, seen[n], "transmission %d was not sent", n)
+	}
+}
+
+func TestTransmitStereo(t *testing.T) {
+	t.Parallel()
+	client := &audioClient{
+		guid:          types.NewGUID(),
+		txChan:        make(chan transmission),
+		captureCh:     make(chan Audio, 1),
+		packetNumber:  1,
+		frameLength:   defaultFrameLength,
+		frameSize:     frameSizeOf(defaultFrameLength),
+		inputChannels: 2,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packetCh := make(chan transmission, 1)
+	go client.encodeVoice(ctx, packetCh)
+
+	// Interleaved stereo: left and right channels of each sample are adjacent.
+	client.Transmit(Audio{1, 0, 0.5, -0.5, -0.5, -1})
+	<-packetCh
+	assert.Equal(t, Audio{0.5, 0, -0.75}, <-client.CaptureTransmissions())
+}
+
+func TestValidateInputChannels(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, validateInputChannels(1))
+	require.NoError(t, validateInputChannels(2))
+	require.Error(t, validateInputChannels(0))
+	require.Error(t, validateInputChannels(6))
+}
+
+// BenchmarkEncodeVoice measures the encoder loop, from a queued transmission of speech to its voice packets.
+func BenchmarkEncodeVoice(b *testing.B) {
+	for _, name := range fixtures {
+		audio := loadFixture(b, name)
+		b.Run(name, func(b *testing.B) {
+			client := newBenchmarkClient()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			packetCh := make(chan transmission)
+			go client.encodeVoice(ctx, packetCh)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				client.txChan <- transmission{audio: audio}
+				<-packetCh
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/wav"
+	"github.com/stretchr/testify/require"
+)
+
+// fixturesDir holds recordings of speech at the SRS sample rate. They are shared with the speech recognizer's tests.
+var fixturesDir = filepath.Join("..", "..", "recognizer", "testdata")
+
+// fixtures are recordings of speech in fixturesDir, used to benchmark the codec path with representative audio.
+var fixtures = []string{"helloworld.wav", "radiocheck.wav"}
+
+// loadFixture returns the audio in the named WAV file in fixturesDir.
+func loadFixture(tb testing.TB, name string) Audio {
+	tb.Helper()
+	f, err := os.Open(filepath.Join(fixturesDir, name))
+	require.NoError(tb, err)
+	defer f.Close()
+	streamer, format, err := wav.Decode(f)
+	require.NoError(tb, err)
+	defer streamer.Close()
+	require.Equal(tb, beep.SampleRate(sampleRate), format.SampleRate)
+
+	audio := make(Audio, 0, streamer.Len())
+	buf := make([][2]float64, 512)
+	for {
+		n, ok := streamer.Stream(buf)
+		for _, s := range buf[:n] {
+			audio = append(audio, float32(s[0]))
+		}
+		if !ok {
+			break
+		}
+	}
+	require.NoError(tb, streamer.Err())
+	require.NotEmpty(tb, audio)
+	return audio
+}
+
+// newBenchmarkClient returns a client with the fields needed by the encoder and decoder loops.
+func newBenchmarkClient() *audioClient {
+	return &audioClient{
+		guid:         types.NewGUID(),
+		radios:       []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}},
+		txChan:       make(chan transmission),
+		rxchan:       make(chan Audio),
+		packetNumber: 1,
+		frameLength:  defaultFrameLength,
+		frameSize:    frameSizeOf(defaultFrameLength),
+	}
+}
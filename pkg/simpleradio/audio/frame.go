@@ -1,7 +1,10 @@
 package audio
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/dharmab/skyeye/pkg/pcm"
@@ -9,32 +12,133 @@ import (
 )
 
 const (
-	// frameLength is the length of an Opus frame sent by SRS.
-	frameLength = 40 * time.Millisecond
+	// defaultFrameLength is the length of an Opus frame sent by SRS.
+	defaultFrameLength = 40 * time.Millisecond
+	// maxFrameLength is the longest duration of audio that can be contained in a single Opus packet.
+	maxFrameLength = 120 * time.Millisecond
 	// sampleRate is the sample rate of the audio data sent by SRS in Hz.
 	sampleRate = 16000 // Wideband
 	// channels is the number of channels in the audio data sent by SRS.
 	channels = 1 // Mono
 	// encodingBufferSize is the size of the buffer used to encode audio data. The buffer size may effect bitrate.
 	encodingBufferSize = 1024
+	// maxPacketLength is the largest expected voice packet length, which is the maximum UDP payload over Ethernet.
+	maxPacketLength = 1500
+	// normalizationPeak is the peak amplitude of normalized audio. This leaves some headroom to avoid clipping.
+	normalizationPeak = 0.9
 )
 
-// frameSize is the Opus frame size used in SRS voice packets.
-var frameSize = channels * frameLength.Milliseconds() * sampleRate / 1000
+// supportedFrameLengths are the Opus frame lengths which can be used in SRS voice packets. Opus also supports 2.5ms
+// and 5ms frames, but these are too short for SRS's jitter buffer.
+var supportedFrameLengths = []time.Duration{
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	40 * time.Millisecond,
+	60 * time.Millisecond,
+}
+
+// validateFrameLength returns an error if the given frame length cannot be used in SRS voice packets.
+func validateFrameLength(length time.Duration) error {
+	if !slices.Contains(supportedFrameLengths, length) {
+		return errors.New("frame length must be one of 10ms, 20ms, 40ms or 60ms")
+	}
+	return nil
+}
+
+// frameSizeOf returns the number of samples in an Opus frame of the given length.
+func frameSizeOf(length time.Duration) int {
+	return int(channels * length.Milliseconds() * sampleRate / 1000)
+}
+
+// decodeBufferPool pools scratch buffers for decoding Opus frames. Decoded audio is copied out of the scratch buffer
+// before the buffer is returned to the pool, so delivered audio never shares memory with a pooled buffer.
+var decodeBufferPool = sync.Pool{
+	New: func() any {
+		// Other clients may use a different frame length than this client, so the buffer must be large enough for any Opus packet.
+		b := make([]float32, frameSizeOf(maxFrameLength))
+		return &b
+	},
+}
 
-// decode decodes the given Opus frame(s) into F32LE PCM audio data.
-func (c *audioClient) decode(decoder *opus.Decoder, b []byte) ([]float32, error) {
-	f32le := make([]float32, frameSize)
-	n, err := decoder.DecodeFloat32(b, f32le)
+// packetDuration returns the duration of the audio in an Opus packet, from its table of contents byte as described in
+// RFC 6716 section 3.1. Each sender chooses its own frame length, so received packets are measured individually rather
+// than assumed to match this client's frame length.
+func packetDuration(packet []byte) (time.Duration, error) {
+	if len(packet) == 0 {
+		return 0, errors.New("empty Opus packet")
+	}
+	toc := packet[0]
+	config := toc >> 3
+	var frameDuration time.Duration
+	switch {
+	case config < 12:
+		// SILK-only modes.
+		frameDuration = [...]time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond}[config%4]
+	case config < 16:
+		// Hybrid modes.
+		frameDuration = [...]time.Duration{10 * time.Millisecond, 20 * time.Millisecond}[config%2]
+	default:
+		// CELT-only modes.
+		frameDuration = [...]time.Duration{2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond}[config%4]
+	}
+	var frames int
+	switch toc & 0x3 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	default:
+		if len(packet) < 2 {
+			return 0, errors.New("Opus packet is missing its frame count")
+		}
+		frames = int(packet[1] & 0x3F)
+	}
+	duration := time.Duration(frames) * frameDuration
+	if duration == 0 || duration > maxFrameLength {
+		return 0, fmt.Errorf("Opus packet has invalid duration %v", duration)
+	}
+	return duration, nil
+}
+
+// decode decodes the given Opus frame(s) into F32LE PCM audio data and appends it to the given slice. A panic in the Go
+// bindings is recovered and returned as an error wrapping [ErrCodecPanic]; see that error for what cannot be recovered.
+func (c *audioClient) decode(decoder *opus.Decoder, b []byte, f32le []float32) (out []float32, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = f32le, c.codecPanicked("decode", r)
+		}
+	}()
+	bufPtr := decodeBufferPool.Get().(*[]float32)
+	defer decodeBufferPool.Put(bufPtr)
+	buf := *bufPtr
+	n, err := decoder.DecodeFloat32(b, buf)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode Opus audio: %w", err)
+		return f32le, fmt.Errorf("failed to decode Opus audio: %w", err)
+	}
+	return append(f32le, buf[:n*channels]...), nil
+}
+
+// newEncoder returns an Opus encoder for a new transmission.
+func (c *audioClient) newEncoder() (*opus.Encoder, error) {
+	encoder, err := opus.NewEncoder(sampleRate, channels, opusApplicationVoIP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Opus encoder: %w", err)
+	}
+	if c.isDTXEnabled {
+		if err := encoder.SetDTX(true); err != nil {
+			return nil, fmt.Errorf("failed to enable Opus DTX: %w", err)
+		}
 	}
-	f32le = f32le[:n*channels]
-	return f32le, nil
+	return encoder, nil
 }
 
 // encode encodes the given F32LE PCM audio data into an Opus frame.
-func (c *audioClient) encode(encoder *opus.Encoder, f32le []float32) ([]byte, error) {
+func (c *audioClient) encode(encoder *opus.Encoder, f32le []float32) (out []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = nil, c.codecPanicked("encode", r)
+		}
+	}()
 	b := make([]byte, encodingBufferSize)
 	n, err := encoder.Encode(pcm.F32toS16LE(f32le), b)
 	if err != nil {
@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFrameLength(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		length     time.Duration
+		expectedOk bool
+	}{
+		{0, false},
+		{2500 * time.Microsecond, false},
+		{5 * time.Millisecond, false},
+		{10 * time.Millisecond, true},
+		{20 * time.Millisecond, true},
+		{30 * time.Millisecond, false},
+		{40 * time.Millisecond, true},
+		{60 * time.Millisecond, true},
+		{120 * time.Millisecond, false},
+	}
+	for _, test := range tests {
+		t.Run(test.length.String(), func(t *testing.T) {
+			t.Parallel()
+			err := validateFrameLength(test.length)
+			if test.expectedOk {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestFrameSizeOf(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 160, frameSizeOf(10*time.Millisecond))
+	assert.Equal(t, 640, frameSizeOf(40*time.Millisecond))
+	assert.Equal(t, 1920, frameSizeOf(120*time.Millisecond))
+}
+
+func TestPacketDuration(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		packet   []byte
+		expected time.Duration
+	}{
+		{"SILK 10ms", []byte{8 << 3}, 10 * time.Millisecond},
+		{"SILK 40ms", []byte{10 << 3}, 40 * time.Millisecond},
+		{"SILK 60ms", []byte{11 << 3}, 60 * time.Millisecond},
+		{"hybrid 20ms", []byte{13 << 3}, 20 * time.Millisecond},
+		{"CELT 2.5ms", []byte{16 << 3}, 2500 * time.Microsecond},
+		{"CELT 20ms", []byte{31 << 3}, 20 * time.Millisecond},
+		{"two equal frames", []byte{9<<3 | 1}, 40 * time.Millisecond},
+		{"two frames of different sizes", []byte{9<<3 | 2}, 40 * time.Millisecond},
+		{"arbitrary number of frames", []byte{8<<3 | 3, 3}, 30 * time.Millisecond},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			duration, err := packetDuration(test.packet)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, duration)
+		})
+	}
+
+	for _, packet := range [][]byte{nil, {8<<3 | 3}, {8<<3 | 3, 0}, {11<<3 | 3, 3}} {
+		_, err := packetDuration(packet)
+		require.Error(t, err, packet)
+	}
+}
+
+func TestEncodedPacketDuration(t *testing.T) {
+	t.Parallel()
+	client := &audioClient{}
+	for _, length := range supportedFrameLengths {
+		t.Run(length.String(), func(t *testing.T) {
+			t.Parallel()
+			encoder, err := client.newEncoder()
+			require.NoError(t, err)
+			packet, err := client.encode(encoder, make([]float32, frameSizeOf(length)))
+			require.NoError(t, err)
+			duration, err := packetDuration(packet)
+			require.NoError(t, err)
+			assert.Equal(t, length, duration)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// startGracePeriod starts a timer which ends the startup grace period after its maximum duration. The caller should
+// stop the timer when the client stops.
+func (c *audioClient) startGracePeriod() *time.Timer {
+	return time.AfterFunc(max(c.startupGrace, 0), func() {
+		c.releaseOnce.Do(func() {
+			if c.startupGrace > 0 {
+				c.transmitLogger.Warn().Stringer("grace", c.startupGrace).Msg("startup grace period ended before peers were synced")
+			}
+			close(c.releasedCh)
+		})
+	})
+}
+
+// ReleaseTransmissions implements [AudioClient.ReleaseTransmissions].
+func (c *audioClient) ReleaseTransmissions() {
+	c.releaseOnce.Do(func() {
+		c.transmitLogger.Info().Msg("startup grace period ended, releasing deferred transmissions")
+		close(c.releasedCh)
+	})
+}
+
+// awaitStartupGrace blocks until the startup grace period ends, or returns an error if the context is canceled first.
+func (c *audioClient) awaitStartupGrace(ctx context.Context) error {
+	if c.startupGrace <= 0 {
+		return nil
+	}
+	select {
+	case <-c.releasedCh:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("canceled while waiting for startup grace period: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,57 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupGraceDefersTransmissions(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	client.startupGrace = time.Hour
+	client.releasedCh = make(chan struct{})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.TransmitReader(context.Background(), bytes.NewReader(make([]byte, 2*client.frameSize)), sampleRate)
+	}()
+	select {
+	case <-packets:
+		require.Fail(t, "transmitted during startup grace period")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	client.ReleaseTransmissions()
+	client.ReleaseTransmissions()
+	require.NoError(t, <-errCh)
+	assert.Len(t, packets, 1)
+}
+
+func TestStartupGraceEnds(t *testing.T) {
+	t.Parallel()
+	client, _ := newStreamingTestClient(t)
+	client.startupGrace = 10 * time.Millisecond
+	client.releasedCh = make(chan struct{})
+
+	timer := client.startGracePeriod()
+	defer timer.Stop()
+	require.NoError(t, client.awaitStartupGrace(context.Background()))
+	// Releasing after the grace period has ended has no effect.
+	client.ReleaseTransmissions()
+}
+
+func TestStartupGraceCanceled(t *testing.T) {
+	t.Parallel()
+	client, _ := newStreamingTestClient(t)
+	client.startupGrace = time.Hour
+	client.releasedCh = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, client.awaitStartupGrace(ctx), context.Canceled)
+}
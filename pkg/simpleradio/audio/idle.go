@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+)
+
+// IdleFill selects the audio the client publishes for a frequency while nothing is being received on it. Consumers
+// which expect a continuous stream of audio can enable it to stay fed between transmissions.
+type IdleFill int
+
+const (
+	// IdleFillNone publishes nothing while idle. This is the default.
+	IdleFillNone IdleFill = iota
+	// IdleFillSilence publishes frames of silence while idle.
+	IdleFillSilence
+	// IdleFillComfortNoise publishes frames of low-level white noise while idle.
+	IdleFillComfortNoise
+)
+
+// comfortNoiseAmplitude is the peak amplitude of comfort noise, about -60dBFS.
+const comfortNoiseAmplitude = 0.001
+
+// String implements [fmt.Stringer].
+func (f IdleFill) String() string {
+	switch f {
+	case IdleFillNone:
+		return "none"
+	case IdleFillSilence:
+		return "silence"
+	case IdleFillComfortNoise:
+		return "comfort noise"
+	default:
+		return "unknown"
+	}
+}
+
+// SetIdleFill implements [AudioClient.SetIdleFill].
+func (c *audioClient) SetIdleFill(frequency unit.Frequency, fill IdleFill) error {
+	if fill < IdleFillNone || fill > IdleFillComfortNoise {
+		return fmt.Errorf("unknown idle fill %d", fill)
+	}
+	c.idleFillsLock.Lock()
+	defer c.idleFillsLock.Unlock()
+	if c.idleFills == nil {
+		c.idleFills = make(map[types.Radio]IdleFill)
+	}
+	isFound := false
+	for _, radio := range c.radioList() {
+		if types.IsSameFrequency(radio.Frequency, frequency.Hertz(), c.frequencyTolerance) {
+			isFound = true
+			if fill == IdleFillNone {
+				delete(c.idleFills, radio)
+			} else {
+				c.idleFills[radio] = fill
+			}
+		}
+	}
+	if !isFound {
+		return fmt.Errorf("no radio is tuned to %s MHz", types.FormatFrequency(frequency))
+	}
+	c.receiveLogger.Info().Func(types.LogFrequency(frequency)).Stringer("fill", fill).Msg("changed idle fill")
+	return nil
+}
+
+// fillIdle publishes idle fill frames at the frame cadence until the context is canceled.
+func (c *audioClient) fillIdle(ctx context.Context) {
+	ticker := time.NewTicker(c.frameLength)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.publishIdleFill()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publishIdleFill publishes one frame of idle fill for each radio with idle fill enabled which is not receiving a
+// transmission. Frames are published to the receive channel and to the receive streams on the radio's frequency.
+// Idle fill never stalls the client: a frame is dropped if a consumer is not ready for it.
+func (c *audioClient) publishIdleFill() {
+	c.idleFillsLock.RLock()
+	defer c.idleFillsLock.RUnlock()
+	receivers := c.receiverMap()
+	for _, radio := range c.radioList() {
+		fill, ok := c.idleFills[radio]
+		if !ok {
+			continue
+		}
+		if receiver, ok := receivers[radio]; ok {
+			if _, _, isReceiving := receiver.activeTransmission(); isReceiving {
+				continue
+			}
+		}
+		frame := c.idleFrame(fill)
+		if c.isReceivePCM() {
+			select {
+			case c.rxPCMChan <- c.receiveFormat.Encode(frame):
+			default:
+			}
+		} else {
+			select {
+			case c.rxchan <- frame:
+			default:
+			}
+		}
+		c.publishIdleFillToStreams(radio, frame)
+	}
+}
+
+// publishIdleFillToStreams queues a frame of idle fill on every stream whose frequency matches the given radio.
+func (c *audioClient) publishIdleFillToStreams(radio types.Radio, frame Audio) {
+	c.streamsLock.RLock()
+	defer c.streamsLock.RUnlock()
+	for stream := range c.streams {
+		if !types.IsSameFrequency(radio.Frequency, stream.frequency.Hertz(), c.frequencyTolerance) {
+			continue
+		}
+		select {
+		case stream.ch <- frame:
+		default:
+		}
+	}
+}
+
+// idleFrame returns a single frame of the given idle fill.
+func (c *audioClient) idleFrame(fill IdleFill) Audio {
+	frame := make(Audio, c.frameSize)
+	if fill == IdleFillComfortNoise {
+		for i := range frame {
+			frame[i] = comfortNoiseAmplitude * (2*rand.Float32() - 1)
+		}
+	}
+	return frame
+}
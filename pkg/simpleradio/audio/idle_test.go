@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdleFill(t *testing.T) {
+	t.Parallel()
+	guard := types.Radio{Frequency: 243000000, Modulation: types.ModulationAM}
+	working := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	c := &audioClient{
+		radios:             []types.Radio{guard, working},
+		receivers:          map[types.Radio]*receiver{guard: {}, working: {}},
+		rxchan:             make(chan Audio, 4),
+		streams:            make(map[*receiveStream]struct{}),
+		frequencyTolerance: types.DefaultFrequencyTolerance,
+		frameLength:        defaultFrameLength,
+		frameSize:          frameSizeOf(defaultFrameLength),
+	}
+
+	// Idle fill is opt-in.
+	c.publishIdleFill()
+	assert.Empty(t, c.rxchan)
+
+	require.Error(t, c.SetIdleFill(133*unit.Megahertz, IdleFillSilence))
+	require.Error(t, c.SetIdleFill(251*unit.Megahertz, IdleFill(-1)))
+
+	require.NoError(t, c.SetIdleFill(251*unit.Megahertz, IdleFillSilence))
+	stream := c.ReceiveReader(251 * unit.Megahertz)
+	c.publishIdleFill()
+	require.Len(t, c.rxchan, 1)
+	assert.Equal(t, make(Audio, c.frameSize), <-c.rxchan)
+
+	// Nothing is published while a transmission is being received.
+	c.receivers[working].deadline = time.Now().Add(time.Hour)
+	c.publishIdleFill()
+	assert.Empty(t, c.rxchan)
+	c.receivers[working].deadline = time.Time{}
+
+	require.NoError(t, c.SetIdleFill(251*unit.Megahertz, IdleFillComfortNoise))
+	c.publishIdleFill()
+	require.Len(t, c.rxchan, 1)
+	noise := <-c.rxchan
+	require.Len(t, noise, c.frameSize)
+	assert.NotEqual(t, make(Audio, c.frameSize), noise)
+	for _, s := range noise {
+		assert.LessOrEqual(t, s, float32(comfortNoiseAmplitude))
+		assert.GreaterOrEqual(t, s, float32(-comfortNoiseAmplitude))
+	}
+
+	// The stream on the frequency received both frames.
+	c.closeStreams()
+	b, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Len(t, pcm.S16LEBytesToF32LE(b), 2*c.frameSize)
+
+	require.NoError(t, c.SetIdleFill(251*unit.Megahertz, IdleFillNone))
+	c.publishIdleFill()
+	assert.Empty(t, c.rxchan)
+}
@@ -0,0 +1,62 @@
+package audio
+
+import (
+	"fmt"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/martinlindhe/unit"
+)
+
+// SetReceiveEnabled implements [AudioClient.SetReceiveEnabled].
+func (c *audioClient) SetReceiveEnabled(frequency unit.Frequency, isEnabled bool) error {
+	c.receiveDisabledLock.Lock()
+	defer c.receiveDisabledLock.Unlock()
+	if c.receiveDisabledRadios == nil {
+		c.receiveDisabledRadios = make(map[types.Radio]bool)
+	}
+	isFound := false
+	for _, radio := range c.radioList() {
+		if types.IsSameFrequency(radio.Frequency, frequency.Hertz(), c.frequencyTolerance) {
+			isFound = true
+			if isEnabled {
+				delete(c.receiveDisabledRadios, radio)
+			} else {
+				c.receiveDisabledRadios[radio] = true
+			}
+		}
+	}
+	if !isFound {
+		return fmt.Errorf("no radio is tuned to %s MHz", types.FormatFrequency(frequency))
+	}
+	c.receiveLogger.Info().Func(types.LogFrequency(frequency)).Bool("enabled", isEnabled).Msg("changed frequency receive")
+	return nil
+}
+
+// isReceiveDisabled returns true if the transmission in the given voice packets is only on radios whose receive is
+// disabled or suppressed by mute, so it does not need to be decoded.
+func (c *audioClient) isReceiveDisabled(voicePackets []voice.VoicePacket) bool {
+	if len(voicePackets) == 0 {
+		return false
+	}
+	c.receiveDisabledLock.RLock()
+	defer c.receiveDisabledLock.RUnlock()
+	c.muteLock.RLock()
+	defer c.muteLock.RUnlock()
+	isSuppressing := c.muteSuppressesReceive && (c.mute || len(c.mutedRadios) > 0)
+	if len(c.receiveDisabledRadios) == 0 && !isSuppressing {
+		return false
+	}
+	for _, radio := range c.radioList() {
+		if c.receiveDisabledRadios[radio] {
+			continue
+		}
+		if c.muteSuppressesReceive && (c.mute || c.mutedRadios[radio]) {
+			continue
+		}
+		if isOnFrequency(voicePackets[0], types.FrequencyFromHertz(radio.Frequency), c.frequencyTolerance) {
+			return false
+		}
+	}
+	return true
+}
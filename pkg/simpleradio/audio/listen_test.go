@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// transmissionOn returns a transmission heard on the given radios.
+func transmissionOn(radios ...types.Radio) []voice.VoicePacket {
+	frequencies := make([]voice.Frequency, 0, len(radios))
+	for _, radio := range radios {
+		frequencies = append(frequencies, voice.Frequency{Frequency: radio.Frequency, Modulation: byte(radio.Modulation)})
+	}
+	return []voice.VoicePacket{voice.NewVoicePacket([]byte{1, 2, 3}, frequencies, 0, 1, 0, nil, nil)}
+}
+
+func TestSetReceiveEnabled(t *testing.T) {
+	t.Parallel()
+	guard := types.Radio{Frequency: 243000000, Modulation: types.ModulationAM}
+	working := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	client := &audioClient{radios: []types.Radio{guard, working}, frequencyTolerance: types.DefaultFrequencyTolerance}
+
+	require.Error(t, client.SetReceiveEnabled(133*unit.Megahertz, false))
+	assert.False(t, client.isReceiveDisabled(transmissionOn(guard)))
+
+	require.NoError(t, client.SetReceiveEnabled(243*unit.Megahertz, false))
+	assert.True(t, client.isReceiveDisabled(transmissionOn(guard)))
+	assert.False(t, client.isReceiveDisabled(transmissionOn(working)))
+	// A transmission heard on any enabled radio is still decoded.
+	assert.False(t, client.isReceiveDisabled(transmissionOn(guard, working)))
+
+	require.NoError(t, client.SetReceiveEnabled(243*unit.Megahertz, true))
+	assert.False(t, client.isReceiveDisabled(transmissionOn(guard)))
+}
+
+func TestSuppressReceiveWhenMuted(t *testing.T) {
+	t.Parallel()
+	guard := types.Radio{Frequency: 243000000, Modulation: types.ModulationAM}
+	working := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	testCases := []struct {
+		name     string
+		suppress bool
+		mute     bool
+		mutedOn  unit.Frequency
+		// expected is whether a transmission heard on each of guard and working is dropped.
+		expected [2]bool
+	}{
+		{name: "not muted", suppress: true, expected: [2]bool{false, false}},
+		{name: "muted and listening", mute: true, expected: [2]bool{false, false}},
+		{name: "muted and silent", suppress: true, mute: true, expected: [2]bool{true, true}},
+		{name: "frequency muted and listening", mutedOn: 243 * unit.Megahertz, expected: [2]bool{false, false}},
+		{name: "frequency muted and silent", suppress: true, mutedOn: 243 * unit.Megahertz, expected: [2]bool{true, false}},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			client := &audioClient{
+				radios:                []types.Radio{guard, working},
+				frequencyTolerance:    types.DefaultFrequencyTolerance,
+				mute:                  test.mute,
+				muteSuppressesReceive: test.suppress,
+			}
+			if test.mutedOn != 0 {
+				require.NoError(t, client.SetMutedOn(test.mutedOn, true))
+			}
+			assert.Equal(t, test.expected[0], client.isReceiveDisabled(transmissionOn(guard)), "guard")
+			assert.Equal(t, test.expected[1], client.isReceiveDisabled(transmissionOn(working)), "working")
+			// A transmission heard on any radio which is not silenced is still received.
+			assert.Equal(t, test.expected[0] && test.expected[1], client.isReceiveDisabled(transmissionOn(guard, working)))
+		})
+	}
+
+	_, err := NewClient(types.NewGUID(), types.ClientConfiguration{SuppressReceiveWhenMuted: true, ListenAll: true})
+	require.Error(t, err)
+}
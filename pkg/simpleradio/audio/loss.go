@@ -0,0 +1,28 @@
+package audio
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+)
+
+// validatePacketLoss returns an error if the packet loss simulation options are invalid. Loss rates are rejected
+// unless packet loss simulation is explicitly enabled.
+func validatePacketLoss(config types.DebugConfiguration) error {
+	for name, rate := range map[string]float64{"receive": config.ReceiveLossRate, "transmit": config.TransmitLossRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s loss rate must be between 0 and 1, got %v", name, rate)
+		}
+		if rate > 0 && !config.SimulatePacketLoss {
+			return fmt.Errorf("%s loss rate is set but packet loss simulation is not enabled", name)
+		}
+	}
+	return nil
+}
+
+// dropPacket returns true if a packet should be dropped to simulate packet loss at the given rate. It uses the global
+// random source because it is called from both the receive and transmit goroutines.
+func dropPacket(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
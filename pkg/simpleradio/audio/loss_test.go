@@ -0,0 +1,59 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePacketLoss(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name    string
+		config  types.DebugConfiguration
+		isValid bool
+	}{
+		{name: "disabled", config: types.DebugConfiguration{}, isValid: true},
+		{name: "enabled", config: types.DebugConfiguration{SimulatePacketLoss: true, ReceiveLossRate: 0.1, TransmitLossRate: 1}, isValid: true},
+		{name: "not enabled", config: types.DebugConfiguration{ReceiveLossRate: 0.1}, isValid: false},
+		{name: "negative", config: types.DebugConfiguration{SimulatePacketLoss: true, TransmitLossRate: -0.1}, isValid: false},
+		{name: "above one", config: types.DebugConfiguration{SimulatePacketLoss: true, ReceiveLossRate: 1.5}, isValid: false},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := validatePacketLoss(test.config)
+			if test.isValid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestDropPacket(t *testing.T) {
+	t.Parallel()
+	dropped := 0
+	for range 10000 {
+		assert.False(t, dropPacket(0))
+		assert.True(t, dropPacket(1))
+		if dropPacket(0.25) {
+			dropped++
+		}
+	}
+	assert.InDelta(t, 2500, dropped, 300)
+}
+
+func TestWritePacketsWithLoss(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	client.transmitLossRate = 1
+	vp := voice.NewVoicePacket([]byte{1, 2, 3}, client.voiceFrequencies(), 100000002, 1, 0, []byte(client.guid), []byte(client.guid))
+	client.writePackets([]voice.VoicePacket{vp, vp}, client.voiceFrequencies(), newPacer(client.pacingStrategy, client.frameLength))
+	assert.Zero(t, client.Stats().PacketsSent)
+	assert.Empty(t, packets)
+}
@@ -0,0 +1,59 @@
+package audio
+
+import (
+	"fmt"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/martinlindhe/unit"
+)
+
+// SetMutedOn implements [AudioClient.SetMutedOn].
+func (c *audioClient) SetMutedOn(frequency unit.Frequency, muted bool) error {
+	c.muteLock.Lock()
+	defer c.muteLock.Unlock()
+	if c.mutedRadios == nil {
+		c.mutedRadios = make(map[types.Radio]bool)
+	}
+	isFound := false
+	for _, radio := range c.radioList() {
+		if types.IsSameFrequency(radio.Frequency, frequency.Hertz(), c.frequencyTolerance) {
+			isFound = true
+			if muted {
+				c.mutedRadios[radio] = true
+			} else {
+				delete(c.mutedRadios, radio)
+			}
+		}
+	}
+	if !isFound {
+		return fmt.Errorf("no radio is tuned to %s MHz", types.FormatFrequency(frequency))
+	}
+	c.transmitLogger.Info().Func(types.LogFrequency(frequency)).Bool("muted", muted).Msg("changed frequency mute")
+	return nil
+}
+
+// unmutedFrequencies returns the frequencies of the client's radios which are not muted, in the form used in voice
+// packets.
+func (c *audioClient) unmutedFrequencies() []voice.Frequency {
+	c.muteLock.RLock()
+	defer c.muteLock.RUnlock()
+	radios := c.radioList()
+	frequencies := make([]voice.Frequency, 0, len(radios))
+	for _, radio := range radios {
+		if c.mutedRadios[radio] {
+			continue
+		}
+		frequencies = append(frequencies, voice.Frequency{
+			Frequency:  radio.Frequency,
+			Modulation: byte(radio.Modulation),
+			Encryption: 0,
+		})
+	}
+	return frequencies
+}
+
+// SetTransmitPermitted implements [AudioClient.SetTransmitPermitted].
+func (c *audioClient) SetTransmitPermitted(isPermitted bool) {
+	c.isTransmitForbidden.Store(!isPermitted)
+}
@@ -0,0 +1,45 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetMutedOn(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	guard := types.Radio{Frequency: 243000000, Modulation: types.ModulationAM}
+	working := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	client.radios = []types.Radio{guard, working}
+	client.frequencyTolerance = types.DefaultFrequencyTolerance
+	transmission := []voice.VoicePacket{voice.NewVoicePacket([]byte{1, 2, 3}, nil, 100000002, 1, 0, []byte(client.guid), []byte(client.guid))}
+
+	require.Error(t, client.SetMutedOn(133*unit.Megahertz, true))
+
+	require.NoError(t, client.SetMutedOn(243*unit.Megahertz, true))
+	client.tx(transmission)
+	packet := <-packets
+	require.Len(t, packet.Frequencies, 1)
+	assert.InDelta(t, working.Frequency, packet.Frequencies[0].Frequency, 0)
+
+	// When every frequency is muted, nothing is transmitted.
+	require.NoError(t, client.SetMutedOn(251*unit.Megahertz, true))
+	client.tx(transmission)
+	assert.Empty(t, packets)
+
+	require.NoError(t, client.SetMutedOn(243*unit.Megahertz, false))
+	require.NoError(t, client.SetMutedOn(251*unit.Megahertz, false))
+	client.tx(transmission)
+	packet = <-packets
+	assert.Len(t, packet.Frequencies, 2)
+
+	// The configured mute takes precedence over per-frequency mute.
+	client.mute = true
+	client.tx(transmission)
+	assert.Empty(t, packets)
+}
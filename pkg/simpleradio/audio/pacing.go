@@ -0,0 +1,84 @@
+package audio
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+)
+
+const (
+	// spinThreshold is how long before a packet is due that PacingSpin stops sleeping and starts busy-waiting. This
+	// should exceed the timer granularity of common operating systems.
+	spinThreshold = 2 * time.Millisecond
+	// burstSize is the number of packets sent together by PacingBurst.
+	burstSize = 2
+)
+
+// validatePacingStrategy returns an error if the given pacing strategy is not recognized.
+func validatePacingStrategy(strategy types.PacingStrategy) error {
+	switch strategy {
+	case types.PacingSleep, types.PacingSpin, types.PacingBurst:
+		return nil
+	default:
+		return fmt.Errorf("unknown pacing strategy %d", strategy)
+	}
+}
+
+// packetDeadline returns the time the i-th packet of a transmission which started at the given time is due to be sent.
+// Packets are sent halfway through the previous packet's frame. Write too quickly, and the server will skip audio to
+// play the latest packet. Write too slowly, and the transmission will stutter.
+//
+// The start time should come from [time.Now], so that it and the returned deadline carry a monotonic clock reading.
+// Comparisons and durations between such times use the monotonic clock, so pacing is unaffected if the wall clock jumps
+// during a transmission, such as when NTP corrects the system clock. Do not strip the monotonic reading from pacing
+// times with methods like Round(0), UTC or UnixNano.
+func packetDeadline(strategy types.PacingStrategy, start time.Time, i int, frameLength time.Duration) time.Time {
+	if strategy == types.PacingBurst {
+		i -= i % burstSize
+	}
+	return start.Add(time.Duration(i) * frameLength).Add(-frameLength / 2)
+}
+
+// pacer schedules the packets of a transmission. If a packet is more than a frame late, such as because audio read from
+// a stream or the next segment of a transmission arrived late, the schedule is re-anchored at the current time, so the
+// packets after a stall keep their usual spacing instead of being sent in a burst to catch up.
+type pacer struct {
+	strategy    types.PacingStrategy
+	frameLength time.Duration
+	// start is the time the schedule is anchored at. See packetDeadline.
+	start time.Time
+	// index is the index of the next packet since start.
+	index int
+}
+
+// newPacer creates a pacer whose schedule starts now.
+func newPacer(strategy types.PacingStrategy, frameLength time.Duration) *pacer {
+	return &pacer{strategy: strategy, frameLength: frameLength, start: time.Now()}
+}
+
+// wait blocks until the next packet is due.
+func (p *pacer) wait() {
+	deadline := packetDeadline(p.strategy, p.start, p.index, p.frameLength)
+	if now := time.Now(); now.Sub(deadline) > p.frameLength {
+		// Anchor the schedule so that this packet is due now.
+		p.start = now.Add(p.frameLength / 2)
+		p.index = 0
+		deadline = packetDeadline(p.strategy, p.start, p.index, p.frameLength)
+	}
+	waitUntil(p.strategy, deadline)
+	p.index++
+}
+
+// waitUntil blocks until the given deadline using the given pacing strategy.
+func waitUntil(strategy types.PacingStrategy, deadline time.Time) {
+	if strategy != types.PacingSpin {
+		time.Sleep(time.Until(deadline))
+		return
+	}
+	time.Sleep(time.Until(deadline) - spinThreshold)
+	for time.Now().Before(deadline) {
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketDeadline(t *testing.T) {
+	t.Parallel()
+	start := time.Now()
+	frame := 40 * time.Millisecond
+	testCases := []struct {
+		strategy types.PacingStrategy
+		expected []time.Duration
+	}{
+		{strategy: types.PacingSleep, expected: []time.Duration{-20, 20, 60, 100, 140}},
+		{strategy: types.PacingSpin, expected: []time.Duration{-20, 20, 60, 100, 140}},
+		{strategy: types.PacingBurst, expected: []time.Duration{-20, -20, 60, 60, 140}},
+	}
+	for _, test := range testCases {
+		t.Run(test.strategy.String(), func(t *testing.T) {
+			t.Parallel()
+			for i, expected := range test.expected {
+				actual := packetDeadline(test.strategy, start, i, frame)
+				assert.Equal(t, expected*time.Millisecond, actual.Sub(start), "packet %d", i)
+			}
+		})
+	}
+}
+
+func TestPacketDeadlineIsMonotonic(t *testing.T) {
+	t.Parallel()
+	start := time.Now()
+	// wallStart is the same instant as start without its monotonic clock reading, which is how a time looks after
+	// Round(0), UTC or a round trip through UnixNano.
+	wallStart := start.Round(0)
+	frame := 40 * time.Millisecond
+	for _, strategy := range []types.PacingStrategy{types.PacingSleep, types.PacingSpin, types.PacingBurst} {
+		for i := range 5 {
+			deadline := packetDeadline(strategy, start, i, frame)
+			offset := deadline.Sub(start)
+			require.True(t, deadline.Equal(wallStart.Add(offset)))
+			// Unlike Equal, == also compares monotonic clock readings. The deadline is only identical to start shifted
+			// by Add if it kept start's monotonic reading, which is what makes time.Until measure it on the monotonic
+			// clock rather than the wall clock.
+			assert.True(t, deadline == start.Add(offset), "%s packet %d deadline lost its monotonic reading", strategy, i)
+			assert.False(t, deadline == wallStart.Add(offset), "%s packet %d deadline has no monotonic reading", strategy, i)
+		}
+	}
+}
+
+func TestPacerReanchorsAfterStall(t *testing.T) {
+	t.Parallel()
+	frame := 10 * time.Millisecond
+	p := newPacer(types.PacingSleep, frame)
+	p.wait()
+	p.wait()
+	require.Equal(t, 2, p.index)
+
+	// A packet more than a frame late is due immediately, and the next packet is a frame later.
+	time.Sleep(5 * frame)
+	stalled := time.Now()
+	p.wait()
+	assert.Equal(t, 1, p.index)
+	assert.Less(t, time.Since(stalled), frame)
+	assert.Equal(t, p.start.Add(frame/2), packetDeadline(p.strategy, p.start, p.index, frame))
+}
+
+func TestValidatePacingStrategy(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, validatePacingStrategy(types.PacingSleep))
+	require.NoError(t, validatePacingStrategy(types.PacingSpin))
+	require.NoError(t, validatePacingStrategy(types.PacingBurst))
+	require.Error(t, validatePacingStrategy(types.PacingStrategy(99)))
+}
+
+// BenchmarkPacing measures how late each pacing strategy sends packets relative to their deadlines. The mean lateness
+// is reported as the "late-ns/packet" metric.
+func BenchmarkPacing(b *testing.B) {
+	frame := 10 * time.Millisecond
+	for _, strategy := range []types.PacingStrategy{types.PacingSleep, types.PacingSpin, types.PacingBurst} {
+		b.Run(strategy.String(), func(b *testing.B) {
+			var lateness time.Duration
+			start := time.Now()
+			for i := range b.N {
+				deadline := packetDeadline(strategy, start, i, frame)
+				waitUntil(strategy, deadline)
+				lateness += time.Since(deadline)
+			}
+			b.ReportMetric(float64(lateness.Nanoseconds())/float64(b.N), "late-ns/packet")
+		})
+	}
+}
@@ -3,20 +3,41 @@ package audio
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"sync"
 	"time"
 
 	srs "github.com/dharmab/skyeye/pkg/simpleradio/types"
-	"github.com/rs/zerolog/log"
 )
 
-// pingInterval determines how often we should ping the SRS server over UDP.
-const pingInterval = 15 * time.Second
+const (
+	// defaultPingInterval determines how often we should ping the SRS server over UDP by default. This matches the
+	// official SRS client.
+	defaultPingInterval = 15 * time.Second
+	// minPingInterval is the shortest allowed ping interval. Pinging more often only wastes bandwidth.
+	minPingInterval = 1 * time.Second
+	// maxPingInterval is the longest allowed ping interval. The SRS server echoes each ping back to the client, and
+	// the client considers the connection lost if no ping is received for a minute. The interval must be short enough
+	// that a few lost pings do not trigger a disconnect.
+	maxPingInterval = 30 * time.Second
+)
+
+// ErrHandshakeTimeout is returned by Run when the SRS server does not echo the client's first ping within the
+// handshake timeout.
+var ErrHandshakeTimeout = errors.New("timed out waiting for the SRS server to respond to the first UDP ping")
+
+// validatePingInterval returns an error if the given ping interval is outside the allowed range.
+func validatePingInterval(interval time.Duration) error {
+	if interval < minPingInterval || interval > maxPingInterval {
+		return fmt.Errorf("ping interval must be between %v and %v", minPingInterval, maxPingInterval)
+	}
+	return nil
+}
 
 // sendPings is a loop which sends the client GUID to the server at regular intervals to keep our connection alive.
 func (c *audioClient) sendPings(ctx context.Context, wg *sync.WaitGroup) {
-	log.Info().Stringer("interval", pingInterval).Msg("starting pings")
+	c.pingLogger.Info().Stringer("interval", c.pingInterval).Msg("starting pings")
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -24,13 +45,13 @@ func (c *audioClient) sendPings(ctx context.Context, wg *sync.WaitGroup) {
 		c.SendPing()
 	}()
 
-	ticker := time.NewTicker(pingInterval)
+	ticker := time.NewTicker(c.pingInterval)
 	for {
 		select {
 		case <-ticker.C:
 			c.SendPing()
 		case <-ctx.Done():
-			log.Info().Msg("stopping SRS pings due to context cancelation")
+			c.pingLogger.Info().Msg("stopping SRS pings due to context cancelation")
 			return
 		}
 	}
@@ -39,9 +60,10 @@ func (c *audioClient) sendPings(ctx context.Context, wg *sync.WaitGroup) {
 // SendPing sends a single ping to the SRS server. "One ping only, Vasily."
 // The SRS server won't send us any audio until it receives a ping from us, so this is useful to initialize VoIP.
 func (c *audioClient) SendPing() {
-	logger := log.With().Str("GUID", string(c.guid)).Logger()
+	guid := c.currentGUID()
+	logger := c.pingLogger.With().Str("GUID", string(guid)).Logger()
 	logger.Trace().Msg("sending UDP ping")
-	n, err := c.connection.Write([]byte(c.guid))
+	n, err := c.write([]byte(guid))
 	if errors.Is(err, net.ErrClosed) {
 		logger.Warn().Msg("ping skipped due to closed connection")
 	} else if err != nil {
@@ -52,3 +74,25 @@ func (c *audioClient) SendPing() {
 		logger.Trace().Msg("sent UDP ping")
 	}
 }
+
+// awaitHandshake waits for the first ping round-trip, which is signaled by the closing of handshakeCh, then closes
+// readyCh. It returns an error wrapping [ErrHandshakeTimeout] if the handshake timeout expires first. It returns nil
+// without closing readyCh if the context is canceled first.
+func (c *audioClient) awaitHandshake(ctx context.Context, handshakeCh <-chan any, readyCh chan<- any) error {
+	var timeoutCh <-chan time.Time
+	if c.handshakeTimeout > 0 {
+		timer := time.NewTimer(c.handshakeTimeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+	select {
+	case <-handshakeCh:
+		close(readyCh)
+		return nil
+	case <-timeoutCh:
+		c.pingLogger.Error().Stringer("timeout", c.handshakeTimeout).Msg("SRS server did not respond to UDP ping; check the server address and that UDP is not blocked")
+		return fmt.Errorf("no ping received after %v: %w", c.handshakeTimeout, ErrHandshakeTimeout)
+	case <-ctx.Done():
+		return nil
+	}
+}
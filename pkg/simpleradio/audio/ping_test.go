@@ -0,0 +1,87 @@
+package audio
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePingInterval(t *testing.T) {
+	t.Parallel()
+	require.Error(t, validatePingInterval(0))
+	require.Error(t, validatePingInterval(500*time.Millisecond))
+	require.NoError(t, validatePingInterval(1*time.Second))
+	require.NoError(t, validatePingInterval(defaultPingInterval))
+	require.NoError(t, validatePingInterval(30*time.Second))
+	require.Error(t, validatePingInterval(1*time.Minute))
+}
+
+// runHandshakeTest runs a client against a UDP server which echoes pings if echo is true, and returns the client's
+// ready channel and the error returned by Run.
+func runHandshakeTest(t *testing.T, echo bool) (<-chan any, <-chan error) {
+	t.Helper()
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+	if echo {
+		go func() {
+			b := make([]byte, maxPacketLength)
+			for {
+				n, addr, err := server.ReadFromUDP(b)
+				if err != nil {
+					return
+				}
+				_, _ = server.WriteToUDP(b[:n], addr)
+			}
+		}()
+	}
+
+	client, err := NewClient(types.NewGUID(), types.ClientConfiguration{
+		Address:          server.LocalAddr().String(),
+		PingInterval:     time.Second,
+		HandshakeTimeout: 1500 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	readyCh := make(chan any)
+	runErr := make(chan error, 1)
+	var wg sync.WaitGroup
+	go func() {
+		runErr <- client.Run(ctx, &wg, readyCh)
+	}()
+	return readyCh, runErr
+}
+
+func TestHandshake(t *testing.T) {
+	t.Parallel()
+	readyCh, runErr := runHandshakeTest(t, true)
+	select {
+	case <-readyCh:
+	case err := <-runErr:
+		require.FailNow(t, "Run returned before the handshake", err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "client did not become ready")
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	t.Parallel()
+	readyCh, runErr := runHandshakeTest(t, false)
+	select {
+	case err := <-runErr:
+		require.ErrorIs(t, err, ErrHandshakeTimeout)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Run did not time out")
+	}
+	select {
+	case <-readyCh:
+		require.Fail(t, "client became ready without a handshake")
+	default:
+	}
+}
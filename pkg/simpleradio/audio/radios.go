@@ -0,0 +1,89 @@
+package audio
+
+import (
+	"maps"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+)
+
+// radioList returns the client's radios. The slice must not be modified.
+func (c *audioClient) radioList() []types.Radio {
+	c.radiosLock.RLock()
+	defer c.radiosLock.RUnlock()
+	return c.radios
+}
+
+// receiverMap returns the receiver of each of the client's radios. The map must not be modified.
+func (c *audioClient) receiverMap() map[types.Radio]*receiver {
+	c.radiosLock.RLock()
+	defer c.radiosLock.RUnlock()
+	return c.receivers
+}
+
+// AddRadio implements [AudioClient.AddRadio].
+func (c *audioClient) AddRadio(radio types.Radio) error {
+	c.radiosLock.Lock()
+	defer c.radiosLock.Unlock()
+	radios, err := types.AddRadio(c.radios, radio, c.frequencyTolerance)
+	if err != nil {
+		return err
+	}
+	receivers := maps.Clone(c.receivers)
+	if receivers == nil {
+		receivers = make(map[types.Radio]*receiver)
+	}
+	receivers[radio] = &receiver{}
+	c.radios, c.receivers = radios, receivers
+	c.receiveLogger.Info().Func(types.LogFrequency(types.FrequencyFromHertz(radio.Frequency))).Msg("added radio")
+	return nil
+}
+
+// RemoveRadio implements [AudioClient.RemoveRadio].
+func (c *audioClient) RemoveRadio(frequency unit.Frequency) error {
+	c.radiosLock.Lock()
+	radios, removed, err := types.RemoveRadio(c.radios, frequency, c.frequencyTolerance)
+	if err != nil {
+		c.radiosLock.Unlock()
+		return err
+	}
+	receivers := maps.Clone(c.receivers)
+	for _, radio := range removed {
+		delete(receivers, radio)
+	}
+	removedReceivers := c.receivers
+	c.radios, c.receivers = radios, receivers
+	c.radiosLock.Unlock()
+
+	for _, radio := range removed {
+		if receiver, ok := removedReceivers[radio]; ok {
+			c.endActivity(radio, receiver)
+		}
+	}
+
+	c.forgetRadios(removed)
+	c.receiveLogger.Info().Func(types.LogFrequency(frequency)).Msg("removed radio")
+	return nil
+}
+
+// forgetRadios clears the mute, receive and idle fill settings of the given removed radios, so that they do not apply
+// if the radios are added again.
+func (c *audioClient) forgetRadios(radios []types.Radio) {
+	c.muteLock.Lock()
+	for _, radio := range radios {
+		delete(c.mutedRadios, radio)
+	}
+	c.muteLock.Unlock()
+
+	c.receiveDisabledLock.Lock()
+	for _, radio := range radios {
+		delete(c.receiveDisabledRadios, radio)
+	}
+	c.receiveDisabledLock.Unlock()
+
+	c.idleFillsLock.Lock()
+	for _, radio := range radios {
+		delete(c.idleFills, radio)
+	}
+	c.idleFillsLock.Unlock()
+}
@@ -0,0 +1,72 @@
+package audio
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRemoveRadio(t *testing.T) {
+	t.Parallel()
+	uhf := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	vhf := types.Radio{Frequency: 133000000, Modulation: types.ModulationAM}
+	c := &audioClient{
+		radios:             []types.Radio{uhf},
+		receivers:          map[types.Radio]*receiver{uhf: {}},
+		frequencyTolerance: types.DefaultFrequencyTolerance,
+	}
+	uhfReceiver := c.receivers[uhf]
+	uhfReceiver.receive(&voice.VoicePacket{PacketID: 1, OriginGUID: []byte(types.NewGUID())})
+
+	// Adding a radio does not disturb the transmission being received on an existing radio.
+	require.NoError(t, c.AddRadio(vhf))
+	assert.Equal(t, []types.Radio{uhf, vhf}, c.Radios())
+	assert.Equal(t, []unit.Frequency{251 * unit.Megahertz, 133 * unit.Megahertz}, c.Frequencies())
+	assert.Same(t, uhfReceiver, c.receiverMap()[uhf])
+	assert.Len(t, uhfReceiver.buffer, 1, "the buffered transmission should be kept")
+	require.Error(t, c.AddRadio(types.Radio{Frequency: 133000200, Modulation: types.ModulationAM}))
+
+	// Removing a radio clears its settings, so they do not apply if it is added again.
+	require.NoError(t, c.SetMutedOn(133*unit.Megahertz, true))
+	require.NoError(t, c.SetReceiveEnabled(133*unit.Megahertz, false))
+	require.NoError(t, c.SetIdleFill(133*unit.Megahertz, IdleFillSilence))
+	require.NoError(t, c.RemoveRadio(133*unit.Megahertz))
+	assert.Equal(t, []types.Radio{uhf}, c.Radios())
+	assert.NotContains(t, c.receiverMap(), vhf)
+	assert.Empty(t, c.mutedRadios)
+	assert.Empty(t, c.receiveDisabledRadios)
+	assert.Empty(t, c.idleFills)
+	require.Error(t, c.RemoveRadio(133*unit.Megahertz))
+	require.Error(t, c.SetMutedOn(133*unit.Megahertz, true))
+}
+
+func TestAddRemoveRadioConcurrently(t *testing.T) {
+	t.Parallel()
+	uhf := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	c := &audioClient{
+		radios:             []types.Radio{uhf},
+		receivers:          map[types.Radio]*receiver{uhf: {}},
+		frequencyTolerance: types.DefaultFrequencyTolerance,
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			_ = c.ReceiverStates()
+			_ = c.voiceFrequencies()
+			c.publishIdleFill()
+		}
+	}()
+	for range 100 {
+		require.NoError(t, c.AddRadio(types.Radio{Frequency: 133000000, Modulation: types.ModulationAM}))
+		require.NoError(t, c.RemoveRadio(133*unit.Megahertz))
+	}
+	wg.Wait()
+	assert.Equal(t, []types.Radio{uhf}, c.Radios())
+}
@@ -4,12 +4,13 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net"
 	"sync"
 	"time"
 
+	"github.com/dharmab/skyeye/pkg/simpleradio/internal/callbacks"
 	"github.com/dharmab/skyeye/pkg/simpleradio/types"
 	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
-	"github.com/rs/zerolog/log"
 )
 
 // receiver contains the state of the current received transmission on a given radio frequency.
@@ -24,30 +25,74 @@ type receiver struct {
 	// packetNumber is the number of the last received voice packet. We only record a packet if its packet number is larger than the last received packet's, and skip any that were dropped or delivered out of order.
 	// If we were more ambitious we would reassemble the packets and use Opus's forward error correction to recover from lost packets... too bad!
 	packetNumber uint64
+	// lastPacket is the time the last voice packet was accepted. Unlike the other fields, it is not cleared by reset.
+	lastPacket time.Time
+	// started is the time the first packet of the current transmission was accepted.
+	started time.Time
+	// isHotMicReported is true if the current transmission has already been reported as a hot mic.
+	isHotMicReported bool
+	// activeOrigin is the GUID of the transmitter last reported as started, until it is reported as ended. Unlike the
+	// transmission state, the activity state is not cleared by reset, so that it can be debounced across transmissions.
+	activeOrigin types.GUID
+	// activeSince is the time the first packet of the current activity was accepted.
+	activeSince time.Time
+	// activeLastPacket is the time the last packet of the current activity was accepted.
+	activeLastPacket time.Time
 }
 
-func (r *receiver) receive(vp *voice.VoicePacket) {
+// ReceiverState is a snapshot of the state of the receiver for a radio.
+type ReceiverState struct {
+	// Radio is the radio the receiver is listening on.
+	Radio types.Radio
+	// IsReceiving is true if a transmission is currently being received.
+	IsReceiving bool
+	// Origin is the GUID of the client whose transmission is being received. It is empty if IsReceiving is false.
+	Origin types.GUID
+	// LastPacket is the time the most recent voice packet was received on this radio. It is the zero time if no
+	// packet has been received.
+	LastPacket time.Time
+}
+
+// state returns a snapshot of the receiver's state.
+func (r *receiver) state(radio types.Radio) ReceiverState {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	state := ReceiverState{
+		Radio:       radio,
+		IsReceiving: r.deadline.After(time.Now()),
+		LastPacket:  r.lastPacket,
+	}
+	if state.IsReceiving {
+		state.Origin = r.origin
+	}
+	return state
+}
+
+// receive buffers the voice packet if it belongs to the current transmission or starts a new one. It returns true if
+// the packet started a new transmission.
+func (r *receiver) receive(vp *voice.VoicePacket) (isNewTransmission bool) {
 	// Accept the packet if it is either:
 	// - the first packet of a new transmission
-	isNewTransmission := r.origin == "" && r.packetNumber == 0
+	isNewTransmission = r.origin == "" && r.packetNumber == 0
 	// - a newer packet from the same origin
 	isNewerPacket := vp.PacketID > r.packetNumber
 	isSameOrigin := r.origin == types.GUID(vp.OriginGUID)
 	shouldAcceptPacket := isNewTransmission || (isNewerPacket && isSameOrigin)
 	if !shouldAcceptPacket {
-		return
-	}
-
-	if isNewTransmission {
-		log.Info().Str("origin", string(vp.OriginGUID)).Msg("receiving transmission")
+		return false
 	}
 
 	r.lock.Lock()
 	defer r.lock.Unlock()
 	r.buffer = append(r.buffer, *vp)
 	r.origin = types.GUID(vp.OriginGUID)
-	r.deadline = time.Now().Add(maxRxGap)
+	r.lastPacket = time.Now()
+	if isNewTransmission {
+		r.started = r.lastPacket
+	}
+	r.deadline = r.lastPacket.Add(maxRxGap)
 	r.packetNumber = vp.PacketID
+	return isNewTransmission
 }
 
 func (r *receiver) hasTransmission() bool {
@@ -64,15 +109,70 @@ func (r *receiver) isReceivingTransmission() bool {
 	return r.deadline.After(time.Now())
 }
 
+// activeTransmission returns the deadline and origin GUID of the transmission currently being received, if any.
+func (r *receiver) activeTransmission() (deadline time.Time, origin types.GUID, ok bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.deadline, r.origin, r.deadline.After(time.Now())
+}
+
+// checkHotMic returns the origin GUID and current duration of the transmission being received if it has lasted longer
+// than the given threshold. Each transmission is only reported once.
+func (r *receiver) checkHotMic(threshold time.Duration) (origin types.GUID, duration time.Duration, ok bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.isHotMicReported || r.started.IsZero() || !r.deadline.After(time.Now()) {
+		return "", 0, false
+	}
+	duration = time.Since(r.started)
+	if duration <= threshold {
+		return "", 0, false
+	}
+	r.isHotMicReported = true
+	return r.origin, duration, true
+}
+
 func (r *receiver) reset() {
 	r.lock.Lock()
 	defer r.lock.Unlock()
+	r.started = time.Time{}
+	r.isHotMicReported = false
 	r.buffer = make([]voice.VoicePacket, 0)
 	r.origin = ""
 	r.deadline = time.Time{}
 	r.packetNumber = 0
 }
 
+// TransmissionMetadata describes a received transmission.
+type TransmissionMetadata struct {
+	// Origin is the GUID of the client which sent the transmission.
+	Origin types.GUID
+	// ReceivedPackets is the number of voice packets received.
+	ReceivedPackets int
+	// ExpectedPackets is the number of voice packets the transmitter is estimated to have sent. Each transmitter
+	// numbers its packets sequentially, so this is estimated from the first and last packet numbers received. Packets
+	// lost before the first or after the last received packet cannot be detected.
+	ExpectedPackets int
+	// PacketLossPercent is the percentage of expected packets which were not received, from 0 to 100.
+	PacketLossPercent float64
+}
+
+// newTransmissionMetadata estimates the metadata of a transmission from its received voice packets, which must be in
+// ascending packet number order.
+func newTransmissionMetadata(packets []voice.VoicePacket) TransmissionMetadata {
+	if len(packets) == 0 {
+		return TransmissionMetadata{}
+	}
+	first, last := packets[0], packets[len(packets)-1]
+	expected := max(int(last.PacketID-first.PacketID)+1, len(packets))
+	return TransmissionMetadata{
+		Origin:            types.GUID(first.OriginGUID),
+		ReceivedPackets:   len(packets),
+		ExpectedPackets:   expected,
+		PacketLossPercent: 100 * float64(expected-len(packets)) / float64(expected),
+	}
+}
+
 // maxRxGap is a duration after which the receiver will assume the end of a transmission if no packets are received.
 // TODO make this configurable.
 const maxRxGap = 300 * time.Millisecond
@@ -81,35 +181,62 @@ const maxRxGap = 300 * time.Millisecond
 // thrashing due to transmissions too short to contain any useful content.
 const minRxDuration = 1 * time.Second // 1s is whisper.cpp's minimum duration, it errors for any samples shorter than this.
 
+// transmissionDuration returns the duration of the audio in the given voice packets, measured from each packet's Opus
+// table of contents. A packet which cannot be measured is assumed to contain one frame of the SRS default length.
+func transmissionDuration(packets []voice.VoicePacket) time.Duration {
+	var duration time.Duration
+	for _, packet := range packets {
+		d, err := packetDuration(packet.AudioBytes)
+		if err != nil {
+			d = defaultFrameLength
+		}
+		duration += d
+	}
+	return duration
+}
+
 // receiveUDP listens for incoming UDP packets and routes them to the appropriate channel.
 func (c *audioClient) receiveUDP(ctx context.Context, pingCh chan<- []byte, voiceCh chan<- []byte) {
 	for {
 		if ctx.Err() != nil {
 			if ctx.Err() == context.Canceled {
-				log.Info().Msg("stopping SRS packet receiver due to context cancellation")
+				c.receiveLogger.Info().Msg("stopping SRS packet receiver due to context cancellation")
 			} else {
-				log.Error().Err(ctx.Err()).Msg("stopping packet receiver due to context error")
+				c.receiveLogger.Error().Err(ctx.Err()).Msg("stopping packet receiver due to context error")
 			}
 			return
 		}
 
 		udpPacketBuf := make([]byte, 1500)
-		n, err := c.connection.Read(udpPacketBuf)
+		n, source, err := c.read(udpPacketBuf)
+		c.throughput.received.Add(uint64(n))
+		c.packets.countRead(err)
 		udpPacket := make([]byte, n)
 		copy(udpPacket, udpPacketBuf[0:n])
 
 		switch {
 		case errors.Is(err, io.EOF):
-			log.Error().Err(err).Msg("UDP connection closed")
+			c.receiveLogger.Error().Err(err).Msg("UDP connection closed")
 		case err != nil:
-			log.Error().Err(err).Msg("UDP connection read error")
+			c.receiveLogger.Error().Err(err).Msg("UDP connection read error")
+		case !c.isFromServer(source):
+			// Stray packets are counted in Stats, so each one is only logged at debug level.
+			count := c.strayPackets.Add(1)
+			c.receiveLogger.Debug().
+				Stringer("source", source).
+				Stringer("server", c.serverAddress).
+				Int("bytes", n).
+				Uint64("strayPackets", count).
+				Msg("dropping UDP packet from unexpected address")
 		case n == 0:
-			log.Warn().Err(err).Msg("0 bytes read from UDP connection")
+			c.receiveLogger.Warn().Err(err).Msg("0 bytes read from UDP connection")
 		case n < types.GUIDLength:
-			log.Debug().Int("bytes", n).Msg("UDP packet smaller than expected")
+			c.receiveLogger.Debug().Int("bytes", n).Msg("UDP packet smaller than expected")
 		case n == types.GUIDLength:
 			// Ping packet
 			pingCh <- udpPacket
+		case n > types.GUIDLength && dropPacket(c.receiveLossRate):
+			c.receiveLogger.Trace().Msg("dropping received voice packet to simulate packet loss")
 		case n > types.GUIDLength:
 			// Voice packet
 			voiceCh <- udpPacket
@@ -117,22 +244,98 @@ func (c *audioClient) receiveUDP(ctx context.Context, pingCh chan<- []byte, voic
 	}
 }
 
-// receivePings listens for incoming UDP ping packets and logs them at DEBUG level.
-func (c *audioClient) receivePings(ctx context.Context, in <-chan []byte) {
+// read reads a single packet from the connection and returns its source address. If the connection cannot report the
+// source of each packet, as with some custom dialers, the packet is assumed to be from the server.
+func (c *audioClient) read(b []byte) (int, net.Addr, error) {
+	connection := c.conn()
+	if conn, ok := connection.(net.PacketConn); ok {
+		return conn.ReadFrom(b)
+	}
+	n, err := connection.Read(b)
+	return n, c.serverAddress, err
+}
+
+// isFromServer returns true if the given address is the SRS server's address.
+func (c *audioClient) isFromServer(source net.Addr) bool {
+	if source == nil || c.serverAddress == nil {
+		return false
+	}
+	if udpSource, ok := source.(*net.UDPAddr); ok {
+		if udpServer, ok := c.serverAddress.(*net.UDPAddr); ok {
+			return udpSource != nil && udpSource.IP.Equal(udpServer.IP) && udpSource.Port == udpServer.Port
+		}
+	}
+	return source.Network() == c.serverAddress.Network() && source.String() == c.serverAddress.String()
+}
+
+// receivePings listens for incoming UDP ping packets and logs them at DEBUG level. readyCh is closed when the first ping is received.
+func (c *audioClient) receivePings(ctx context.Context, in <-chan []byte, readyCh chan<- any) {
+	isReady := false
 	for {
 		select {
 		case b := <-in:
 			n := len(b)
 			if n < types.GUIDLength {
-				log.Debug().Int("bytes", n).Msg("received UDP ping smaller than expected")
+				c.pingLogger.Debug().Int("bytes", n).Msg("received UDP ping smaller than expected")
 			} else if n > types.GUIDLength {
-				log.Debug().Int("bytes", n).Msg("received UDP ping larger than expected")
+				c.pingLogger.Debug().Int("bytes", n).Msg("received UDP ping larger than expected")
 			} else {
-				log.Trace().Str("GUID", string(b[0:types.GUIDLength])).Msg("received UDP ping")
-				c.lastPing = time.Now()
+				c.pingLogger.Trace().Str("GUID", string(b[0:types.GUIDLength])).Msg("received UDP ping")
+				c.markPing(time.Now())
+				if !isReady {
+					close(readyCh)
+					isReady = true
+					c.pingLogger.Info().Msg("SRS audio client ready")
+				}
+			}
+		case <-ctx.Done():
+			c.pingLogger.Info().Msg("stopping SRS ping receiver due to context cancellation")
+			return
+		}
+	}
+}
+
+// checkHotMics calls the hot mic callback for each radio where a single transmitter has held the channel for longer
+// than the hot mic threshold.
+func (c *audioClient) checkHotMics() {
+	callback := callbacks.Load(&c.hotMicCallback)
+	if c.hotMicThreshold <= 0 || callback == nil {
+		return
+	}
+	for radio, receiver := range c.receiverMap() {
+		if origin, duration, ok := receiver.checkHotMic(c.hotMicThreshold); ok {
+			c.receiveLogger.Warn().
+				Func(types.LogFrequency(types.FrequencyFromHertz(radio.Frequency))).
+				Str("origin", string(origin)).
+				Stringer("duration", duration).
+				Msg("detected hot mic")
+			callback(radio, origin, duration)
+		}
+	}
+}
+
+// publishVoicePackets queues the raw voice packets of a received transmission for the voice packets callback. If the
+// callback has fallen behind and the queue is full, the packets are dropped rather than stalling the receiver.
+func (c *audioClient) publishVoicePackets(packets []voice.VoicePacket) {
+	if callback := callbacks.Load(&c.voicePacketsCallback); callback == nil {
+		return
+	}
+	select {
+	case c.voicePacketsCh <- packets:
+	default:
+		c.receiveLogger.Warn().Int("count", len(packets)).Msg("voice packets callback is falling behind, dropping packets")
+	}
+}
+
+// dispatchVoicePackets calls the voice packets callback for each transmission queued by publishVoicePackets.
+func (c *audioClient) dispatchVoicePackets(ctx context.Context) {
+	for {
+		select {
+		case packets := <-c.voicePacketsCh:
+			if callback := callbacks.Load(&c.voicePacketsCallback); callback != nil {
+				callback(packets)
 			}
 		case <-ctx.Done():
-			log.Info().Msg("stopping SRS ping receiver due to context cancellation")
 			return
 		}
 	}
@@ -141,44 +344,52 @@ func (c *audioClient) receivePings(ctx context.Context, in <-chan []byte) {
 // receiveVoice listens for incoming UDP voice packets, decodes them into VoicePacket structs, and routes them to the out channel for audio decoding.
 func (c *audioClient) receiveVoice(ctx context.Context, in <-chan []byte, out chan<- []voice.VoicePacket) {
 	// t is a ticker which triggers the check for the end of a transmission.
-	t := time.NewTicker(frameLength)
+	t := time.NewTicker(c.frameLength)
 	for {
 		select {
 		case b := <-in:
 			vp, err := decodeVoicePacket(b)
 			if err != nil {
-				log.Debug().Err(err).Msg("failed to decode voice packet")
+				c.receiveLogger.Debug().Err(err).Msg("failed to decode voice packet")
 				continue
 			}
 			if vp == nil {
-				log.Warn().Msg("nil pointer returned from decodeVoicePacket")
+				c.receiveLogger.Warn().Msg("nil pointer returned from decodeVoicePacket")
 				continue
 			}
-			for radio, receiver := range c.receivers {
+			for radio, receiver := range c.receiverMap() {
 				for _, packetFrequency := range vp.Frequencies {
 					testRadio := types.Radio{
 						Frequency:   packetFrequency.Frequency,
 						Modulation:  types.Modulation(packetFrequency.Modulation),
 						IsEncrypted: packetFrequency.Encryption != 0,
 					}
-					if testRadio.IsSameFrequency(radio) {
-						receiver.receive(vp)
+					if testRadio.IsSameFrequencyWithin(radio, c.frequencyTolerance) {
+						if receiver.receive(vp) {
+							c.receiveLogger.Info().Str("origin", string(vp.OriginGUID)).Msg("receiving transmission")
+						}
+						c.updateActivity(radio, receiver)
 					}
 				}
 			}
 
 		case <-t.C:
+			c.checkHotMics()
+			for radio, receiver := range c.receiverMap() {
+				c.updateActivity(radio, receiver)
+			}
 			// Check if everyone has stopped talking.
 			if len(in) == 0 {
-				for _, receiver := range c.receivers {
+				for _, receiver := range c.receiverMap() {
 					if receiver.hasTransmission() {
-						duration := time.Duration(len(receiver.buffer)) * frameLength
-						logger := log.With().Stringer("duration", duration).Logger()
+						audio := make([]voice.VoicePacket, len(receiver.buffer))
+						copy(audio, receiver.buffer)
+						duration := transmissionDuration(audio)
+						logger := c.receiveLogger.With().Stringer("duration", duration).Logger()
+						c.publishVoicePackets(audio)
 						if duration > minRxDuration {
 							logger.Info().Msg("received transmission")
-							audio := make([]voice.VoicePacket, len(receiver.buffer))
-							copy(audio, receiver.buffer)
-							out <- audio
+							c.queueDecode(ctx, out, audio)
 						} else {
 							logger.Info().Msg("discarding transmission below minimum size")
 						}
@@ -187,7 +398,7 @@ func (c *audioClient) receiveVoice(ctx context.Context, in <-chan []byte, out ch
 				}
 			}
 		case <-ctx.Done():
-			log.Info().Msg("stopping SRS audio receiver due to context cancellation")
+			c.receiveLogger.Info().Msg("stopping SRS audio receiver due to context cancellation")
 			return
 		}
 	}
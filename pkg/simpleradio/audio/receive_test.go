@@ -0,0 +1,200 @@
+package audio
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsFromServer(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{serverAddress: &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 5002}}
+	assert.True(t, c.isFromServer(&net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5002}))
+	assert.False(t, c.isFromServer(&net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5003}))
+	assert.False(t, c.isFromServer(&net.UDPAddr{IP: net.IPv4(192, 0, 2, 2), Port: 5002}))
+	assert.False(t, c.isFromServer(nil))
+}
+
+func TestNewTransmissionMetadata(t *testing.T) {
+	t.Parallel()
+	origin := types.NewGUID()
+	packets := func(ids ...uint64) []voice.VoicePacket {
+		vps := make([]voice.VoicePacket, 0, len(ids))
+		for _, id := range ids {
+			vps = append(vps, voice.VoicePacket{PacketID: id, OriginGUID: []byte(origin)})
+		}
+		return vps
+	}
+	testCases := []struct {
+		name     string
+		packets  []voice.VoicePacket
+		expected TransmissionMetadata
+	}{
+		{
+			name:     "empty",
+			packets:  nil,
+			expected: TransmissionMetadata{},
+		},
+		{
+			name:     "single packet",
+			packets:  packets(7),
+			expected: TransmissionMetadata{Origin: origin, ReceivedPackets: 1, ExpectedPackets: 1},
+		},
+		{
+			name:     "no loss",
+			packets:  packets(10, 11, 12, 13),
+			expected: TransmissionMetadata{Origin: origin, ReceivedPackets: 4, ExpectedPackets: 4},
+		},
+		{
+			name:     "some lost",
+			packets:  packets(1, 3, 5, 7, 8),
+			expected: TransmissionMetadata{Origin: origin, ReceivedPackets: 5, ExpectedPackets: 8, PacketLossPercent: 37.5},
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, newTransmissionMetadata(test.packets))
+		})
+	}
+}
+
+func TestTransmissionDuration(t *testing.T) {
+	t.Parallel()
+	packets := func(toc byte, count int) []voice.VoicePacket {
+		vps := make([]voice.VoicePacket, 0, count)
+		for i := range count {
+			vps = append(vps, voice.VoicePacket{PacketID: uint64(i + 1), AudioBytes: []byte{toc, 0xFF}})
+		}
+		return vps
+	}
+	// The duration is measured from the packets, not this client's frame length, so a transmission from a sender using
+	// the SRS default 40ms frames is measured correctly whatever this client is configured with.
+	assert.Equal(t, 600*time.Millisecond, transmissionDuration(packets(10<<3, 15)))
+	assert.Equal(t, 600*time.Millisecond, transmissionDuration(packets(8<<3, 60)))
+	// Packets which cannot be measured are assumed to be the SRS default frame length.
+	assert.Equal(t, 2*defaultFrameLength, transmissionDuration([]voice.VoicePacket{{}, {AudioBytes: []byte{8<<3 | 3}}}))
+}
+
+func TestReceiverStates(t *testing.T) {
+	t.Parallel()
+	uhf := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	vhf := types.Radio{Frequency: 133000000, Modulation: types.ModulationAM}
+	c := &audioClient{
+		radios:    []types.Radio{uhf, vhf},
+		receivers: map[types.Radio]*receiver{uhf: {}, vhf: {}},
+	}
+
+	states := c.ReceiverStates()
+	require.Len(t, states, 2)
+	for _, state := range states {
+		assert.False(t, state.IsReceiving)
+		assert.Empty(t, state.Origin)
+		assert.True(t, state.LastPacket.IsZero())
+	}
+
+	origin := types.NewGUID()
+	before := time.Now()
+	c.receivers[vhf].receive(&voice.VoicePacket{PacketID: 1, OriginGUID: []byte(origin)})
+	states = c.ReceiverStates()
+	require.Len(t, states, 2)
+	assert.Equal(t, uhf, states[0].Radio)
+	assert.False(t, states[0].IsReceiving)
+	assert.Equal(t, vhf, states[1].Radio)
+	assert.True(t, states[1].IsReceiving)
+	assert.Equal(t, origin, states[1].Origin)
+	assert.False(t, states[1].LastPacket.Before(before))
+
+	c.receivers[vhf].reset()
+	states = c.ReceiverStates()
+	assert.False(t, states[1].IsReceiving)
+	assert.Empty(t, states[1].Origin)
+	assert.False(t, states[1].LastPacket.IsZero(), "last packet time should survive the end of a transmission")
+}
+
+func TestPublishVoicePackets(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{voicePacketsCh: make(chan []voice.VoicePacket, 1)}
+	packets := []voice.VoicePacket{{PacketID: 1}, {PacketID: 2}}
+
+	// Without a callback, nothing is queued.
+	c.publishVoicePackets(packets)
+	assert.Empty(t, c.voicePacketsCh)
+
+	received := make(chan []voice.VoicePacket)
+	unblock := make(chan struct{})
+	c.SetVoicePacketsCallback(func(vps []voice.VoicePacket) {
+		received <- vps
+		<-unblock
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.dispatchVoicePackets(ctx)
+
+	c.publishVoicePackets(packets)
+	assert.Equal(t, packets, <-received)
+
+	// The callback is now blocked. The next transmission fills the queue, and any further transmissions are dropped
+	// without blocking the caller.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 5 {
+			c.publishVoicePackets(packets)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "publishing blocked on a slow callback")
+	}
+	close(unblock)
+	assert.Equal(t, packets, <-received)
+}
+
+func TestCheckHotMics(t *testing.T) {
+	t.Parallel()
+	radio := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	c := &audioClient{
+		radios:          []types.Radio{radio},
+		receivers:       map[types.Radio]*receiver{radio: {}},
+		hotMicThreshold: 100 * time.Millisecond,
+	}
+	type report struct {
+		radio    types.Radio
+		origin   types.GUID
+		duration time.Duration
+	}
+	var reports []report
+	c.SetHotMicCallback(func(radio types.Radio, origin types.GUID, duration time.Duration) {
+		reports = append(reports, report{radio, origin, duration})
+	})
+
+	origin := types.NewGUID()
+	rx := c.receivers[radio]
+	rx.receive(&voice.VoicePacket{PacketID: 1, OriginGUID: []byte(origin)})
+	c.checkHotMics()
+	assert.Empty(t, reports, "transmission shorter than threshold should not be reported")
+
+	// Keep the transmission alive past the threshold.
+	for id := uint64(2); id < 10; id++ {
+		time.Sleep(20 * time.Millisecond)
+		rx.receive(&voice.VoicePacket{PacketID: id, OriginGUID: []byte(origin)})
+	}
+	c.checkHotMics()
+	c.checkHotMics()
+	require.Len(t, reports, 1, "hot mic should be reported once per transmission")
+	assert.Equal(t, radio, reports[0].radio)
+	assert.Equal(t, origin, reports[0].origin)
+	assert.Greater(t, reports[0].duration, c.hotMicThreshold)
+
+	rx.reset()
+	c.checkHotMics()
+	assert.Len(t, reports, 1)
+}
@@ -0,0 +1,98 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/rs/zerolog/log"
+)
+
+// dialUDP opens a UDP connection to the SRS server and sets its receive buffer size, unless the size is zero.
+func dialUDP(ctx context.Context, openTransport types.AudioTransportFactory, network, address string, readBufferSize int) (types.AudioTransport, error) {
+	connection, err := openTransport(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SRS server %v over UDP: %w", address, err)
+	}
+	log.Info().Stringer("local", connection.LocalAddr()).Msg("connected to SRS server over UDP")
+	if readBufferSize > 0 {
+		effective, err := setReadBuffer(connection, readBufferSize)
+		if err != nil {
+			_ = connection.Close()
+			return nil, fmt.Errorf("failed to set UDP read buffer size to %d: %w", readBufferSize, err)
+		}
+		log.Info().Int("requested", readBufferSize).Int("effective", effective).Msg("set UDP read buffer size")
+	}
+	return connection, nil
+}
+
+// conn returns the current UDP connection to the SRS server.
+func (c *audioClient) conn() types.AudioTransport {
+	c.connectionLock.RLock()
+	defer c.connectionLock.RUnlock()
+	return c.connection
+}
+
+// Reconnect implements [AudioClient.Reconnect].
+func (c *audioClient) Reconnect(ctx context.Context) error {
+	connection, err := dialUDP(ctx, c.openTransport, c.network, c.address, c.udpReadBufferSize)
+	if err != nil {
+		return fmt.Errorf("failed to reconnect: %w", err)
+	}
+
+	c.connectionLock.Lock()
+	if c.isConnectionClosed {
+		c.connectionLock.Unlock()
+		_ = connection.Close()
+		return errors.New("failed to reconnect: client is closed")
+	}
+	previous := c.connection
+	c.connection = connection
+	c.connectionLock.Unlock()
+
+	// Closing the previous connection interrupts a read which is blocked on it, so that the receiver reads from the new
+	// connection.
+	if err := previous.Close(); err != nil {
+		log.Warn().Err(err).Msg("error closing previous UDP connection to SRS")
+	}
+	if c.preserveReceivers {
+		c.receiveLogger.Info().Msg("preserving receiver state across reconnection")
+	} else {
+		c.resetReceivers()
+	}
+	// The server only relays audio to the new connection once it has been pinged from it.
+	c.SendPing()
+	return nil
+}
+
+// SetGUID implements [AudioClient.SetGUID].
+func (c *audioClient) SetGUID(guid types.GUID) {
+	c.guidLock.Lock()
+	defer c.guidLock.Unlock()
+	c.guid = guid
+}
+
+// currentGUID returns the GUID the client identifies itself with.
+func (c *audioClient) currentGUID() types.GUID {
+	c.guidLock.RLock()
+	defer c.guidLock.RUnlock()
+	return c.guid
+}
+
+// resetReceivers discards the transmissions being received and waiting to be decoded.
+func (c *audioClient) resetReceivers() {
+	for _, receiver := range c.receiverMap() {
+		receiver.reset()
+	}
+	discarded := 0
+	for {
+		select {
+		case <-c.decodeCh:
+			discarded++
+		default:
+			c.receiveLogger.Info().Int("discardedTransmissions", discarded).Msg("reset receiver state after reconnection")
+			return
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package audio
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeDialer connects over in-memory pipes, and publishes whatever is written to each connection to a channel.
+type pipeDialer struct {
+	t       *testing.T
+	written chan []byte
+}
+
+func (d *pipeDialer) DialContext(_ context.Context, _, _ string) (net.Conn, error) {
+	clientConnection, serverConnection := net.Pipe()
+	d.t.Cleanup(func() {
+		_ = clientConnection.Close()
+		_ = serverConnection.Close()
+	})
+	go func() {
+		for {
+			b := make([]byte, maxPacketLength)
+			n, err := serverConnection.Read(b)
+			if err != nil {
+				return
+			}
+			d.written <- b[:n]
+		}
+	}()
+	return clientConnection, nil
+}
+
+// newReconnectTestClient returns a client on a single radio which reconnects over in-memory pipes, and a channel which
+// receives whatever the client writes after reconnecting.
+func newReconnectTestClient(t *testing.T) (*audioClient, *receiver, <-chan []byte) {
+	t.Helper()
+	client, _ := newStreamingTestClient(t)
+	dialer := &pipeDialer{t: t, written: make(chan []byte, 1)}
+	client.openTransport = func(ctx context.Context, network, address string) (types.AudioTransport, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	client.network = "udp"
+	client.decodeCh = make(chan []voice.VoicePacket, 1)
+	r := &receiver{}
+	client.receivers = map[types.Radio]*receiver{client.radios[0]: r}
+	return client, r, dialer.written
+}
+
+func TestReconnect(t *testing.T) {
+	t.Parallel()
+	client, r, written := newReconnectTestClient(t)
+	previous := client.conn()
+	origin := types.NewGUID()
+
+	// A transmission is partially received, and another is waiting to be decoded.
+	r.receive(&voice.VoicePacket{PacketID: 100, OriginGUID: []byte(origin)})
+	r.receive(&voice.VoicePacket{PacketID: 101, OriginGUID: []byte(origin)})
+	client.decodeCh <- []voice.VoicePacket{{PacketID: 50, OriginGUID: []byte(origin)}}
+
+	require.NoError(t, client.Reconnect(context.Background()))
+	assert.NotSame(t, previous, client.conn())
+	assert.Equal(t, []byte(client.guid), <-written, "the server should be pinged from the new connection")
+	assert.Empty(t, client.decodeCh)
+	assert.False(t, r.isReceivingTransmission())
+
+	// The sender's packet numbering restarts after the reconnection. Its packets start a new transmission instead of
+	// being rejected or appended to the transmission from before the reconnection.
+	assert.True(t, r.receive(&voice.VoicePacket{PacketID: 1, OriginGUID: []byte(origin)}))
+	r.receive(&voice.VoicePacket{PacketID: 2, OriginGUID: []byte(origin)})
+	require.Len(t, r.buffer, 2)
+	assert.Equal(t, uint64(1), r.buffer[0].PacketID)
+	assert.Equal(t, uint64(2), r.buffer[1].PacketID)
+
+	require.NoError(t, client.close())
+	require.Error(t, client.Reconnect(context.Background()))
+}
+
+func TestReconnectPreservesReceivers(t *testing.T) {
+	t.Parallel()
+	client, r, written := newReconnectTestClient(t)
+	client.preserveReceivers = true
+	origin := types.NewGUID()
+
+	r.receive(&voice.VoicePacket{PacketID: 100, OriginGUID: []byte(origin)})
+	client.decodeCh <- []voice.VoicePacket{{PacketID: 50, OriginGUID: []byte(origin)}}
+
+	require.NoError(t, client.Reconnect(context.Background()))
+	<-written
+	assert.Len(t, client.decodeCh, 1)
+	assert.Len(t, r.buffer, 1)
+}
+
+func TestSetGUID(t *testing.T) {
+	t.Parallel()
+	client, _, written := newReconnectTestClient(t)
+	guid := types.NewGUID()
+	client.SetGUID(guid)
+	require.NoError(t, client.Reconnect(context.Background()))
+	assert.Equal(t, []byte(guid), <-written, "the server should be pinged with the new GUID")
+}
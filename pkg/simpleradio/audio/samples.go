@@ -0,0 +1,53 @@
+package audio
+
+import (
+	"slices"
+	"time"
+)
+
+// Duration returns the duration of the audio when played at the given sample rate in Hz. It returns zero if the
+// sample rate is not positive.
+func (a Audio) Duration(sampleRate int) time.Duration {
+	if sampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(len(a)) * time.Second / time.Duration(sampleRate)
+}
+
+// Append returns new audio of this audio followed by the other audio. Neither is modified.
+func (a Audio) Append(other Audio) Audio {
+	return slices.Concat(a, other)
+}
+
+// Silence returns silent audio of the given duration at the given sample rate in Hz, rounded down to a whole sample.
+// It returns empty audio if the duration or sample rate is not positive.
+func Silence(d time.Duration, sampleRate int) Audio {
+	return make(Audio, samplesIn(d, sampleRate))
+}
+
+// samplesIn returns the number of samples in the given duration at the given sample rate in Hz, rounded down to a
+// whole sample. It returns zero if the duration or sample rate is not positive.
+func samplesIn(d time.Duration, sampleRate int) int {
+	if d <= 0 || sampleRate <= 0 {
+		return 0
+	}
+	return int(d * time.Duration(sampleRate) / time.Second)
+}
+
+// Fade returns a copy of the audio with a linear fade-in of the given duration applied to its start and a linear
+// fade-out of the given duration applied to its end, at the given sample rate in Hz. This avoids the clicks caused by
+// audio which starts or stops abruptly. Each fade is shortened to at most half of the audio, so that the fades never
+// overlap. The receiver is not modified.
+func (a Audio) Fade(in, out time.Duration, sampleRate int) Audio {
+	faded := slices.Clone(a)
+	half := len(faded) / 2
+	inSamples := min(samplesIn(in, sampleRate), half)
+	for i := range inSamples {
+		faded[i] *= float32(i) / float32(inSamples)
+	}
+	outSamples := min(samplesIn(out, sampleRate), half)
+	for i := range outSamples {
+		faded[len(faded)-1-i] *= float32(i) / float32(outSamples)
+	}
+	return faded
+}
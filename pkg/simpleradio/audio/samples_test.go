@@ -0,0 +1,112 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudioDuration(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		samples    int
+		sampleRate int
+		expected   time.Duration
+	}{
+		{samples: 0, sampleRate: sampleRate, expected: 0},
+		{samples: 16000, sampleRate: sampleRate, expected: time.Second},
+		{samples: 640, sampleRate: sampleRate, expected: 40 * time.Millisecond},
+		{samples: 48000, sampleRate: 48000, expected: time.Second},
+		{samples: 1, sampleRate: 3, expected: time.Second / 3},
+		{samples: 16000, sampleRate: 0, expected: 0},
+		{samples: 16000, sampleRate: -1, expected: 0},
+	}
+	for _, test := range testCases {
+		t.Run(test.expected.String(), func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, make(Audio, test.samples).Duration(test.sampleRate))
+		})
+	}
+}
+
+func TestAudioAppend(t *testing.T) {
+	t.Parallel()
+	a := make(Audio, 2, 8)
+	a[0], a[1] = 0.1, 0.2
+	b := Audio{0.3}
+	c := a.Append(b)
+	assert.Equal(t, Audio{0.1, 0.2, 0.3}, c)
+
+	// Appending must not write into the spare capacity of the receiver.
+	c[2] = 1
+	assert.Equal(t, Audio{0.1, 0.2, 0}, a[:3])
+	assert.Equal(t, Audio{0.3}, b)
+
+	assert.Empty(t, Audio(nil).Append(nil))
+}
+
+func TestSilence(t *testing.T) {
+	t.Parallel()
+	silence := Silence(40*time.Millisecond, sampleRate)
+	require.Len(t, silence, 640)
+	for _, s := range silence {
+		assert.Zero(t, s)
+	}
+	assert.Equal(t, 40*time.Millisecond, silence.Duration(sampleRate))
+	assert.Empty(t, Silence(0, sampleRate))
+	assert.Empty(t, Silence(-time.Second, sampleRate))
+	assert.Empty(t, Silence(time.Second, 0))
+}
+
+func TestAudioFade(t *testing.T) {
+	t.Parallel()
+	constant := func(n int) Audio {
+		a := make(Audio, n)
+		for i := range a {
+			a[i] = 1
+		}
+		return a
+	}
+	testCases := []struct {
+		name      string
+		samples   int
+		in        time.Duration
+		out       time.Duration
+		inLength  int
+		outLength int
+	}{
+		{name: "both", samples: 1600, in: 5 * time.Millisecond, out: 5 * time.Millisecond, inLength: 80, outLength: 80},
+		{name: "in only", samples: 1600, in: 10 * time.Millisecond, inLength: 160},
+		{name: "out only", samples: 1600, out: 10 * time.Millisecond, outLength: 160},
+		{name: "none", samples: 1600},
+		{name: "clamped to half", samples: 100, in: time.Second, out: time.Second, inLength: 50, outLength: 50},
+		{name: "empty", samples: 0, in: time.Second, out: time.Second},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			original := constant(test.samples)
+			faded := original.Fade(test.in, test.out, sampleRate)
+			require.Len(t, faded, test.samples)
+			assert.Equal(t, constant(test.samples), original, "receiver should not be modified")
+
+			for i := range test.inLength {
+				assert.InDelta(t, float32(i)/float32(test.inLength), faded[i], 1e-6, "fade-in sample %d", i)
+			}
+			for i := range test.outLength {
+				assert.InDelta(t, float32(i)/float32(test.outLength), faded[len(faded)-1-i], 1e-6, "fade-out sample %d", i)
+			}
+			for i := test.inLength; i < test.samples-test.outLength; i++ {
+				require.InDelta(t, 1, faded[i], 1e-6, "sample %d outside the fades should be unchanged", i)
+			}
+			if test.inLength > 0 {
+				assert.Zero(t, faded[0], "audio should start silent")
+			}
+			if test.outLength > 0 {
+				assert.Zero(t, faded[len(faded)-1], "audio should end silent")
+			}
+		})
+	}
+}
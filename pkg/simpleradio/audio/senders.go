@@ -0,0 +1,112 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+)
+
+// senderStatsTTL is how long the statistics of a sender are kept after its last decoded transmission. This bounds the
+// memory used on busy servers where many players come and go.
+const senderStatsTTL = 30 * time.Minute
+
+// SenderStats is a snapshot of the decode statistics of the transmissions received from a single sender. It helps
+// diagnose whether bad audio is caused by this client or by the sender's microphone or connection.
+type SenderStats struct {
+	// Transmissions is the number of the sender's transmissions which were decoded.
+	Transmissions uint64
+	// PacketsReceived is the number of voice packets received in the sender's decoded transmissions.
+	PacketsReceived uint64
+	// DecodeErrors is the number of the sender's frames which could not be decoded.
+	DecodeErrors uint64
+	// PacketLossPercent is the percentage of the sender's packets estimated to have been lost, from 0 to 100. It is
+	// estimated in the same way as [TransmissionMetadata.PacketLossPercent], over all of the sender's transmissions.
+	PacketLossPercent float64
+	// AverageLevel is the RMS level of the sender's decoded audio, from 0 to 1. A level near zero suggests a quiet or
+	// broken microphone, and a level near one suggests clipping.
+	AverageLevel float64
+	// LastHeard is the time the sender's most recent transmission was decoded. It is the zero time if no statistics are
+	// kept for the sender.
+	LastHeard time.Time
+}
+
+// senderStats accumulates the decode statistics of a single sender.
+type senderStats struct {
+	transmissions   uint64
+	packetsReceived uint64
+	packetsExpected uint64
+	decodeErrors    uint64
+	sumOfSquares    float64
+	samples         uint64
+	lastHeard       time.Time
+}
+
+// snapshot returns the sender's statistics.
+func (s *senderStats) snapshot() SenderStats {
+	stats := SenderStats{
+		Transmissions:   s.transmissions,
+		PacketsReceived: s.packetsReceived,
+		DecodeErrors:    s.decodeErrors,
+		LastHeard:       s.lastHeard,
+	}
+	if s.packetsExpected > 0 {
+		stats.PacketLossPercent = 100 * float64(s.packetsExpected-s.packetsReceived) / float64(s.packetsExpected)
+	}
+	if s.samples > 0 {
+		stats.AverageLevel = math.Sqrt(s.sumOfSquares / float64(s.samples))
+	}
+	return stats
+}
+
+// SenderStats implements [AudioClient.SenderStats].
+func (c *audioClient) SenderStats(origin types.GUID) SenderStats {
+	c.sendersLock.Lock()
+	defer c.sendersLock.Unlock()
+	c.evictSenders(time.Now())
+	if stats, ok := c.senders[origin]; ok {
+		return stats.snapshot()
+	}
+	return SenderStats{}
+}
+
+// recordSenderStats adds a decoded transmission to its sender's statistics, and evicts the statistics of senders which
+// have not been heard within the TTL.
+func (c *audioClient) recordSenderStats(voicePackets []voice.VoicePacket, txPCM []float32, decodeErrors int) {
+	metadata := newTransmissionMetadata(voicePackets)
+	if metadata.Origin == "" {
+		return
+	}
+	now := time.Now()
+	c.sendersLock.Lock()
+	defer c.sendersLock.Unlock()
+	c.evictSenders(now)
+	if c.senders == nil {
+		c.senders = make(map[types.GUID]*senderStats)
+	}
+	stats, ok := c.senders[metadata.Origin]
+	if !ok {
+		stats = &senderStats{}
+		c.senders[metadata.Origin] = stats
+	}
+	stats.transmissions++
+	stats.packetsReceived += uint64(metadata.ReceivedPackets)
+	stats.packetsExpected += uint64(metadata.ExpectedPackets)
+	stats.decodeErrors += uint64(decodeErrors)
+	for _, sample := range txPCM {
+		stats.sumOfSquares += float64(sample) * float64(sample)
+	}
+	stats.samples += uint64(len(txPCM))
+	stats.lastHeard = now
+}
+
+// evictSenders forgets the statistics of senders which have not been heard within the TTL. The caller must hold
+// sendersLock.
+func (c *audioClient) evictSenders(now time.Time) {
+	for origin, stats := range c.senders {
+		if now.Sub(stats.lastHeard) > senderStatsTTL {
+			delete(c.senders, origin)
+		}
+	}
+}
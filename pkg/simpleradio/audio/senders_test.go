@@ -0,0 +1,51 @@
+package audio
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/hraban/opus.v2"
+)
+
+func TestSenderStats(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{frameLength: defaultFrameLength, frameSize: frameSizeOf(defaultFrameLength), decoderResetThreshold: defaultDecoderResetThreshold}
+	origin := types.NewGUID()
+	packets := newTestTransmission(t, c, 10)
+	for i := range packets {
+		packets[i].OriginGUID = []byte(origin)
+	}
+	assert.Zero(t, c.SenderStats(origin))
+
+	decoder, err := opus.NewDecoder(sampleRate, channels)
+	require.NoError(t, err)
+	_ = c.decodeTransmission(decoder, packets)
+	// Two of the second transmission's packets are lost.
+	lossy := slices.Concat(packets[:4], packets[6:])
+	decoder, err = opus.NewDecoder(sampleRate, channels)
+	require.NoError(t, err)
+	_ = c.decodeTransmission(decoder, lossy)
+
+	stats := c.SenderStats(origin)
+	assert.Equal(t, uint64(2), stats.Transmissions)
+	assert.Equal(t, uint64(18), stats.PacketsReceived)
+	assert.Zero(t, stats.DecodeErrors)
+	assert.InDelta(t, 10, stats.PacketLossPercent, 0.001)
+	assert.InDelta(t, 0.5/math.Sqrt2, stats.AverageLevel, 0.05, "a sine wave's RMS level is its amplitude divided by the square root of two")
+	assert.WithinDuration(t, time.Now(), stats.LastHeard, time.Minute)
+	assert.Zero(t, c.SenderStats(types.NewGUID()))
+
+	// A nil decoder fails to decode every frame.
+	_ = c.decodeTransmission(nil, packets[:3])
+	assert.Equal(t, uint64(3), c.SenderStats(origin).DecodeErrors)
+
+	// Senders not heard within the TTL are forgotten.
+	c.senders[origin].lastHeard = time.Now().Add(-senderStatsTTL - time.Second)
+	assert.Zero(t, c.SenderStats(origin))
+	assert.Empty(t, c.senders)
+}
@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrShuttingDown is returned when a transmission is queued after Shutdown is called.
+var ErrShuttingDown = errors.New("client is shutting down")
+
+// drainInterval is how often Shutdown checks whether the transmission queue has drained.
+const drainInterval = 50 * time.Millisecond
+
+// enqueue queues a transmission, unless the client is shutting down.
+func (c *audioClient) enqueue(queued transmission) error {
+	if !c.beginTransmission() {
+		return ErrShuttingDown
+	}
+	if c.isNextContinued.Swap(false) {
+		queued.continues = true
+	}
+	c.txChan <- queued
+	return nil
+}
+
+// beginTransmission counts a transmission as pending until finishTransmission is called. It returns false without
+// counting the transmission if the client is shutting down.
+func (c *audioClient) beginTransmission() bool {
+	c.shutdownLock.RLock()
+	defer c.shutdownLock.RUnlock()
+	if c.isShuttingDown {
+		return false
+	}
+	c.pendingTransmissions.Add(1)
+	return true
+}
+
+// finishTransmission marks a transmission as no longer pending, once it has been sent or discarded.
+func (c *audioClient) finishTransmission(id string) {
+	c.releaseTransmissionID(id)
+	c.pendingTransmissions.Add(-1)
+}
+
+// Shutdown implements [AudioClient.Shutdown].
+func (c *audioClient) Shutdown(ctx context.Context) error {
+	c.shutdownLock.Lock()
+	c.isShuttingDown = true
+	c.shutdownLock.Unlock()
+
+	// Queued transmissions are sent even if the startup grace period has not ended.
+	c.ReleaseTransmissions()
+	err := c.drain(ctx)
+	return errors.Join(err, c.Close())
+}
+
+// drain blocks until every pending transmission has been sent or discarded, or until the context is done.
+func (c *audioClient) drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+	for {
+		pending := c.pendingTransmissions.Load()
+		if pending == 0 {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("%d transmissions were not sent: %w", pending, ctx.Err())
+		}
+	}
+}
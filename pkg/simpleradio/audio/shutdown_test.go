@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	client.txChan = make(chan transmission)
+	client.closeCh = make(chan struct{})
+	client.releasedCh = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	packetCh := make(chan transmission)
+	go client.encodeVoice(ctx, packetCh)
+	go client.transmit(ctx, packetCh)
+
+	client.Transmit(make(Audio, 3*client.frameSize))
+	shutdownCtx, cancelShutdown := context.WithTimeout(ctx, 10*time.Second)
+	defer cancelShutdown()
+	require.NoError(t, client.Shutdown(shutdownCtx))
+	assert.Len(t, packets, 3)
+
+	// New transmissions are rejected once shutdown has begun.
+	require.ErrorIs(t, client.Speak(make([]float32, client.frameSize), sampleRate, false), ErrShuttingDown)
+	require.ErrorIs(t, client.TransmitReader(ctx, nil, sampleRate), ErrShuttingDown)
+	client.Transmit(make(Audio, client.frameSize))
+	assert.Zero(t, client.pendingTransmissions.Load())
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	t.Parallel()
+	client, _ := newStreamingTestClient(t)
+	client.closeCh = make(chan struct{})
+	client.releasedCh = make(chan struct{})
+	require.True(t, client.beginTransmission())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, client.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Equal(t, int64(1), client.pendingTransmissions.Load())
+}
@@ -0,0 +1,42 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+)
+
+// errReadBufferUnsupported is returned when the connection does not support setting its read buffer size. This is
+// usually because a custom dialer or transport returned a connection which is not a UDP socket.
+var errReadBufferUnsupported = errors.New("connection does not support setting the read buffer size")
+
+// setReadBuffer sets the size of the operating system's receive buffer for the connection, and returns the effective
+// size. The operating system may clamp the requested size to a system limit, or adjust it for bookkeeping overhead;
+// for example, Linux doubles the requested size. The effective size is zero if it cannot be read back.
+func setReadBuffer(connection types.AudioTransport, size int) (int, error) {
+	conn, ok := connection.(interface{ SetReadBuffer(int) error })
+	if !ok {
+		return 0, errReadBufferUnsupported
+	}
+	if err := conn.SetReadBuffer(size); err != nil {
+		return 0, fmt.Errorf("failed to set read buffer size: %w", err)
+	}
+	sysConn, ok := connection.(syscall.Conn)
+	if !ok {
+		return 0, nil
+	}
+	rawConn, err := sysConn.SyscallConn()
+	if err != nil {
+		return 0, nil
+	}
+	var effective int
+	var sockoptErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		effective, sockoptErr = getReadBuffer(fd)
+	}); err != nil || sockoptErr != nil {
+		return 0, nil
+	}
+	return effective, nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package audio
+
+import "errors"
+
+// getReadBuffer returns an error, because reading the receive buffer size is not supported on this platform.
+func getReadBuffer(uintptr) (int, error) {
+	return 0, errors.New("reading the receive buffer size is not supported on this platform")
+}
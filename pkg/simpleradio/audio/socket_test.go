@@ -0,0 +1,31 @@
+package audio
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetReadBuffer(t *testing.T) {
+	t.Parallel()
+	connection, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = connection.Close() })
+
+	effective, err := setReadBuffer(connection, 64*1024)
+	require.NoError(t, err)
+	assert.Positive(t, effective)
+}
+
+func TestSetReadBufferUnsupported(t *testing.T) {
+	t.Parallel()
+	connection, other := net.Pipe()
+	t.Cleanup(func() {
+		_ = connection.Close()
+		_ = other.Close()
+	})
+	_, err := setReadBuffer(connection, 64*1024)
+	require.ErrorIs(t, err, errReadBufferUnsupported)
+}
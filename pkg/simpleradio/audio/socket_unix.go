@@ -0,0 +1,10 @@
+//go:build unix
+
+package audio
+
+import "syscall"
+
+// getReadBuffer returns the size of the receive buffer of the given socket.
+func getReadBuffer(fd uintptr) (int, error) {
+	return syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF)
+}
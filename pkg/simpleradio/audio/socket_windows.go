@@ -0,0 +1,16 @@
+//go:build windows
+
+package audio
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// getReadBuffer returns the size of the receive buffer of the given socket.
+func getReadBuffer(fd uintptr) (int, error) {
+	var size int32
+	length := int32(unsafe.Sizeof(size))
+	err := syscall.Getsockopt(syscall.Handle(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF, (*byte)(unsafe.Pointer(&size)), &length)
+	return int(size), err
+}
@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ClientStats is a point-in-time snapshot of the audio client's counters, for operators who poll statistics into their
+// own monitoring system.
+type ClientStats struct {
+	// PacketsSent is the number of UDP packets sent since the client started, including pings.
+	PacketsSent uint64
+	// PacketsReceived is the number of UDP packets received since the client started, including stray packets.
+	PacketsReceived uint64
+	// StrayPackets is the number of packets received from addresses other than the SRS server.
+	StrayPackets uint64
+	// Errors is the number of failed UDP reads and writes.
+	Errors uint64
+	// DecodeErrors is the number of received frames which could not be decoded, including frames skipped because the
+	// Opus codec panicked.
+	DecodeErrors uint64
+	// CodecPanics is the number of frames which were skipped because the Go bindings for the Opus codec panicked.
+	CodecPanics uint64
+	// LastPing is the time the SRS server last echoed a ping.
+	LastPing time.Time
+	// QueueDepth is the number of transmissions which have been queued or are being streamed, and have not yet been
+	// sent or discarded.
+	QueueDepth int
+	// DecodeBacklog is the number of received transmissions waiting to be decoded.
+	DecodeBacklog int
+	// DecodeDrops is the number of received transmissions dropped because the decode queue was full.
+	DecodeDrops uint64
+	// CaptureDrops is the number of captured transmissions dropped because the capture consumer fell behind.
+	CaptureDrops uint64
+	// Throughput is the client's network throughput.
+	Throughput Throughput
+}
+
+// packetCounters counts the packets sent and received by the client, and the failed reads and writes.
+type packetCounters struct {
+	sent     atomic.Uint64
+	received atomic.Uint64
+	errors   atomic.Uint64
+}
+
+// countWrite counts the outcome of a single write.
+func (p *packetCounters) countWrite(err error) {
+	if err != nil {
+		p.errors.Add(1)
+	} else {
+		p.sent.Add(1)
+	}
+}
+
+// countRead counts the outcome of a single read.
+func (p *packetCounters) countRead(err error) {
+	if err != nil {
+		p.errors.Add(1)
+	} else {
+		p.received.Add(1)
+	}
+}
+
+// Stats implements [AudioClient.Stats].
+func (c *audioClient) Stats() ClientStats {
+	return ClientStats{
+		PacketsSent:     c.packets.sent.Load(),
+		PacketsReceived: c.packets.received.Load(),
+		StrayPackets:    c.strayPackets.Load(),
+		Errors:          c.packets.errors.Load(),
+		DecodeErrors:    c.decodeErrors.Load(),
+		CodecPanics:     c.codecPanics.Load(),
+		LastPing:        c.LastPing(),
+		QueueDepth:      int(c.pendingTransmissions.Load()),
+		DecodeBacklog:   len(c.decodeCh),
+		DecodeDrops:     c.decodeDrops.Load(),
+		CaptureDrops:    c.captureDrops.Load(),
+		Throughput:      c.Throughput(),
+	}
+}
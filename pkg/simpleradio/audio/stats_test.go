@@ -0,0 +1,100 @@
+package audio
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	clientConnection, serverConnection := net.Pipe()
+	t.Cleanup(func() { _ = serverConnection.Close() })
+	go func() { _, _ = io.Copy(io.Discard, serverConnection) }()
+	client := &audioClient{
+		guid:       types.NewGUID(),
+		connection: clientConnection,
+	}
+
+	for range 2 {
+		_, err := client.write([]byte(client.guid))
+		require.NoError(t, err)
+	}
+	client.packets.countRead(nil)
+	client.strayPackets.Add(1)
+	require.NoError(t, clientConnection.Close())
+	_, err := client.write([]byte(client.guid))
+	require.Error(t, err)
+
+	stats := client.Stats()
+	assert.Equal(t, uint64(2), stats.PacketsSent)
+	assert.Equal(t, uint64(1), stats.PacketsReceived)
+	assert.Equal(t, uint64(1), stats.StrayPackets)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Equal(t, uint64(2*len(client.guid)), stats.Throughput.BytesSent)
+}
+
+func TestStatsQueueDepth(t *testing.T) {
+	t.Parallel()
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer server.Close()
+	go func() { _, _ = io.Copy(io.Discard, server) }()
+
+	// The startup grace period holds queued transmissions until it is released.
+	c, err := NewClient(types.NewGUID(), types.ClientConfiguration{
+		Address:      server.LocalAddr().String(),
+		Radios:       []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}},
+		StartupGrace: time.Minute,
+	})
+	require.NoError(t, err)
+	client := c.(*audioClient)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = client.Run(ctx, &wg, make(chan any))
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for range 3 {
+		go client.Transmit(make(Audio, client.frameSize))
+	}
+	require.Eventually(t, func() bool { return client.Stats().QueueDepth == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	client.ReleaseTransmissions()
+	require.Eventually(t, func() bool { return client.Stats().QueueDepth == 0 }, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestStatsWhileReceivingPings(t *testing.T) {
+	t.Parallel()
+	client := &audioClient{pingLogger: zerolog.Nop()}
+	assert.True(t, client.Stats().LastPing.IsZero())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan []byte)
+	readyCh := make(chan any)
+	go client.receivePings(ctx, in, readyCh)
+	ping := []byte(types.NewGUID())
+	for range 10 {
+		in <- ping
+		_ = client.Stats()
+	}
+	<-readyCh
+	assert.False(t, client.Stats().LastPing.IsZero())
+	assert.Equal(t, client.LastPing(), client.Stats().LastPing)
+}
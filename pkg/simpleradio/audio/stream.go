@@ -0,0 +1,125 @@
+package audio
+
+import (
+	"io"
+
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/martinlindhe/unit"
+)
+
+// streamBufferSize is the number of received transmissions which may be buffered for each receive stream.
+const streamBufferSize = 16
+
+// receiveStream is an [io.ReadCloser] of the audio received on a single frequency, as S16LE PCM. Decoded
+// transmissions are queued on a channel, and a pump goroutine writes them into a pipe which the consumer reads from.
+type receiveStream struct {
+	// frequency is the frequency this stream receives audio from.
+	frequency unit.Frequency
+	// ch queues decoded transmissions for the pump. It is closed when the stream is removed from the client.
+	ch chan Audio
+	// reader is read by the consumer.
+	reader *io.PipeReader
+	// writer is written by the pump.
+	writer *io.PipeWriter
+	// client is the client which publishes to this stream.
+	client *audioClient
+}
+
+// ReceiveReader implements [AudioClient.ReceiveReader].
+func (c *audioClient) ReceiveReader(frequency unit.Frequency) io.ReadCloser {
+	reader, writer := io.Pipe()
+	stream := &receiveStream{
+		frequency: frequency,
+		ch:        make(chan Audio, streamBufferSize),
+		reader:    reader,
+		writer:    writer,
+		client:    c,
+	}
+	c.streamsLock.Lock()
+	defer c.streamsLock.Unlock()
+	if c.streamsClosed {
+		close(stream.ch)
+	} else {
+		c.streams[stream] = struct{}{}
+	}
+	go stream.pump()
+	return stream
+}
+
+// pump writes queued transmissions into the pipe until the stream is removed from the client, then closes the pipe so
+// that the consumer reads [io.EOF] after the remaining audio.
+func (s *receiveStream) pump() {
+	for audio := range s.ch {
+		// Once the consumer closes the reader, writes fail immediately. Keep draining until the channel is closed.
+		_, _ = s.writer.Write(pcm.F32toS16LEBytes(audio))
+	}
+	_ = s.writer.Close()
+}
+
+// Read implements [io.Reader].
+func (s *receiveStream) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
+
+// Close implements [io.Closer]. Audio which has not yet been read is discarded.
+func (s *receiveStream) Close() error {
+	s.client.removeStream(s)
+	return s.reader.Close()
+}
+
+// removeStream stops publishing to the given stream.
+func (c *audioClient) removeStream(stream *receiveStream) {
+	c.streamsLock.Lock()
+	defer c.streamsLock.Unlock()
+	if _, ok := c.streams[stream]; ok {
+		delete(c.streams, stream)
+		close(stream.ch)
+	}
+}
+
+// closeStreams stops publishing to every stream, so that their consumers read [io.EOF] after the remaining audio.
+// Streams opened afterwards are closed immediately.
+func (c *audioClient) closeStreams() {
+	c.streamsLock.Lock()
+	defer c.streamsLock.Unlock()
+	for stream := range c.streams {
+		delete(c.streams, stream)
+		close(stream.ch)
+	}
+	c.streamsClosed = true
+}
+
+// publishToStreams queues the decoded audio of a transmission on every stream whose frequency matches the frequency
+// the transmission was received on. If a stream's consumer has fallen behind and its queue is full, the audio is
+// dropped for that stream rather than stalling the decoder.
+func (c *audioClient) publishToStreams(voicePackets []voice.VoicePacket, audio Audio) {
+	if len(voicePackets) == 0 {
+		return
+	}
+	c.streamsLock.RLock()
+	defer c.streamsLock.RUnlock()
+	for stream := range c.streams {
+		if !isOnFrequency(voicePackets[0], stream.frequency, c.frequencyTolerance) {
+			continue
+		}
+		select {
+		case stream.ch <- audio:
+		default:
+			c.receiveLogger.Warn().
+				Func(types.LogFrequency(stream.frequency)).
+				Msg("receive stream consumer is falling behind, dropping received audio")
+		}
+	}
+}
+
+// isOnFrequency returns true if the voice packet was transmitted on the given frequency.
+func isOnFrequency(vp voice.VoicePacket, frequency unit.Frequency, tolerance unit.Frequency) bool {
+	for _, f := range vp.Frequencies {
+		if types.IsSameFrequency(f.Frequency, frequency.Hertz(), tolerance) {
+			return true
+		}
+	}
+	return false
+}
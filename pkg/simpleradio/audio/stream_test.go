@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"io"
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiveReader(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{
+		streams:            make(map[*receiveStream]struct{}),
+		frequencyTolerance: types.DefaultFrequencyTolerance,
+	}
+	uhf := c.ReceiveReader(251 * unit.Megahertz)
+	vhf := c.ReceiveReader(133 * unit.Megahertz)
+
+	onUHF := []voice.VoicePacket{{Frequencies: []voice.Frequency{{Frequency: 251000000}}}}
+	onVHF := []voice.VoicePacket{{Frequencies: []voice.Frequency{{Frequency: 133000000}}}}
+	first := Audio{0.5, -0.5}
+	second := Audio{0.25}
+	c.publishToStreams(onUHF, first)
+	c.publishToStreams(onVHF, Audio{1})
+	c.publishToStreams(onUHF, second)
+
+	// Closing a stream discards its unread audio and stops publishing to it.
+	require.NoError(t, vhf.Close())
+	c.publishToStreams(onVHF, Audio{1})
+	assert.NotContains(t, c.streams, vhf)
+
+	// Closing the client ends the remaining streams after their buffered audio.
+	c.closeStreams()
+	b, err := io.ReadAll(uhf)
+	require.NoError(t, err)
+	expected := append(pcm.F32toS16LEBytes(first), pcm.F32toS16LEBytes(second)...)
+	assert.Equal(t, expected, b)
+
+	// Streams opened after the client is closed end immediately.
+	b, err = io.ReadAll(c.ReceiveReader(251 * unit.Megahertz))
+	require.NoError(t, err)
+	assert.Empty(t, b)
+}
+
+func TestPublishToStreamsDropsWhenFull(t *testing.T) {
+	t.Parallel()
+	c := &audioClient{
+		streams:            make(map[*receiveStream]struct{}),
+		frequencyTolerance: types.DefaultFrequencyTolerance,
+	}
+	stream := c.ReceiveReader(251 * unit.Megahertz)
+	packets := []voice.VoicePacket{{Frequencies: []voice.Frequency{{Frequency: 251000000}}}}
+	// Nothing reads the stream, so the pump blocks on the first transmission and the rest fill the queue. Further
+	// transmissions must be dropped without blocking the caller.
+	for range streamBufferSize * 2 {
+		c.publishToStreams(packets, Audio{0})
+	}
+	require.NoError(t, stream.Close())
+}
@@ -0,0 +1,29 @@
+package audio
+
+// Suspend implements [AudioClient.Suspend].
+func (c *audioClient) Suspend() {
+	c.isSuspended.Store(true)
+	discarded := c.discardQueued()
+	c.transmitLogger.Info().Int("discarded", discarded).Msg("suspended transmission")
+}
+
+// Resume implements [AudioClient.Resume].
+func (c *audioClient) Resume() {
+	c.isSuspended.Store(false)
+	c.transmitLogger.Info().Msg("resumed transmission")
+}
+
+// discardQueued discards the transmissions waiting in txChan and returns how many were discarded. A transmission which
+// is already being encoded or transmitted is not discarded here, but is skipped when it keys up.
+func (c *audioClient) discardQueued() int {
+	discarded := 0
+	for {
+		select {
+		case queued := <-c.txChan:
+			c.finishTransmission(queued.id)
+			discarded++
+		default:
+			return discarded
+		}
+	}
+}
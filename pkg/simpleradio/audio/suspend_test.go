@@ -0,0 +1,36 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuspend(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	client.txChan = make(chan transmission, 2)
+	transmission := []voice.VoicePacket{voice.NewVoicePacket([]byte{1, 2, 3}, nil, 100000002, 1, 0, []byte(client.guid), []byte(client.guid))}
+
+	client.Transmit(make(Audio, client.frameSize))
+	client.TransmitWithID("picture", make(Audio, client.frameSize))
+	require.Equal(t, int64(2), client.pendingTransmissions.Load())
+
+	// Suspending discards the queue and skips transmissions which key up while suspended.
+	client.Suspend()
+	assert.Empty(t, client.txChan)
+	assert.Zero(t, client.pendingTransmissions.Load())
+	client.tx(transmission)
+	assert.Empty(t, packets)
+
+	client.Resume()
+	client.tx(transmission)
+	packet := <-packets
+	assert.Len(t, packet.Frequencies, 1)
+
+	// The IDs of discarded transmissions are released, so they may be reused.
+	client.TransmitWithID("picture", make(Audio, client.frameSize))
+	assert.Len(t, client.txChan, 1)
+}
@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// throughputInterval is how often the instantaneous throughput is updated.
+const throughputInterval = 5 * time.Second
+
+// Throughput is a snapshot of the audio client's network throughput. Byte counts include UDP payloads only, not
+// packet headers.
+type Throughput struct {
+	// BytesSent is the total number of bytes sent since the client started.
+	BytesSent uint64
+	// BytesReceived is the total number of bytes received since the client started.
+	BytesReceived uint64
+	// SentPerSecond is the rate of bytes sent over the most recent measurement interval.
+	SentPerSecond float64
+	// ReceivedPerSecond is the rate of bytes received over the most recent measurement interval.
+	ReceivedPerSecond float64
+	// AverageSentPerSecond is the average rate of bytes sent since the client started.
+	AverageSentPerSecond float64
+	// AverageReceivedPerSecond is the average rate of bytes received since the client started.
+	AverageReceivedPerSecond float64
+}
+
+// throughputMeter counts the bytes sent and received by the client, and periodically samples the counters to compute
+// the instantaneous rates. Counting is lock-free so that it adds negligible overhead to the send and receive loops.
+type throughputMeter struct {
+	sent     atomic.Uint64
+	received atomic.Uint64
+
+	// lock protects the following fields.
+	lock sync.Mutex
+	// started is when the client started. It is zero until start is called.
+	started time.Time
+	// lastSample is when the counters were last sampled.
+	lastSample time.Time
+	// lastSent is the value of sent when the counters were last sampled.
+	lastSent uint64
+	// lastReceived is the value of received when the counters were last sampled.
+	lastReceived uint64
+	// sentRate is the rate of bytes sent between the two most recent samples.
+	sentRate float64
+	// receivedRate is the rate of bytes received between the two most recent samples.
+	receivedRate float64
+}
+
+// start records the start time, which is the first sample.
+func (m *throughputMeter) start(now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.started = now
+	m.lastSample = now
+	m.lastSent = m.sent.Load()
+	m.lastReceived = m.received.Load()
+}
+
+// sample updates the instantaneous rates from the change in the counters since the previous sample.
+func (m *throughputMeter) sample(now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	elapsed := now.Sub(m.lastSample).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	sent := m.sent.Load()
+	received := m.received.Load()
+	m.sentRate = float64(sent-m.lastSent) / elapsed
+	m.receivedRate = float64(received-m.lastReceived) / elapsed
+	m.lastSample = now
+	m.lastSent = sent
+	m.lastReceived = received
+}
+
+// snapshot returns the current throughput.
+func (m *throughputMeter) snapshot(now time.Time) Throughput {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	throughput := Throughput{
+		BytesSent:         m.sent.Load(),
+		BytesReceived:     m.received.Load(),
+		SentPerSecond:     m.sentRate,
+		ReceivedPerSecond: m.receivedRate,
+	}
+	if elapsed := now.Sub(m.started).Seconds(); !m.started.IsZero() && elapsed > 0 {
+		throughput.AverageSentPerSecond = float64(throughput.BytesSent) / elapsed
+		throughput.AverageReceivedPerSecond = float64(throughput.BytesReceived) / elapsed
+	}
+	return throughput
+}
+
+// measureThroughput samples the throughput counters every throughputInterval until the context is canceled.
+func (c *audioClient) measureThroughput(ctx context.Context) {
+	c.throughput.start(time.Now())
+	ticker := time.NewTicker(throughputInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			c.throughput.sample(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Throughput implements [AudioClient.Throughput].
+func (c *audioClient) Throughput() Throughput {
+	return c.throughput.snapshot(time.Now())
+}
+
+// write writes a single packet to the connection and counts the bytes sent.
+func (c *audioClient) write(b []byte) (int, error) {
+	n, err := c.conn().Write(b)
+	c.throughput.sent.Add(uint64(n))
+	c.packets.countWrite(err)
+	return n, err
+}
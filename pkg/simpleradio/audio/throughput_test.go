@@ -0,0 +1,34 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThroughputMeter(t *testing.T) {
+	t.Parallel()
+	var meter throughputMeter
+	assert.Equal(t, Throughput{}, meter.snapshot(time.Now()))
+
+	start := time.Now()
+	meter.start(start)
+	meter.sent.Add(1000)
+	meter.received.Add(4000)
+	meter.sample(start.Add(2 * time.Second))
+	meter.sent.Add(500)
+
+	throughput := meter.snapshot(start.Add(5 * time.Second))
+	assert.Equal(t, uint64(1500), throughput.BytesSent)
+	assert.Equal(t, uint64(4000), throughput.BytesReceived)
+	assert.InDelta(t, 500, throughput.SentPerSecond, 0.001)
+	assert.InDelta(t, 2000, throughput.ReceivedPerSecond, 0.001)
+	assert.InDelta(t, 300, throughput.AverageSentPerSecond, 0.001)
+	assert.InDelta(t, 800, throughput.AverageReceivedPerSecond, 0.001)
+
+	meter.sample(start.Add(7 * time.Second))
+	throughput = meter.snapshot(start.Add(7 * time.Second))
+	assert.InDelta(t, 100, throughput.SentPerSecond, 0.001)
+	assert.Zero(t, throughput.ReceivedPerSecond)
+}
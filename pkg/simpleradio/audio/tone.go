@@ -0,0 +1,121 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+)
+
+// toneAmplitude is the peak amplitude of synthesized tones. The sine waves of a tone share this amplitude, so that
+// DTMF tones do not clip.
+const toneAmplitude = 0.5
+
+// toneFade is the duration of the fade-in and fade-out applied to each synthesized tone, which avoids clicks where a
+// tone starts and stops.
+const toneFade = 5 * time.Millisecond
+
+// Tone is a segment of a tone signal: one or more sine waves played together for a duration. A tone with no
+// frequencies is silence, which can be used as a gap between tones.
+type Tone struct {
+	// Frequencies are the audio frequencies of the sine waves mixed together.
+	Frequencies []unit.Frequency
+	// Duration is how long the tone is played.
+	Duration time.Duration
+}
+
+// Beep returns a tone of a single sine wave of the given audio frequency and duration.
+func Beep(frequency unit.Frequency, duration time.Duration) Tone {
+	return Tone{Frequencies: []unit.Frequency{frequency}, Duration: duration}
+}
+
+// Pause returns a silent tone of the given duration.
+func Pause(duration time.Duration) Tone {
+	return Tone{Duration: duration}
+}
+
+// dtmfRows are the low DTMF frequencies, one for each row of the keypad.
+var dtmfRows = [4]unit.Frequency{697 * unit.Hertz, 770 * unit.Hertz, 852 * unit.Hertz, 941 * unit.Hertz}
+
+// dtmfColumns are the high DTMF frequencies, one for each column of the keypad.
+var dtmfColumns = [4]unit.Frequency{1209 * unit.Hertz, 1336 * unit.Hertz, 1477 * unit.Hertz, 1633 * unit.Hertz}
+
+// dtmfKeypad is the layout of the DTMF keypad.
+var dtmfKeypad = [4]string{"123A", "456B", "789C", "*0#D"}
+
+// DTMF returns the tones which dial the given digits, each played for the given duration and followed by a pause of
+// the given gap. The digits may be 0-9, A-D, * and #.
+func DTMF(digits string, duration, gap time.Duration) ([]Tone, error) {
+	tones := make([]Tone, 0, 2*len(digits))
+	for _, digit := range digits {
+		tone, err := dtmfTone(digit, duration)
+		if err != nil {
+			return nil, err
+		}
+		tones = append(tones, tone, Pause(gap))
+	}
+	return tones, nil
+}
+
+// dtmfTone returns the tone for a single DTMF digit.
+func dtmfTone(digit rune, duration time.Duration) (Tone, error) {
+	for row, keys := range dtmfKeypad {
+		for column, key := range keys {
+			if key == digit {
+				return Tone{Frequencies: []unit.Frequency{dtmfRows[row], dtmfColumns[column]}, Duration: duration}, nil
+			}
+		}
+	}
+	return Tone{}, fmt.Errorf("%q is not a DTMF digit", digit)
+}
+
+// SynthesizeTones returns the audio of the given tones played one after another, at the given sample rate in Hz. Each
+// tone is faded in and out over a few milliseconds to avoid clicks.
+func SynthesizeTones(tones []Tone, sampleRate int) Audio {
+	audio := make(Audio, 0)
+	for _, tone := range tones {
+		segment := make(Audio, samplesIn(tone.Duration, sampleRate))
+		if len(tone.Frequencies) > 0 {
+			amplitude := toneAmplitude / float64(len(tone.Frequencies))
+			for i := range segment {
+				t := float64(i) / float64(sampleRate)
+				var sample float64
+				for _, frequency := range tone.Frequencies {
+					sample += amplitude * math.Sin(2*math.Pi*frequency.Hertz()*t)
+				}
+				segment[i] = float32(sample)
+			}
+			segment = segment.Fade(toneFade, toneFade, sampleRate)
+		}
+		audio = append(audio, segment...)
+	}
+	return audio
+}
+
+// TransmitTone implements [AudioClient.TransmitTone].
+func (c *audioClient) TransmitTone(frequency unit.Frequency, tones []Tone) error {
+	audio := SynthesizeTones(tones, sampleRate)
+	if len(audio) == 0 {
+		return errors.New("tones have no duration")
+	}
+	if !c.isTunedTo(frequency) {
+		return fmt.Errorf("no radio is tuned to %s MHz", types.FormatFrequency(frequency))
+	}
+	if err := c.enqueue(transmission{audio: audio, frequency: frequency}); err != nil {
+		return fmt.Errorf("failed to queue tones: %w", err)
+	}
+	return nil
+}
+
+// isTunedTo returns true if any of the client's radios is tuned to the given frequency.
+func (c *audioClient) isTunedTo(frequency unit.Frequency) bool {
+	for _, radio := range c.radioList() {
+		if types.IsSameFrequency(radio.Frequency, frequency.Hertz(), c.frequencyTolerance) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDTMF(t *testing.T) {
+	t.Parallel()
+	tones, err := DTMF("1#", 100*time.Millisecond, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, tones, 4)
+	assert.Equal(t, []unit.Frequency{697 * unit.Hertz, 1209 * unit.Hertz}, tones[0].Frequencies)
+	assert.Equal(t, 100*time.Millisecond, tones[0].Duration)
+	assert.Empty(t, tones[1].Frequencies)
+	assert.Equal(t, 50*time.Millisecond, tones[1].Duration)
+	assert.Equal(t, []unit.Frequency{941 * unit.Hertz, 1477 * unit.Hertz}, tones[2].Frequencies)
+
+	_, err = DTMF("12X", 100*time.Millisecond, 50*time.Millisecond)
+	require.Error(t, err)
+}
+
+func TestSynthesizeTones(t *testing.T) {
+	t.Parallel()
+	dtmf, err := DTMF("5", 100*time.Millisecond, 0)
+	require.NoError(t, err)
+	tones := append([]Tone{Beep(1000*unit.Hertz, 200*time.Millisecond), Pause(100 * time.Millisecond)}, dtmf...)
+	audio := SynthesizeTones(tones, sampleRate)
+	require.Len(t, audio, samplesIn(400*time.Millisecond, sampleRate))
+
+	beep := audio[:samplesIn(200*time.Millisecond, sampleRate)]
+	assert.InDelta(t, 0, beep[0], 0.001, "tone should fade in")
+	crossings := 0
+	for i := 1; i < len(beep); i++ {
+		if (beep[i-1] < 0) != (beep[i] < 0) {
+			crossings++
+		}
+	}
+	assert.InDelta(t, 400, crossings, 4, "a 1kHz tone crosses zero twice per cycle")
+
+	pause := audio[len(beep):samplesIn(300*time.Millisecond, sampleRate)]
+	for _, sample := range pause {
+		require.Zero(t, sample)
+	}
+	for _, sample := range audio {
+		require.LessOrEqual(t, math.Abs(float64(sample)), toneAmplitude+0.001, "tones should not exceed the tone amplitude")
+	}
+}
+
+func TestTransmitTone(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	guard := types.Radio{Frequency: 243000000, Modulation: types.ModulationAM}
+	working := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	client.radios = []types.Radio{guard, working}
+	client.frequencyTolerance = types.DefaultFrequencyTolerance
+	client.txChan = make(chan transmission, 1)
+
+	tones := []Tone{Beep(1000*unit.Hertz, 100*time.Millisecond)}
+	require.Error(t, client.TransmitTone(133*unit.Megahertz, tones))
+	require.Error(t, client.TransmitTone(251*unit.Megahertz, nil))
+	require.NoError(t, client.TransmitTone(251*unit.Megahertz, tones))
+	queued := <-client.txChan
+	assert.Equal(t, 251*unit.Megahertz, queued.frequency)
+	assert.Equal(t, SynthesizeTones(tones, sampleRate), queued.audio)
+
+	// The transmission is only sent on the radio tuned to its frequency.
+	transmission := []voice.VoicePacket{voice.NewVoicePacket([]byte{1, 2, 3}, nil, 100000002, 1, 0, []byte(client.guid), []byte(client.guid))}
+	client.txOn(transmission, queued.frequency)
+	packet := <-packets
+	require.Len(t, packet.Frequencies, 1)
+	assert.InDelta(t, working.Frequency, packet.Frequencies[0].Frequency, 0)
+
+	// Mute applies to tones like any other transmission.
+	require.NoError(t, client.SetMutedOn(251*unit.Megahertz, true))
+	client.txOn(transmission, queued.frequency)
+	assert.Empty(t, packets)
+}
@@ -2,76 +2,274 @@ package audio
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"math/rand/v2"
+	"slices"
 	"time"
 
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/dharmab/skyeye/pkg/simpleradio/internal/callbacks"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
 	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
-	"github.com/rs/zerolog/log"
+	"github.com/martinlindhe/unit"
 )
 
 // transmit the voice packets from queued transmissions to the SRS server.
-func (c *audioClient) transmit(ctx context.Context, packetCh <-chan []voice.VoicePacket) {
+func (c *audioClient) transmit(ctx context.Context, packetCh <-chan transmission) {
+	if err := c.awaitStartupGrace(ctx); err != nil {
+		c.transmitLogger.Info().Msg("stopping SRS audio transmitter due to context cancellation")
+		return
+	}
+	var lastEnd time.Time
 	for {
 		select {
-		case packets := <-packetCh:
-			c.tx(packets)
-			// Pause between transmissions to sound more natural.
-			pause := time.Duration(500+rand.IntN(500)) * time.Millisecond
-			time.Sleep(pause)
+		case queued := <-packetCh:
+			for next := &queued; next != nil; {
+				if !next.continues {
+					// Pause between transmissions to sound more natural.
+					time.Sleep(time.Until(lastEnd.Add(c.transmitPause())))
+				}
+				next = c.txSegments(*next, packetCh)
+				lastEnd = time.Now()
+			}
 		case <-ctx.Done():
-			log.Info().Msg("stopping SRS audio transmitter due to context cancellation")
+			c.transmitLogger.Info().Msg("stopping SRS audio transmitter due to context cancellation")
 			return
 		}
 	}
 }
 
-func (c *audioClient) waitForClearChannel() {
+// transmitPause returns a random pause of between 500ms and 1s to wait between transmissions. The configured random
+// source is used if set; otherwise, the global source is used.
+func (c *audioClient) transmitPause() time.Duration {
+	var jitter int
+	if c.random != nil {
+		jitter = c.random.IntN(500)
+	} else {
+		jitter = rand.IntN(500)
+	}
+	return time.Duration(500+jitter) * time.Millisecond
+}
+
+// waitForClearChannel blocks until no incoming transmissions are being received, or until the clear channel timeout
+// expires. It returns the time spent waiting, and whether the channel was clear when it returned. If the channel was
+// not clear, the GUID of the colliding transmitter is also returned.
+func (c *audioClient) waitForClearChannel() (waited time.Duration, origin types.GUID, isClear bool) {
+	start := time.Now()
 	for {
 		isReceiving := false
 		deadline := time.Now()
-		for _, receiver := range c.receivers {
-			if receiver.isReceivingTransmission() {
+		for _, receiver := range c.receiverMap() {
+			if rxDeadline, rxOrigin, ok := receiver.activeTransmission(); ok {
 				isReceiving = true
-				if receiver.deadline.After(deadline) {
-					deadline = receiver.deadline
+				if rxDeadline.After(deadline) {
+					deadline = rxDeadline
+					origin = rxOrigin
 				}
 			}
 		}
-		if isReceiving {
-			delay := time.Until(deadline) + 250*time.Millisecond
-			log.Info().Stringer("delay", delay).Msg("delaying outgoing transmission to avoid interrupting incoming transmission")
-			time.Sleep(delay)
-		} else {
-			return
+		if !isReceiving {
+			return time.Since(start), "", true
 		}
+		if c.clearChannelTimeout > 0 && time.Since(start) >= c.clearChannelTimeout {
+			return time.Since(start), origin, false
+		}
+		delay := time.Until(deadline) + 250*time.Millisecond
+		if c.clearChannelTimeout > 0 {
+			delay = min(delay, c.clearChannelTimeout-time.Since(start))
+		}
+		c.transmitLogger.Info().Stringer("delay", delay).Msg("delaying outgoing transmission to avoid interrupting incoming transmission")
+		time.Sleep(delay)
 	}
 }
 
-func (c *audioClient) writePackets(packets []voice.VoicePacket) {
-	startTime := time.Now()
-	for i, vp := range packets {
-		b := vp.Encode()
-		// Tight timing is important here - don't write the next packet until halfway through the previous packet's frame.
-		// Write too quickly, and the server will skip audio to play the latest packet.
-		// Write too slowly, and the transmission will stutter.
-		delay := time.Until(
-			startTime.
-				Add(time.Duration(i) * frameLength).
-				Add(-frameLength / 2),
-		)
-		time.Sleep(delay)
-		_, err := c.connection.Write(b)
+// writePackets writes the voice packets to the SRS server, addressed to the given frequencies, on the given pacer's
+// schedule.
+func (c *audioClient) writePackets(packets []voice.VoicePacket, frequencies []voice.Frequency, pacer *pacer) {
+	// buf is reused for each packet to avoid allocating in this tight loop.
+	buf := make([]byte, 0, maxPacketLength)
+	for _, vp := range packets {
+		// Rebuild the packet so that its segment lengths match the frequencies.
+		vp = voice.NewVoicePacket(vp.AudioBytes, frequencies, vp.UnitID, vp.PacketID, vp.Hops, vp.RelayGUID, vp.OriginGUID)
+		b := vp.EncodeInto(buf)
+		// Tight timing is important here - see packetDeadline.
+		pacer.wait()
+		if dropPacket(c.transmitLossRate) {
+			c.transmitLogger.Trace().Msg("dropping transmitted voice packet to simulate packet loss")
+			continue
+		}
+		_, err := c.write(b)
 		if err != nil {
-			log.Error().Err(err).Msg("failed to transmit voice packet")
+			c.transmitLogger.Error().Err(err).Msg("failed to transmit voice packet")
+		}
+	}
+}
+
+// txSegments transmits the given transmission, followed by the queued transmissions which continue it. The segments of
+// a logical transmission are sent back to back while holding c.busy, so they are sent on the same frequencies without
+// pausing or waiting for a clear channel between them, and no streamed transmission can cut in. Only segments which are
+// already encoded when the previous segment ends are held this way; a later segment is sent as soon as possible, but
+// must key up again. The first queued transmission which does not continue the logical transmission is returned, or
+// nil if there is none.
+func (c *audioClient) txSegments(queued transmission, packetCh <-chan transmission) *transmission {
+	c.busy.Lock()
+	defer c.busy.Unlock()
+	frequencies, ok := c.keyUp(queued.frequency)
+	// The segments share a schedule, so their packets are evenly spaced across the boundaries between segments.
+	pacer := newPacer(c.pacingStrategy, c.frameLength)
+	for {
+		if ok {
+			c.writePackets(queued.packets, frequencies, pacer)
+		}
+		c.finishTransmission(queued.id)
+		select {
+		case next := <-packetCh:
+			if !next.continues {
+				return &next
+			}
+			queued = next
+		default:
+			return nil
 		}
 	}
 }
 
 func (c *audioClient) tx(packets []voice.VoicePacket) {
+	c.txOn(packets, 0)
+}
+
+// txOn transmits the voice packets on the radios tuned to the given frequency, or on every radio if the frequency is
+// zero.
+func (c *audioClient) txOn(packets []voice.VoicePacket, frequency unit.Frequency) {
+	c.busy.Lock()
+	defer c.busy.Unlock()
+	if frequencies, ok := c.keyUp(frequency); ok {
+		c.writePackets(packets, frequencies, newPacer(c.pacingStrategy, c.frameLength))
+	}
+}
+
+// keyUp waits for a clear channel before a transmission. It returns the frequencies to transmit on, or false if the
+// client is muted on every frequency and should not transmit. If the given frequency is not zero, only the radios tuned
+// to it are considered. The caller must hold c.busy.
+func (c *audioClient) keyUp(frequency unit.Frequency) ([]voice.Frequency, bool) {
+	waited, origin, isClear := c.waitForClearChannel()
+	if c.mute {
+		return nil, false
+	}
+	if c.isSuspended.Load() {
+		c.transmitLogger.Debug().Msg("skipping transmission because the client is suspended")
+		return nil, false
+	}
+	if c.isTransmitForbidden.Load() {
+		c.transmitLogger.Warn().Msg("skipping transmission because the SRS server does not permit this client to transmit")
+		return nil, false
+	}
+	frequencies := c.unmutedFrequencies()
+	if frequency != 0 {
+		frequencies = slices.DeleteFunc(frequencies, func(f voice.Frequency) bool {
+			return !types.IsSameFrequency(f.Frequency, frequency.Hertz(), c.frequencyTolerance)
+		})
+	}
+	if len(frequencies) == 0 {
+		c.transmitLogger.Debug().Msg("skipping transmission because every frequency is muted")
+		return nil, false
+	}
+	if !isClear {
+		c.transmitLogger.Warn().
+			Stringer("waited", waited).
+			Str("origin", string(origin)).
+			Msg("clear channel timeout expired, transmitting over incoming transmission")
+		if callback := callbacks.Load(&c.transmittedOverCallback); callback != nil {
+			callback(waited, origin)
+		}
+	}
+	return frequencies, true
+}
+
+// TransmitReader implements [AudioClient.TransmitReader].
+func (c *audioClient) TransmitReader(ctx context.Context, r io.Reader, inputRate int) error {
+	if inputRate <= 0 {
+		return fmt.Errorf("sample rate must be positive, got %d", inputRate)
+	}
+	if !c.beginTransmission() {
+		return ErrShuttingDown
+	}
+	defer c.finishTransmission("")
+	encoder, err := c.newEncoder()
+	if err != nil {
+		return err
+	}
+	if err := c.awaitStartupGrace(ctx); err != nil {
+		return err
+	}
+	// in holds one frame of input audio, which is downmixed and resampled into one Opus frame.
+	in := make([]byte, 2*max(1, c.inputChannels)*max(1, int(math.Round(float64(inputRate)*c.frameLength.Seconds()))))
+	// buf is reused for each packet to avoid allocating in this loop.
+	buf := make([]byte, 0, maxPacketLength)
+
 	c.busy.Lock()
 	defer c.busy.Unlock()
-	c.waitForClearChannel()
-	if !c.mute {
-		c.writePackets(packets)
+	frequencies, shouldTransmit := c.keyUp(0)
+	guid := []byte(c.currentGUID())
+	var transmission Audio
+	defer func() {
+		if len(transmission) > 0 {
+			c.capture(transmission)
+		}
+	}()
+	// The resampler keeps its state across reads, so the audio is resampled as one stream without discontinuities at
+	// the boundaries between reads.
+	resampler := pcm.NewResampler(inputRate, sampleRate)
+	pacer := newPacer(c.pacingStrategy, c.frameLength)
+	// pending holds resampled audio which does not yet fill an Opus frame.
+	var pending Audio
+	for i := 0; ; {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("streamed transmission canceled: %w", err)
+		}
+		n, readErr := io.ReadFull(r, in)
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("failed to read audio: %w", readErr)
+		}
+		pending = append(pending, resampler.Write(c.toMono(pcm.S16LEBytesToF32LE(in[:n-n%2])))...)
+		isEnd := readErr != nil
+		if isEnd {
+			pending = append(pending, resampler.Flush()...)
+			// Align audio to Opus frame size
+			if remainder := len(pending) % c.frameSize; remainder != 0 {
+				pending = append(pending, make([]float32, c.frameSize-remainder)...)
+			}
+		}
+
+		for ; len(pending) >= c.frameSize; i++ {
+			frame := pending[:c.frameSize]
+			pending = pending[c.frameSize:]
+			if c.captureCh != nil {
+				transmission = append(transmission, frame...)
+			}
+			if !shouldTransmit {
+				continue
+			}
+			audioBytes, err := c.encode(encoder, frame)
+			if err != nil {
+				c.transmitLogger.Error().Err(err).Int("index", i).Msg("failed to encode audio")
+				continue
+			}
+			vp := voice.NewVoicePacket(audioBytes, frequencies, 100000002, c.nextPacketNumber(), 0, guid, guid)
+			// Tight timing is important here - see packetDeadline.
+			pacer.wait()
+			if dropPacket(c.transmitLossRate) {
+				c.transmitLogger.Trace().Msg("dropping transmitted voice packet to simulate packet loss")
+			} else if _, err := c.write(vp.EncodeInto(buf)); err != nil {
+				c.transmitLogger.Error().Err(err).Msg("failed to transmit voice packet")
+			}
+		}
+
+		if isEnd {
+			return nil
+		}
 	}
 }
@@ -0,0 +1,208 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"math/rand/v2"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamingTestClient returns a client connected to an in-memory pipe, and a channel which receives the voice
+// packets written to the pipe.
+func newStreamingTestClient(t *testing.T) (*audioClient, <-chan voice.VoicePacket) {
+	t.Helper()
+	clientConnection, serverConnection := net.Pipe()
+	t.Cleanup(func() {
+		_ = clientConnection.Close()
+		_ = serverConnection.Close()
+	})
+	packets := make(chan voice.VoicePacket, 16)
+	go func() {
+		b := make([]byte, maxPacketLength)
+		for {
+			n, err := serverConnection.Read(b)
+			if err != nil {
+				return
+			}
+			packets <- voice.NewVoicePacketFrom(bytes.Clone(b[:n]))
+		}
+	}()
+	client := &audioClient{
+		guid:         types.NewGUID(),
+		radios:       []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}},
+		connection:   clientConnection,
+		captureCh:    make(chan Audio, 1),
+		packetNumber: 1,
+		frameLength:  defaultFrameLength,
+		frameSize:    frameSizeOf(defaultFrameLength),
+	}
+	return client, packets
+}
+
+func TestTransmitReader(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+
+	// 2.5 frames of 48kHz audio is sent as three packets, the last padded with silence.
+	const inputRate = 48000
+	inputFrameSize := inputRate * int(defaultFrameLength.Milliseconds()) / 1000
+	sample := make([]float32, inputFrameSize*5/2)
+	for i := range sample {
+		sample[i] = 0.25
+	}
+	err := client.TransmitReader(context.Background(), bytes.NewReader(pcm.F32toS16LEBytes(sample)), inputRate)
+	require.NoError(t, err)
+
+	for i := range 3 {
+		packet := <-packets
+		assert.Equal(t, uint64(i+1), packet.PacketID)
+		assert.Equal(t, []byte(client.guid), packet.OriginGUID)
+		require.Len(t, packet.Frequencies, 1)
+		assert.InDelta(t, 251000000, packet.Frequencies[0].Frequency, 0)
+	}
+	assert.Empty(t, packets)
+	assert.Len(t, <-client.CaptureTransmissions(), 3*client.frameSize)
+}
+
+func TestTransmitReaderResamplesContinuously(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	go func() {
+		for range packets {
+		}
+	}()
+
+	// A stream read one frame at a time is resampled the same as the whole stream at once, without discontinuities at
+	// the frame boundaries.
+	const inputRate = 48000
+	sample := make([]float32, inputRate/2)
+	for i := range sample {
+		sample[i] = 0.5 * float32(math.Sin(2*math.Pi*440*float64(i)/inputRate))
+	}
+	err := client.TransmitReader(context.Background(), bytes.NewReader(pcm.F32toS16LEBytes(sample)), inputRate)
+	require.NoError(t, err)
+
+	expected := pcm.Resample(pcm.S16LEBytesToF32LE(pcm.F32toS16LEBytes(sample)), inputRate, sampleRate)
+	captured := <-client.CaptureTransmissions()
+	require.GreaterOrEqual(t, len(captured), len(expected))
+	for i := range expected {
+		require.InDelta(t, expected[i], captured[i], 1e-6, "sample %d", i)
+	}
+}
+
+// stallingReader is an io.Reader which pauses once before reading from the given offset.
+type stallingReader struct {
+	r      *bytes.Reader
+	offset int64
+	stall  time.Duration
+}
+
+func (r *stallingReader) Read(p []byte) (int, error) {
+	position := r.r.Size() - int64(r.r.Len())
+	if position == r.offset {
+		time.Sleep(r.stall)
+	} else if position < r.offset && position+int64(len(p)) > r.offset {
+		p = p[:r.offset-position]
+	}
+	return r.r.Read(p)
+}
+
+func TestTransmitReaderSlowReader(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+
+	// The reader stalls for several frames after the first two frames of audio.
+	frameBytes := 2 * client.frameSize
+	r := &stallingReader{
+		r:      bytes.NewReader(make([]byte, 8*frameBytes)),
+		offset: int64(2 * frameBytes),
+		stall:  5 * client.frameLength,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.TransmitReader(context.Background(), r, sampleRate)
+	}()
+
+	var received []time.Time
+	for range 8 {
+		<-packets
+		received = append(received, time.Now())
+	}
+	require.NoError(t, <-errCh)
+
+	// After the stall, packets keep their usual spacing instead of being sent in a burst to catch up.
+	for i := 3; i < len(received); i++ {
+		assert.Greater(t, received[i].Sub(received[i-1]), client.frameLength/2, "packet %d was sent in a burst", i)
+	}
+}
+
+func TestTransmitReaderMuted(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	client.mute = true
+
+	r := bytes.NewReader(make([]byte, 10*2*client.frameSize))
+	require.NoError(t, client.TransmitReader(context.Background(), r, sampleRate))
+	assert.Zero(t, r.Len(), "muted stream should still be read to the end")
+	assert.Empty(t, packets)
+}
+
+func TestTransmitNotPermitted(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	client.SetTransmitPermitted(false)
+
+	r := bytes.NewReader(make([]byte, 10*2*client.frameSize))
+	require.NoError(t, client.TransmitReader(context.Background(), r, sampleRate))
+	assert.Zero(t, r.Len(), "stream should still be read to the end")
+	assert.Empty(t, packets)
+
+	client.SetTransmitPermitted(true)
+	require.NoError(t, client.TransmitReader(context.Background(), bytes.NewReader(make([]byte, 2*client.frameSize)), sampleRate))
+	packet := <-packets
+	assert.Equal(t, uint64(1), packet.PacketID)
+}
+
+func TestTransmitReaderCanceled(t *testing.T) {
+	t.Parallel()
+	client, packets := newStreamingTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.TransmitReader(ctx, bytes.NewReader(make([]byte, 2*client.frameSize)), sampleRate)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, packets)
+}
+
+func TestTransmitReaderInvalidSampleRate(t *testing.T) {
+	t.Parallel()
+	client, _ := newStreamingTestClient(t)
+	require.Error(t, client.TransmitReader(context.Background(), bytes.NewReader(nil), 0))
+}
+
+func TestTransmitPause(t *testing.T) {
+	t.Parallel()
+	seeded := func() *audioClient {
+		return &audioClient{random: rand.New(rand.NewPCG(1, 2))}
+	}
+	a, b := seeded(), seeded()
+	for range 100 {
+		pause := a.transmitPause()
+		assert.Equal(t, pause, b.transmitPause(), "seeded pauses should be reproducible")
+		assert.GreaterOrEqual(t, pause, 500*time.Millisecond)
+		assert.Less(t, pause, time.Second)
+	}
+
+	pause := (&audioClient{}).transmitPause()
+	assert.GreaterOrEqual(t, pause, 500*time.Millisecond)
+	assert.Less(t, pause, time.Second)
+}
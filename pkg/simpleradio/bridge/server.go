@@ -0,0 +1,284 @@
+// Package bridge streams audio between an SRS client and WebSocket clients, for integrating with browser-based tools.
+package bridge
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/dharmab/skyeye/pkg/simpleradio"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/gorilla/websocket"
+	"github.com/martinlindhe/unit"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/hraban/opus.v2"
+)
+
+// sampleRate is the sample rate of the audio exchanged with WebSocket clients, which is the SRS sample rate.
+const sampleRate = 16000
+
+// opusFrameSize is the number of samples in each Opus frame sent to WebSocket clients, which is 20ms of audio.
+const opusFrameSize = sampleRate / 50
+
+// opusBufferSize is the size of the buffer each Opus frame is encoded into.
+const opusBufferSize = 1500
+
+// pcmChunkSize is the largest number of bytes of PCM16 audio sent to a WebSocket client in a single message.
+const pcmChunkSize = 4096
+
+// maxMessageSize is the largest message accepted from a WebSocket client. This is several minutes of 16kHz PCM16.
+const maxMessageSize = 16 << 20
+
+// closeTimeout is the maximum time to wait to send a close message to a WebSocket client.
+const closeTimeout = time.Second
+
+// Format is the encoding of received audio streamed to WebSocket clients.
+type Format string
+
+const (
+	// FormatPCM16 streams 16kHz mono S16LE PCM. Each binary message contains a whole number of samples. This is the
+	// default.
+	FormatPCM16 Format = "pcm16"
+	// FormatOpus streams 16kHz mono Opus. Each binary message contains a single 20ms Opus frame.
+	FormatOpus Format = "opus"
+)
+
+// Configuration configures a [Server].
+type Configuration struct {
+	// AllowedOrigins are the origins of the web pages allowed to connect, such as "http://localhost:3000". Browsers
+	// send the page's origin with each WebSocket connection, and connections from any other origin are rejected so
+	// that an unrelated web page cannot use the radio. Clients which are not browsers usually send no origin, and are
+	// always allowed. Origins are compared without regard to case.
+	AllowedOrigins []string
+	// Token is a shared secret which WebSocket clients must present, either in an "Authorization: Bearer" header or,
+	// since browsers cannot set headers on WebSocket connections, in the "token" query parameter. If empty, no token
+	// is required.
+	Token string
+}
+
+// Radio is the subset of [simpleradio.Client] used by the bridge.
+type Radio interface {
+	// ReceiveReader returns a stream of the audio received on the given frequency, as 16kHz mono S16LE PCM.
+	ReceiveReader(unit.Frequency) io.ReadCloser
+	// Speak queues F32LE PCM audio at the given sample rate to transmit.
+	Speak(sample []float32, sampleRate int, normalize bool) error
+}
+
+// Server is an [http.Handler] which bridges a radio to WebSocket clients.
+//
+// Each WebSocket connection selects a frequency with the "frequency" query parameter, such as "?frequency=251.0",
+// and optionally an encoding with the "format" query parameter, either "pcm16" or "opus". Audio received on the
+// frequency is streamed to the WebSocket client as binary messages. Each binary message sent by the WebSocket client
+// is transmitted as a single transmission; it must contain 16kHz S16LE PCM with the radio's configured number of
+// input channels, regardless of the selected format. Text messages are ignored.
+//
+// Connections from origins which are not allowed by the configuration are rejected, as are connections without the
+// configured token.
+type Server struct {
+	// radio is the radio bridged to WebSocket clients.
+	radio Radio
+	// allowedOrigins are the origins of the web pages allowed to connect.
+	allowedOrigins []string
+	// token is the shared secret which WebSocket clients must present. If empty, no token is required.
+	token string
+	// upgrader performs the WebSocket handshake.
+	upgrader websocket.Upgrader
+	// logger logs WebSocket connections.
+	logger zerolog.Logger
+}
+
+// NewServer returns a server which bridges the given radio to WebSocket clients.
+func NewServer(radio Radio, config Configuration) (*Server, error) {
+	if radio == nil {
+		return nil, errors.New("radio is required")
+	}
+	s := &Server{
+		radio:          radio,
+		allowedOrigins: slices.Clone(config.AllowedOrigins),
+		token:          config.Token,
+		logger:         log.With().Str("component", "bridge").Logger(),
+	}
+	s.upgrader = websocket.Upgrader{CheckOrigin: s.isAllowedOrigin}
+	return s, nil
+}
+
+// isAllowedOrigin returns true if the request has no origin, or if its origin is allowed by the configuration.
+func (s *Server) isAllowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range s.allowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAuthorized returns true if no token is configured, or if the request presents the configured token.
+func (s *Server) isAuthorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// ServeHTTP implements [http.Handler].
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthorized(r) {
+		s.logger.Warn().Str("remote", r.RemoteAddr).Msg("rejecting WebSocket connection without a valid token")
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "a valid token is required", http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+	frequency, err := simpleradio.ParseRadioFrequency(query.Get("frequency"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid frequency: %v", err), http.StatusBadRequest)
+		return
+	}
+	format := Format(query.Get("format"))
+	if format == "" {
+		format = FormatPCM16
+	}
+	if format != FormatPCM16 && format != FormatOpus {
+		http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	// The upgrader writes an HTTP error to the response if the upgrade fails.
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("remote", r.RemoteAddr).Str("origin", r.Header.Get("Origin")).Msg("failed to upgrade WebSocket connection")
+		return
+	}
+	ws.SetReadLimit(maxMessageSize)
+	logger := s.logger.With().Str("remote", r.RemoteAddr).Logger()
+	logger.Info().
+		Func(types.LogFrequency(frequency.Frequency)).
+		Str("format", string(format)).
+		Msg("WebSocket client connected")
+	defer logger.Info().Msg("WebSocket client disconnected")
+
+	stream := s.radio.ReceiveReader(frequency.Frequency)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := s.sendReceived(ws, stream, format); err != nil {
+			logger.Debug().Err(err).Msg("stopped streaming received audio")
+		}
+		// Close the connection once the stream ends, which also unblocks the transmit loop.
+		closeConnection(ws)
+	}()
+
+	s.transmitMessages(ws, logger)
+	_ = stream.Close()
+	closeConnection(ws)
+	wg.Wait()
+}
+
+// closeConnection sends a normal close message to the WebSocket client, then closes the connection. It is safe to call
+// more than once and from multiple goroutines.
+func closeConnection(ws *websocket.Conn) {
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = ws.WriteControl(websocket.CloseMessage, message, time.Now().Add(closeTimeout))
+	_ = ws.Close()
+}
+
+// sendReceived streams received audio to the WebSocket client until the stream ends or a write fails.
+func (s *Server) sendReceived(ws *websocket.Conn, stream io.Reader, format Format) error {
+	if format == FormatOpus {
+		return sendOpus(ws, stream)
+	}
+	return sendPCM16(ws, stream)
+}
+
+// sendPCM16 streams received audio to the WebSocket client as PCM16.
+func sendPCM16(ws *websocket.Conn, stream io.Reader) error {
+	buf := make([]byte, pcmChunkSize)
+	// pending holds the first byte of a sample split across reads, so that each message has whole samples.
+	var pending []byte
+	for {
+		n, err := stream.Read(buf)
+		if n > 0 {
+			chunk := append(pending, buf[:n]...)
+			whole := len(chunk) - len(chunk)%2
+			if whole > 0 {
+				if writeErr := ws.WriteMessage(websocket.BinaryMessage, chunk[:whole]); writeErr != nil {
+					return writeErr
+				}
+			}
+			pending = chunk[whole:]
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sendOpus streams received audio to the WebSocket client as Opus frames. A final partial frame is padded with
+// silence.
+func sendOpus(ws *websocket.Conn, stream io.Reader) error {
+	encoder, err := opus.NewEncoder(sampleRate, 1, opus.AppVoIP)
+	if err != nil {
+		return fmt.Errorf("failed to create Opus encoder: %w", err)
+	}
+	in := make([]byte, 2*opusFrameSize)
+	out := make([]byte, opusBufferSize)
+	for {
+		n, readErr := io.ReadFull(stream, in)
+		if n == 0 {
+			return readErr
+		}
+		clear(in[n:])
+		frame := pcm.F32toS16LE(pcm.S16LEBytesToF32LE(in))
+		size, err := encoder.Encode(frame, out)
+		if err != nil {
+			return fmt.Errorf("failed to encode Opus frame: %w", err)
+		}
+		if err := ws.WriteMessage(websocket.BinaryMessage, out[:size]); err != nil {
+			return err
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// transmitMessages transmits each binary message from the WebSocket client until the connection is closed.
+func (s *Server) transmitMessages(ws *websocket.Conn, logger zerolog.Logger) {
+	for {
+		messageType, payload, err := ws.ReadMessage()
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+			return
+		}
+		if err != nil {
+			logger.Debug().Err(err).Msg("stopped reading from WebSocket client")
+			return
+		}
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+		if len(payload)%2 != 0 {
+			logger.Warn().Int("bytes", len(payload)).Msg("dropping transmission with a partial sample")
+			continue
+		}
+		if err := s.radio.Speak(pcm.S16LEBytesToF32LE(payload), sampleRate, false); err != nil {
+			logger.Error().Err(err).Msg("failed to transmit audio from WebSocket client")
+		}
+	}
+}
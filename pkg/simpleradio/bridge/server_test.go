@@ -0,0 +1,217 @@
+package bridge
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/gorilla/websocket"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRadio is a [Radio] which records transmissions and streams audio written to its receive pipe.
+type fakeRadio struct {
+	lock          sync.Mutex
+	frequencies   []unit.Frequency
+	reader        *io.PipeReader
+	writer        *io.PipeWriter
+	transmissions chan []float32
+}
+
+func newFakeRadio() *fakeRadio {
+	reader, writer := io.Pipe()
+	return &fakeRadio{reader: reader, writer: writer, transmissions: make(chan []float32, 1)}
+}
+
+func (r *fakeRadio) ReceiveReader(frequency unit.Frequency) io.ReadCloser {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.frequencies = append(r.frequencies, frequency)
+	return r.reader
+}
+
+func (r *fakeRadio) Speak(sample []float32, sampleRate int, _ bool) error {
+	if sampleRate != 16000 {
+		return fmt.Errorf("unexpected sample rate %d", sampleRate)
+	}
+	r.transmissions <- sample
+	return nil
+}
+
+// dial connects to the test server with the given query and request headers.
+func dial(t *testing.T, server *httptest.Server, query string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/?" + query
+	ws, response, err := websocket.DefaultDialer.Dial(url, header)
+	if response != nil {
+		_ = response.Body.Close()
+	}
+	if ws != nil {
+		t.Cleanup(func() { _ = ws.Close() })
+	}
+	return ws, response, err
+}
+
+// connect connects to the test server with the given query, and fails the test if the WebSocket handshake fails.
+func connect(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+	ws, _, err := dial(t, server, query, nil)
+	require.NoError(t, err)
+	return ws
+}
+
+func TestNewServer(t *testing.T) {
+	t.Parallel()
+	_, err := NewServer(nil, Configuration{})
+	require.Error(t, err)
+}
+
+func TestServeHTTPRejectsInvalidRequests(t *testing.T) {
+	t.Parallel()
+	server, err := NewServer(newFakeRadio(), Configuration{})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	for _, query := range []string{"", "frequency=abc", "frequency=251.0&format=mp3", "frequency=251.0"} {
+		response, err := http.Get(httpServer.URL + "/?" + query)
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, query)
+	}
+}
+
+func TestServeHTTPPCM16(t *testing.T) {
+	t.Parallel()
+	radio := newFakeRadio()
+	server, err := NewServer(radio, Configuration{})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	ws := connect(t, httpServer, "frequency=251.0")
+
+	// Received audio is streamed to the WebSocket client.
+	received := pcm.F32toS16LEBytes([]float32{0.25, -0.5, 0.75})
+	go func() { _, _ = radio.writer.Write(received) }()
+	messageType, payload, err := ws.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, messageType)
+	assert.Equal(t, received, payload)
+	radio.lock.Lock()
+	assert.Equal(t, []unit.Frequency{251 * unit.Megahertz}, radio.frequencies)
+	radio.lock.Unlock()
+
+	// Binary messages from the WebSocket client are transmitted; text messages are ignored.
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte("hello")))
+	require.NoError(t, ws.WriteMessage(websocket.BinaryMessage, pcm.F32toS16LEBytes([]float32{0.5, -0.25})))
+	assert.InDeltaSlice(t, []float32{0.5, -0.25}, <-radio.transmissions, 0.001)
+
+	// The server acknowledges a close from the WebSocket client.
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	require.NoError(t, ws.WriteMessage(websocket.CloseMessage, closeMessage))
+	_, _, err = ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), "unexpected error %v", err)
+}
+
+func TestServeHTTPOpus(t *testing.T) {
+	t.Parallel()
+	radio := newFakeRadio()
+	server, err := NewServer(radio, Configuration{})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	ws := connect(t, httpServer, "frequency=251.0&format=opus")
+
+	// A frame and a half of audio is sent as two Opus frames once the stream ends.
+	go func() {
+		_, _ = radio.writer.Write(make([]byte, 3*opusFrameSize))
+		_ = radio.writer.Close()
+	}()
+	for range 2 {
+		messageType, payload, err := ws.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.BinaryMessage, messageType)
+		assert.NotEmpty(t, payload)
+		assert.Less(t, len(payload), opusBufferSize)
+	}
+	_, _, err = ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), "unexpected error %v", err)
+}
+
+func TestServeHTTPOrigin(t *testing.T) {
+	t.Parallel()
+	server, err := NewServer(newFakeRadio(), Configuration{AllowedOrigins: []string{"http://localhost:3000"}})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	testCases := []struct {
+		name     string
+		origin   string
+		expected int
+	}{
+		{name: "no origin", origin: "", expected: http.StatusSwitchingProtocols},
+		{name: "allowed", origin: "http://localhost:3000", expected: http.StatusSwitchingProtocols},
+		{name: "allowed with different case", origin: "HTTP://LOCALHOST:3000", expected: http.StatusSwitchingProtocols},
+		{name: "not allowed", origin: "https://attacker.example", expected: http.StatusForbidden},
+		{name: "same host as server", origin: httpServer.URL, expected: http.StatusForbidden},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			header := http.Header{}
+			if test.origin != "" {
+				header.Set("Origin", test.origin)
+			}
+			_, response, _ := dial(t, httpServer, "frequency=251.0", header)
+			require.NotNil(t, response)
+			assert.Equal(t, test.expected, response.StatusCode)
+		})
+	}
+}
+
+func TestServeHTTPToken(t *testing.T) {
+	t.Parallel()
+	server, err := NewServer(newFakeRadio(), Configuration{Token: "hunter2"})
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	testCases := []struct {
+		name     string
+		query    string
+		header   http.Header
+		expected int
+	}{
+		{name: "no token", query: "frequency=251.0", expected: http.StatusUnauthorized},
+		{name: "wrong token", query: "frequency=251.0&token=hunter3", expected: http.StatusUnauthorized},
+		{name: "query parameter", query: "frequency=251.0&token=hunter2", expected: http.StatusSwitchingProtocols},
+		{
+			name:     "bearer header",
+			query:    "frequency=251.0",
+			header:   http.Header{"Authorization": {"Bearer hunter2"}},
+			expected: http.StatusSwitchingProtocols,
+		},
+		{
+			name:     "wrong bearer header",
+			query:    "frequency=251.0&token=hunter2",
+			header:   http.Header{"Authorization": {"Bearer hunter3"}},
+			expected: http.StatusUnauthorized,
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			_, response, _ := dial(t, httpServer, test.query, test.header)
+			require.NotNil(t, response)
+			assert.Equal(t, test.expected, response.StatusCode)
+		})
+	}
+}
@@ -5,30 +5,213 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dharmab/skyeye/pkg/coalitions"
 	"github.com/dharmab/skyeye/pkg/simpleradio/audio"
 	"github.com/dharmab/skyeye/pkg/simpleradio/data"
+	"github.com/dharmab/skyeye/pkg/simpleradio/internal/callbacks"
 	"github.com/dharmab/skyeye/pkg/simpleradio/types"
 	"github.com/martinlindhe/unit"
 	"github.com/rs/zerolog/log"
 )
 
+// pingTimeout is the maximum time since the last ping was received before the audio link is considered lost.
+const pingTimeout = 1 * time.Minute
+
+// ErrKicked is wrapped by the error returned from Run when the SRS server kicks the client. See [data.ErrKicked].
+var ErrKicked = data.ErrKicked
+
+// ErrPossiblyKicked is wrapped by the error returned from Run when the SRS server closes the connection in a way that
+// may have been a kick. See [data.ErrPossiblyKicked].
+var ErrPossiblyKicked = data.ErrPossiblyKicked
+
 // Client is a SimpleRadio-Standalone client.
 type Client interface {
 	// Name returns the name of the client as it appears in the SRS client list and in in-game transmissions.
 	Name() string
+	// SetName changes the name of the client as it appears in the SRS client list and in in-game transmissions.
+	SetName(string) error
+	// Frequencies returns the frequencies of the client's radios.
+	Frequencies() []unit.Frequency
+	// SetFrequencyName registers a friendly name for the given frequency, such as "Strike" or "Guard", replacing any
+	// existing name. An empty name removes the frequency's name.
+	SetFrequencyName(unit.Frequency, string)
+	// FrequencyName returns the friendly name of the given frequency, matched within the frequency tolerance. If the
+	// frequency has no name, it is formatted in MHz the way it is spoken on the radio, such as "251.0".
+	FrequencyName(unit.Frequency) string
+	// Radios returns a copy of the client's configured radios, including their modulation and encryption settings.
+	Radios() []types.Radio
+	// AddRadio starts receiving and transmitting on another radio and announces it to the SRS server, without
+	// disturbing transmissions being received on the existing radios. An error is returned if the client already has
+	// the maximum number of radios or a radio on the same frequency, and the radio is not added. The radio is still
+	// added if the announcement cannot be sent, in which case the error wraps [data.ErrNotAnnounced].
+	AddRadio(types.Radio) error
+	// RemoveRadio stops receiving and transmitting on the radios tuned to the given frequency and announces the change
+	// to the SRS server. A transmission being received on a removed radio is discarded, although it is still received
+	// on any remaining radio which is hearing it. An error is returned if no radio is tuned to the frequency.
+	RemoveRadio(unit.Frequency) error
+	// RadioCheck reports the client's current listeners and link health, so an operator can confirm the client is set
+	// up correctly.
+	RadioCheck() RadioCheckResult
 	// Run starts the SimpleRadio-Standalone client. It should be called exactly once.
 	Run(context.Context, *sync.WaitGroup) error
+	// CaptureTransmissions returns a channel that receives a copy of each transmission's F32LE PCM audio before it is
+	// encoded. Capture must be enabled in the client configuration; otherwise the returned channel is nil.
+	CaptureTransmissions() <-chan audio.Audio
 	// Receive returns a channel that receives transmissions over the radio. Each transmission is F32LE PCM audio data.
 	Receive() <-chan audio.Audio
-	// Transmit queues a transmission to send over the radio. The audio data should be in F32LE PCM format.
+	// ReceivePCM returns a channel that receives transmissions over the radio as raw PCM bytes in the configured
+	// receive format. It only receives audio if the receive format is not the default of F32LE; otherwise,
+	// transmissions are published to Receive instead.
+	ReceivePCM() <-chan []byte
+	// SetMutedOn mutes or unmutes transmission on the given frequency, while the client continues to transmit on its
+	// other frequencies. The configured mute takes precedence: a client muted by configuration never transmits.
+	SetMutedOn(unit.Frequency, bool) error
+	// ReconnectAudio replaces the UDP audio connection with a new connection to the same SRS server, such as after a NAT
+	// mapping expired, without disturbing the data connection. Unless the client is configured to preserve receiver
+	// state, transmissions being received are discarded so that no audio from before the reconnection is mixed into
+	// audio received after it.
+	ReconnectAudio(context.Context) error
+	// Suspend pauses the client without disconnecting, such as during a maintenance window. The client stops
+	// transmitting and discards its queued transmissions, as well as any transmissions queued while suspended. If
+	// hideRadios is true, the client also announces its radios as switched off, so players can see it is offline; the
+	// SRS server then stops relaying audio to the client until it resumes. An error is returned if the radios cannot be
+	// hidden, but transmission is suspended regardless.
+	Suspend(hideRadios bool) error
+	// Resume undoes Suspend, so that the client transmits again and announces its radios if they were hidden.
+	Resume() error
+	// SetReceiveEnabled enables or disables processing of audio received on the given frequency. While disabled, the
+	// frequency is still advertised to the SRS server, but transmissions heard only on it are dropped before decoding
+	// to save CPU.
+	SetReceiveEnabled(unit.Frequency, bool) error
+	// SetIdleFill sets the audio published on the receive channel and receive streams for the given frequency while
+	// nothing is being received on it, for consumers which expect a continuous stream. By default, nothing is
+	// published while idle.
+	SetIdleFill(unit.Frequency, audio.IdleFill) error
+	// ReceiveReader returns a stream of the audio received on the given frequency, as 16kHz mono S16LE PCM. This is
+	// convenient for piping received audio into external tools. The stream returns io.EOF once the client stops.
+	ReceiveReader(unit.Frequency) io.ReadCloser
+	// CodecInfo returns the effective Opus codec parameters used by the audio client.
+	CodecInfo() audio.CodecInfo
+	// Throughput returns the audio client's network throughput.
+	Throughput() audio.Throughput
+	// CodecPanics returns the number of audio frames which were skipped because the Go bindings for the Opus codec
+	// panicked. Faults inside libopus itself cannot be recovered.
+	CodecPanics() uint64
+	// Stats returns a snapshot of the audio and data clients' statistics. It is cheap enough to poll frequently.
+	Stats() ClientStats
+	// SenderStats returns a snapshot of the decode statistics of the transmissions received from the sender with the
+	// given GUID, such as packet loss and audio level. This helps tell whether bad audio is caused by this client or by
+	// a particular player's microphone or connection. Senders not heard recently are forgotten.
+	SenderStats(types.GUID) audio.SenderStats
+	// Transmit queues a transmission to send over the radio. The audio data should be in F32LE PCM format. It is safe
+	// to call from multiple goroutines; each transmission is sent in full before the next begins.
 	Transmit(audio.Audio)
+	// TransmitWithID is like Transmit, but drops the audio if a transmission with the same caller-supplied ID is
+	// already queued or being transmitted. This guards against sending the same response twice.
+	TransmitWithID(id string, audio audio.Audio)
+	// TransmitToCoalition queues a transmission to send over the radio, heard only by the given coalition. SRS only
+	// supports transmitting to the client's own coalition, so any other coalition returns
+	// [audio.ErrCoalitionOverrideUnsupported].
+	TransmitToCoalition(audio.Audio, coalitions.Coalition) error
+	// TransmitTone synthesizes the given tones, such as a beep or DTMF digits, and transmits them on only the radios
+	// tuned to the given frequency. This can signal an automated broadcast. The transmission waits for a clear channel
+	// and respects mute like any other transmission.
+	TransmitTone(unit.Frequency, []audio.Tone) error
+	// TransmitSilence queues silence of the given duration, which keys up and holds the channel without speaking, such
+	// as to reserve airtime between spoken segments of a scripted broadcast. The silence and the transmissions queued
+	// immediately before and after it are sent as a single transmission. See [audio.AudioClient.TransmitSilence].
+	TransmitSilence(time.Duration) error
+	// Speak resamples F32LE PCM audio at the given sample rate to the format used by SRS, optionally normalizes its
+	// volume, and queues it to send over the radio.
+	Speak(sample []float32, sampleRate int, normalize bool) error
+	// TransmitReader streams mono S16LE PCM audio at the given sample rate from the reader over the radio as a single
+	// transmission, until the reader returns io.EOF. This suits speech synthesizers which produce a stream of audio.
+	TransmitReader(ctx context.Context, r io.Reader, sampleRate int) error
+	// ReceiverStates returns a snapshot of the receiver state of each configured radio, for diagnostics.
+	ReceiverStates() []audio.ReceiverState
 	// IsOnFrequency checks if the named unit is on any of the client's frequencies.
 	IsOnFrequency(string) bool
+	// ResolveSender returns the peer which sent a received transmission, given the transmission's origin GUID. It
+	// returns false if the sender is not a known peer on the client's frequencies.
+	ResolveSender(types.GUID) (types.ClientInfo, bool)
+	// IsTransmitting checks if the named unit is currently transmitting on any of the client's frequencies. The unit
+	// must be a peer on the client's frequencies.
+	IsTransmitting(string) bool
 	// ClientsOnFrequency returns the number of peers on the client's frequencies.
 	ClientsOnFrequency() int
+	// TunedCounts returns the number of peers tuned to each of the client's frequencies, so that a client with several
+	// radios can report how many peers are on each.
+	TunedCounts() map[unit.Frequency]int
+	// PeersOnFrequency returns the peers on the client's frequencies, sorted by name, optionally restricted to the
+	// given coalitions.
+	PeersOnFrequency(...coalitions.Coalition) []types.ClientInfo
+	// HasAudience returns true if any peer is on the client's frequencies, meaning there is someone to hear a
+	// transmission. Spectators are included unless excluded by the client configuration.
+	HasAudience() bool
+	// LastReceived returns the time a message was most recently received from the SRS server over the data protocol.
+	LastReceived() time.Time
+	// IsStale returns true if no message has been received from the SRS server over the data protocol within the given
+	// timeout.
+	IsStale(timeout time.Duration) bool
+	// ServerVersion returns the SRS server's version, or an empty string if it has not yet been discovered.
+	ServerVersion() string
+	// DataLocalAddr returns the local address of the TCP data connection, including the ephemeral source port chosen
+	// when dialing.
+	DataLocalAddr() net.Addr
+	// AudioLocalAddr returns the local address of the UDP audio connection, including the ephemeral source port chosen
+	// when dialing. SRS voice is sensitive to NAT, so this is useful for diagnosing firewall and NAT issues.
+	AudioLocalAddr() net.Addr
+	// SetServerVersionCallback sets the callback function to be called once, when the SRS server's version is discovered.
+	SetServerVersionCallback(data.ServerVersionCallback)
+	// SetVoicePacketsCallback sets the callback function to be called with the raw voice packets of each received
+	// transmission, before they are decoded.
+	SetVoicePacketsCallback(audio.VoicePacketsCallback)
+	// SetHotMicCallback sets the callback function to be called when a single transmitter holds one of the client's
+	// frequencies for longer than the configured hot mic threshold.
+	SetHotMicCallback(audio.HotMicCallback)
+	// SetTransmissionStartedCallback sets the callback function to be called when a transmitter starts transmitting on
+	// one of the client's frequencies. Together with the ended callback, this provides a real-time activity feed
+	// without consuming the received audio.
+	SetTransmissionStartedCallback(audio.TransmissionStartedCallback)
+	// SetTransmissionEndedCallback sets the callback function to be called when a transmitter stops transmitting on
+	// one of the client's frequencies.
+	SetTransmissionEndedCallback(audio.TransmissionEndedCallback)
+	// SetAuthenticationLostCallback sets the callback function to be called when the SRS server disconnects the client
+	// from External AWACS Mode. Until the client re-authenticates, the server may not relay its transmissions.
+	SetAuthenticationLostCallback(data.AuthenticationLostCallback)
+	// SetTransmitPermissionCallback sets the callback function to be called when the SRS server's settings change
+	// whether the client may transmit. While transmission is not permitted, the client listens but does not transmit.
+	SetTransmitPermissionCallback(data.TransmitPermissionCallback)
+	// SetKickedCallback sets the callback function to be called when the SRS server kicks the client. The client stops
+	// transmitting and Run returns an error wrapping [ErrKicked]. The client does not reconnect.
+	SetKickedCallback(data.KickedCallback)
+	// SetGUIDCollisionCallback sets the callback function to be called when another client reports this client's GUID
+	// with a different name or unit ID, which can make this client invisible to its peers.
+	SetGUIDCollisionCallback(data.GUIDCollisionCallback)
+	// SetMessageCallback sets the callback function to be called with every message received from the SRS server,
+	// including message types the client otherwise ignores. This is useful for experimenting with new message types.
+	SetMessageCallback(data.MessageCallback)
+	// SetTransmittedOverCallback sets the callback function to be called when the client transmits over an incoming
+	// transmission because the clear channel timeout expired.
+	SetTransmittedOverCallback(audio.TransmittedOverCallback)
+	// SetTransmissionReceivedCallback sets the callback function to be called with the metadata of each received
+	// transmission, immediately before its audio is published to the channel returned by Receive.
+	SetTransmissionReceivedCallback(audio.TransmissionReceivedCallback)
+	// Close stops the client and closes its network connections, without needing to cancel the context passed to Run.
+	// It is safe to call more than once.
+	Close() error
+	// Shutdown stops the client gracefully. It stops accepting new transmissions, waits for queued transmissions to be
+	// sent until the context is done, sends a disconnect message to the SRS server, and then closes the client. Unlike
+	// Close, the client's final transmission is not cut off.
+	Shutdown(context.Context) error
 }
 
 // client implements the SRS Client.
@@ -37,9 +220,49 @@ type client struct {
 	dataClient data.DataClient
 	// audioClient is a client for the SRS audio protocol.
 	audioClient audio.AudioClient
+	// coalition is the coalition the client declared to the SRS server.
+	coalition coalitions.Coalition
+	// frequencyNames are the friendly names of frequencies.
+	frequencyNames map[unit.Frequency]string
+	// frequencyNamesLock protects frequencyNames.
+	frequencyNamesLock sync.RWMutex
+	// frequencyTolerance is the maximum difference between a frequency and a named frequency for the name to apply.
+	frequencyTolerance unit.Frequency
+	// transmitPermissionCallback is called when the server settings change whether the client may transmit.
+	transmitPermissionCallback atomic.Pointer[data.TransmitPermissionCallback]
+	// kickedCallback is called when the server kicks the client.
+	kickedCallback atomic.Pointer[data.KickedCallback]
+	// isRadiosHidden is true if the client's radios were hidden when it was suspended. It is protected by suspendLock.
+	isRadiosHidden bool
+	// suspendLock serializes Suspend and Resume.
+	suspendLock sync.Mutex
+	// pipe pipes received audio into an external command. It is nil if no command is configured.
+	pipe *pipe
+	// closeCh is closed when Close is called, which stops Run.
+	closeCh chan struct{}
+	// closeOnce ensures Close only takes effect once.
+	closeOnce sync.Once
 }
 
 func NewClient(config types.ClientConfiguration) (Client, error) {
+	// Resolve the address once, so that the data and audio clients connect to the same server.
+	if config.AddressProvider != nil {
+		config.Address = config.AddressProvider()
+		if config.Address == "" {
+			return nil, errors.New("SRS server address provider returned an empty address")
+		}
+		config.AddressProvider = nil
+	}
+
+	for _, radio := range config.Radios {
+		warnIfMisconfigured(radio)
+	}
+
+	pipe, err := newPipe(config.ReceivePipe, config.Radios)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive pipe configuration: %w", err)
+	}
+
 	guid := types.NewGUID()
 	dataClient, err := data.NewClient(guid, config)
 	if err != nil {
@@ -52,9 +275,17 @@ func NewClient(config types.ClientConfiguration) (Client, error) {
 	}
 
 	client := &client{
-		dataClient:  dataClient,
-		audioClient: audioClient,
+		dataClient:         dataClient,
+		audioClient:        audioClient,
+		pipe:               pipe,
+		coalition:          config.Coalition,
+		closeCh:            make(chan struct{}),
+		frequencyNames:     maps.Clone(config.FrequencyNames),
+		frequencyTolerance: config.FrequencyTolerance,
 	}
+	dataClient.SetTransmitPermissionCallback(client.updateTransmitPermission)
+	dataClient.SetKickedCallback(client.handleKick)
+	dataClient.SetGUIDRotatedCallback(client.rotateAudioGUID)
 
 	return client, nil
 }
@@ -64,16 +295,75 @@ func (c *client) Name() string {
 	return c.dataClient.Name()
 }
 
+// SetName implements [Client.SetName].
+func (c *client) SetName(name string) error {
+	if err := c.dataClient.SetName(name); err != nil {
+		return fmt.Errorf("failed to set client name: %w", err)
+	}
+	return nil
+}
+
 // Frequencies implements [Client.Frequencies].
 func (c *client) Frequencies() []unit.Frequency {
 	return c.audioClient.Frequencies()
 }
 
+// Radios implements [Client.Radios].
+func (c *client) Radios() []types.Radio {
+	return c.audioClient.Radios()
+}
+
+// AddRadio implements [Client.AddRadio].
+func (c *client) AddRadio(radio types.Radio) error {
+	// Validate before changing either client, so that a rejected radio does not leave the clients disagreeing.
+	if _, err := types.AddRadio(c.audioClient.Radios(), radio, c.tolerance()); err != nil {
+		return fmt.Errorf("failed to add radio: %w", err)
+	}
+	warnIfMisconfigured(radio)
+	if err := c.audioClient.AddRadio(radio); err != nil {
+		return fmt.Errorf("failed to add radio: %w", err)
+	}
+	if err := c.dataClient.AddRadio(radio); err != nil {
+		if errors.Is(err, data.ErrNotAnnounced) {
+			return fmt.Errorf("added radio, but %w", err)
+		}
+		if removeErr := c.audioClient.RemoveRadio(types.FrequencyFromHertz(radio.Frequency)); removeErr != nil {
+			log.Error().Err(removeErr).Msg("failed to remove radio from audio client after data client rejected it")
+		}
+		return fmt.Errorf("failed to add radio: %w", err)
+	}
+	return nil
+}
+
+// warnIfMisconfigured logs a warning if the given radio is likely misconfigured, explaining that no one will hear the
+// client on it.
+func warnIfMisconfigured(radio types.Radio) {
+	if err := types.CheckRadio(radio); err != nil {
+		log.Warn().
+			Err(err).
+			Func(types.LogFrequency(types.FrequencyFromHertz(radio.Frequency))).
+			Msg("radio is likely misconfigured; no players will be tuned to it, so no one will hear transmissions on it")
+	}
+}
+
+// RemoveRadio implements [Client.RemoveRadio].
+func (c *client) RemoveRadio(frequency unit.Frequency) error {
+	if err := c.audioClient.RemoveRadio(frequency); err != nil {
+		return fmt.Errorf("failed to remove radio: %w", err)
+	}
+	if err := c.dataClient.RemoveRadio(frequency); err != nil {
+		return fmt.Errorf("failed to remove radio: %w", err)
+	}
+	return nil
+}
+
 // Run implements [Client.Run].
 func (c *client) Run(ctx context.Context, wg *sync.WaitGroup) error {
-	errorChan := make(chan error)
+	defer c.logSummary(time.Now())
+	// errorChan has one slot for each of the data and audio clients, so that neither blocks when reporting an error
+	// after Run has returned.
+	errorChan := make(chan error, 2)
 
-	// TODO return a ready channel and wait for each. This resolves a minor race condition on startup
 	dataReadyCh := make(chan any)
 	wg.Add(1)
 	go func() {
@@ -85,15 +375,33 @@ func (c *client) Run(ctx context.Context, wg *sync.WaitGroup) error {
 	}()
 	<-dataReadyCh
 
+	// Defer transmissions until peers have been synced, so that the client does not transmit to no one.
+	go func() {
+		select {
+		case <-c.dataClient.Synced():
+			c.audioClient.ReleaseTransmissions()
+		case <-ctx.Done():
+		}
+	}()
+
+	audioReadyCh := make(chan any)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		log.Info().Msg("running SRS audio client")
-		if err := c.audioClient.Run(ctx, wg); err != nil {
+		if err := c.audioClient.Run(ctx, wg, audioReadyCh); err != nil {
 			errorChan <- err
 		}
 	}()
 
+	if c.pipe != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.runPipe(ctx)
+		}()
+	}
+
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 	for {
@@ -103,8 +411,15 @@ func (c *client) Run(ctx context.Context, wg *sync.WaitGroup) error {
 			return fmt.Errorf("stopping client due to context cancelation: %w", ctx.Err())
 		case err := <-errorChan:
 			return fmt.Errorf("client error: %w", err)
+		case <-c.closeCh:
+			log.Info().Msg("stopping SRS client because it was closed")
+			return nil
+		case <-audioReadyCh:
+			log.Info().Msg("SRS client ready")
+			// A nil channel blocks forever, so this case only runs once.
+			audioReadyCh = nil
 		case <-ticker.C:
-			if time.Since(c.audioClient.LastPing()) > 1*time.Minute {
+			if time.Since(c.audioClient.LastPing()) > pingTimeout {
 				log.Warn().Msg("stopped receiving pings from SRS data client")
 				return errors.New("stopped receiving pings from SRS data client")
 			}
@@ -117,17 +432,330 @@ func (c *client) Receive() <-chan audio.Audio {
 	return c.audioClient.Receive()
 }
 
+// ReceivePCM implements [Client.ReceivePCM].
+func (c *client) ReceivePCM() <-chan []byte {
+	return c.audioClient.ReceivePCM()
+}
+
+// SetMutedOn implements [Client.SetMutedOn].
+func (c *client) SetMutedOn(frequency unit.Frequency, muted bool) error {
+	if err := c.audioClient.SetMutedOn(frequency, muted); err != nil {
+		return fmt.Errorf("failed to set mute: %w", err)
+	}
+	return nil
+}
+
+// ReconnectAudio implements [Client.ReconnectAudio].
+func (c *client) ReconnectAudio(ctx context.Context) error {
+	if err := c.audioClient.Reconnect(ctx); err != nil {
+		return fmt.Errorf("failed to reconnect audio client: %w", err)
+	}
+	return nil
+}
+
+// Suspend implements [Client.Suspend].
+func (c *client) Suspend(hideRadios bool) error {
+	c.suspendLock.Lock()
+	defer c.suspendLock.Unlock()
+	c.audioClient.Suspend()
+	if !hideRadios {
+		return nil
+	}
+	c.isRadiosHidden = true
+	if err := c.dataClient.SetRadiosHidden(true); err != nil {
+		return fmt.Errorf("failed to hide radios: %w", err)
+	}
+	return nil
+}
+
+// Resume implements [Client.Resume].
+func (c *client) Resume() error {
+	c.suspendLock.Lock()
+	defer c.suspendLock.Unlock()
+	c.audioClient.Resume()
+	if !c.isRadiosHidden {
+		return nil
+	}
+	c.isRadiosHidden = false
+	if err := c.dataClient.SetRadiosHidden(false); err != nil {
+		return fmt.Errorf("failed to show radios: %w", err)
+	}
+	return nil
+}
+
+// SetReceiveEnabled implements [Client.SetReceiveEnabled].
+func (c *client) SetReceiveEnabled(frequency unit.Frequency, isEnabled bool) error {
+	if err := c.audioClient.SetReceiveEnabled(frequency, isEnabled); err != nil {
+		return fmt.Errorf("failed to set receive enabled: %w", err)
+	}
+	return nil
+}
+
+// SetIdleFill implements [Client.SetIdleFill].
+func (c *client) SetIdleFill(frequency unit.Frequency, fill audio.IdleFill) error {
+	if err := c.audioClient.SetIdleFill(frequency, fill); err != nil {
+		return fmt.Errorf("failed to set idle fill: %w", err)
+	}
+	return nil
+}
+
+// CodecInfo implements [Client.CodecInfo].
+func (c *client) CodecInfo() audio.CodecInfo {
+	return c.audioClient.CodecInfo()
+}
+
+// Throughput implements [Client.Throughput].
+func (c *client) Throughput() audio.Throughput {
+	return c.audioClient.Throughput()
+}
+
+// CodecPanics implements [Client.CodecPanics].
+func (c *client) CodecPanics() uint64 {
+	return c.audioClient.CodecPanics()
+}
+
+// ReceiveReader implements [Client.ReceiveReader].
+func (c *client) ReceiveReader(frequency unit.Frequency) io.ReadCloser {
+	return c.audioClient.ReceiveReader(frequency)
+}
+
+// CaptureTransmissions implements [Client.CaptureTransmissions].
+func (c *client) CaptureTransmissions() <-chan audio.Audio {
+	return c.audioClient.CaptureTransmissions()
+}
+
 // Transmit implements [Client.Transmit].
 func (c *client) Transmit(sample audio.Audio) {
 	c.audioClient.Transmit(sample)
 }
 
+// TransmitWithID implements [Client.TransmitWithID].
+func (c *client) TransmitWithID(id string, sample audio.Audio) {
+	c.audioClient.TransmitWithID(id, sample)
+}
+
+// TransmitToCoalition implements [Client.TransmitToCoalition].
+func (c *client) TransmitToCoalition(sample audio.Audio, coalition coalitions.Coalition) error {
+	if err := c.audioClient.TransmitToCoalition(sample, coalition); err != nil {
+		return fmt.Errorf("failed to transmit: %w", err)
+	}
+	return nil
+}
+
+// TransmitTone implements [Client.TransmitTone].
+func (c *client) TransmitTone(frequency unit.Frequency, tones []audio.Tone) error {
+	if err := c.audioClient.TransmitTone(frequency, tones); err != nil {
+		return fmt.Errorf("failed to transmit tones: %w", err)
+	}
+	return nil
+}
+
+// TransmitSilence implements [Client.TransmitSilence].
+func (c *client) TransmitSilence(d time.Duration) error {
+	if err := c.audioClient.TransmitSilence(d); err != nil {
+		return fmt.Errorf("failed to transmit silence: %w", err)
+	}
+	return nil
+}
+
+// TransmitReader implements [Client.TransmitReader].
+func (c *client) TransmitReader(ctx context.Context, r io.Reader, sampleRate int) error {
+	if err := c.audioClient.TransmitReader(ctx, r, sampleRate); err != nil {
+		return fmt.Errorf("failed to transmit audio stream: %w", err)
+	}
+	return nil
+}
+
+// Speak implements [Client.Speak].
+func (c *client) Speak(sample []float32, sampleRate int, normalize bool) error {
+	if err := c.audioClient.Speak(sample, sampleRate, normalize); err != nil {
+		return fmt.Errorf("failed to speak: %w", err)
+	}
+	return nil
+}
+
+// ReceiverStates implements [Client.ReceiverStates].
+func (c *client) ReceiverStates() []audio.ReceiverState {
+	return c.audioClient.ReceiverStates()
+}
+
 // IsOnFrequency implements [Client.IsOnFrequency].
 func (c *client) IsOnFrequency(name string) bool {
 	return c.dataClient.IsOnFrequency(name)
 }
 
+// ResolveSender implements [Client.ResolveSender].
+func (c *client) ResolveSender(origin types.GUID) (types.ClientInfo, bool) {
+	return c.dataClient.Peer(origin)
+}
+
+// IsTransmitting implements [Client.IsTransmitting]. It cross-references the peers known to the data client with the
+// transmissions being received by the audio client.
+func (c *client) IsTransmitting(name string) bool {
+	guids := c.dataClient.PeerGUIDs(name)
+	if len(guids) == 0 {
+		return false
+	}
+	for _, state := range c.audioClient.ReceiverStates() {
+		if state.IsReceiving && slices.Contains(guids, state.Origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// TunedCounts implements [Client.TunedCounts].
+func (c *client) TunedCounts() map[unit.Frequency]int {
+	return c.dataClient.TunedCounts()
+}
+
 // ClientsOnFrequency implements [Client.ClientsOnFrequency].
 func (c *client) ClientsOnFrequency() int {
 	return c.dataClient.ClientsOnFrequency()
 }
+
+// PeersOnFrequency implements [Client.PeersOnFrequency].
+func (c *client) PeersOnFrequency(filter ...coalitions.Coalition) []types.ClientInfo {
+	return c.dataClient.PeersOnFrequency(filter...)
+}
+
+// HasAudience implements [Client.HasAudience].
+func (c *client) HasAudience() bool {
+	return c.dataClient.HasAudience()
+}
+
+// LastReceived implements [Client.LastReceived].
+func (c *client) LastReceived() time.Time {
+	return c.dataClient.LastReceived()
+}
+
+// IsStale implements [Client.IsStale].
+func (c *client) IsStale(timeout time.Duration) bool {
+	return c.dataClient.IsStale(timeout)
+}
+
+// ServerVersion implements [Client.ServerVersion].
+func (c *client) ServerVersion() string {
+	return c.dataClient.ServerVersion()
+}
+
+// DataLocalAddr implements [Client.DataLocalAddr].
+func (c *client) DataLocalAddr() net.Addr {
+	return c.dataClient.LocalAddr()
+}
+
+// AudioLocalAddr implements [Client.AudioLocalAddr].
+func (c *client) AudioLocalAddr() net.Addr {
+	return c.audioClient.LocalAddr()
+}
+
+// SetServerVersionCallback implements [Client.SetServerVersionCallback].
+func (c *client) SetServerVersionCallback(callback data.ServerVersionCallback) {
+	c.dataClient.SetServerVersionCallback(callback)
+}
+
+// SetVoicePacketsCallback implements [Client.SetVoicePacketsCallback].
+func (c *client) SetVoicePacketsCallback(callback audio.VoicePacketsCallback) {
+	c.audioClient.SetVoicePacketsCallback(callback)
+}
+
+// SetHotMicCallback implements [Client.SetHotMicCallback].
+func (c *client) SetHotMicCallback(callback audio.HotMicCallback) {
+	c.audioClient.SetHotMicCallback(callback)
+}
+
+// SetTransmissionStartedCallback implements [Client.SetTransmissionStartedCallback].
+func (c *client) SetTransmissionStartedCallback(callback audio.TransmissionStartedCallback) {
+	c.audioClient.SetTransmissionStartedCallback(callback)
+}
+
+// SetTransmissionEndedCallback implements [Client.SetTransmissionEndedCallback].
+func (c *client) SetTransmissionEndedCallback(callback audio.TransmissionEndedCallback) {
+	c.audioClient.SetTransmissionEndedCallback(callback)
+}
+
+// SetGUIDCollisionCallback implements [Client.SetGUIDCollisionCallback].
+func (c *client) SetGUIDCollisionCallback(callback data.GUIDCollisionCallback) {
+	c.dataClient.SetGUIDCollisionCallback(callback)
+}
+
+// SetMessageCallback implements [Client.SetMessageCallback].
+func (c *client) SetMessageCallback(callback data.MessageCallback) {
+	c.dataClient.SetMessageCallback(callback)
+}
+
+// SetTransmitPermissionCallback implements [Client.SetTransmitPermissionCallback].
+func (c *client) SetTransmitPermissionCallback(callback data.TransmitPermissionCallback) {
+	c.transmitPermissionCallback.Store(&callback)
+}
+
+// updateTransmitPermission mutes or unmutes the audio client when the server settings change whether the client may
+// transmit, then calls the transmit permission callback.
+func (c *client) updateTransmitPermission(isPermitted bool) {
+	c.audioClient.SetTransmitPermitted(isPermitted)
+	if callback := callbacks.Load(&c.transmitPermissionCallback); callback != nil {
+		callback(isPermitted)
+	}
+}
+
+// SetKickedCallback implements [Client.SetKickedCallback].
+func (c *client) SetKickedCallback(callback data.KickedCallback) {
+	c.kickedCallback.Store(&callback)
+}
+
+// handleKick stops the audio client from transmitting when the server kicks the client, then calls the kicked
+// callback.
+func (c *client) handleKick() {
+	c.audioClient.SetTransmitPermitted(false)
+	if callback := callbacks.Load(&c.kickedCallback); callback != nil {
+		callback()
+	}
+}
+
+// rotateAudioGUID switches the audio client to the new GUID the data client reconnected with, then reconnects the audio
+// client so that the server associates the new GUID with its UDP connection.
+func (c *client) rotateAudioGUID(guid types.GUID) {
+	c.audioClient.SetGUID(guid)
+	if err := c.audioClient.Reconnect(context.Background()); err != nil {
+		log.Error().Err(err).Msg("failed to reconnect SRS audio client with new GUID")
+	}
+}
+
+// SetAuthenticationLostCallback implements [Client.SetAuthenticationLostCallback].
+func (c *client) SetAuthenticationLostCallback(callback data.AuthenticationLostCallback) {
+	c.dataClient.SetAuthenticationLostCallback(callback)
+}
+
+// SetTransmittedOverCallback implements [Client.SetTransmittedOverCallback].
+func (c *client) SetTransmittedOverCallback(callback audio.TransmittedOverCallback) {
+	c.audioClient.SetTransmittedOverCallback(callback)
+}
+
+// SetTransmissionReceivedCallback implements [Client.SetTransmissionReceivedCallback].
+func (c *client) SetTransmissionReceivedCallback(callback audio.TransmissionReceivedCallback) {
+	c.audioClient.SetTransmissionReceivedCallback(callback)
+}
+
+// Shutdown implements [Client.Shutdown].
+func (c *client) Shutdown(ctx context.Context) error {
+	var err error
+	c.closeOnce.Do(func() {
+		audioErr := c.audioClient.Shutdown(ctx)
+		if audioErr != nil {
+			audioErr = fmt.Errorf("failed to shut down audio client: %w", audioErr)
+		}
+		err = errors.Join(audioErr, c.dataClient.Disconnect())
+		close(c.closeCh)
+	})
+	return err
+}
+
+// Close implements [Client.Close].
+func (c *client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+		err = errors.Join(c.dataClient.Close(), c.audioClient.Close())
+	})
+	return err
+}
@@ -0,0 +1,338 @@
+package simpleradio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/audio"
+	"github.com/dharmab/skyeye/pkg/simpleradio/data"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDialer records the addresses it dials, and connects to nothing over an in-memory pipe.
+type recordingDialer struct {
+	lock      sync.Mutex
+	addresses map[string]string
+}
+
+func (d *recordingDialer) DialContext(_ context.Context, network, address string) (net.Conn, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.addresses[network] = address
+	conn, _ := net.Pipe()
+	return conn, nil
+}
+
+func TestAddressProvider(t *testing.T) {
+	t.Parallel()
+	address := "srs.example.com:5002"
+	calls := 0
+	dialer := &recordingDialer{addresses: make(map[string]string)}
+	client, err := NewClient(types.ClientConfiguration{
+		Address: "localhost:5002",
+		AddressProvider: func() string {
+			calls++
+			return address
+		},
+		TCPDialer: dialer,
+		UDPDialer: dialer,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, map[string]string{"tcp": address, "udp": address}, dialer.addresses)
+
+	_, err = NewClient(types.ClientConfiguration{
+		AddressProvider: func() string { return "" },
+		TCPDialer:       dialer,
+		UDPDialer:       dialer,
+	})
+	require.Error(t, err)
+}
+
+// fakeDataClient is a data client with a fixed set of peers. Methods other than Peer and PeerGUIDs are not
+// implemented.
+type fakeDataClient struct {
+	data.DataClient
+	peers          map[string][]types.GUID
+	isRadiosHidden bool
+	addRadioErr    error
+}
+
+func (c *fakeDataClient) AddRadio(types.Radio) error {
+	return c.addRadioErr
+}
+
+func (c *fakeDataClient) SetRadiosHidden(isHidden bool) error {
+	c.isRadiosHidden = isHidden
+	return nil
+}
+
+func (c *fakeDataClient) Peer(guid types.GUID) (types.ClientInfo, bool) {
+	for name, guids := range c.peers {
+		if slices.Contains(guids, guid) {
+			return types.ClientInfo{GUID: guid, Name: name}, true
+		}
+	}
+	return types.ClientInfo{}, false
+}
+
+func (c *fakeDataClient) PeerGUIDs(name string) []types.GUID {
+	return c.peers[name]
+}
+
+// fakeAudioClient is an audio client with fixed receiver states. Methods other than those below are not implemented.
+type fakeAudioClient struct {
+	audio.AudioClient
+	states      []audio.ReceiverState
+	radios      []types.Radio
+	addCalls    int
+	isPermitted bool
+	isSuspended bool
+}
+
+func (c *fakeAudioClient) Radios() []types.Radio {
+	return c.radios
+}
+
+func (c *fakeAudioClient) AddRadio(radio types.Radio) error {
+	c.addCalls++
+	radios, err := types.AddRadio(c.radios, radio, types.DefaultFrequencyTolerance)
+	if err != nil {
+		return err
+	}
+	c.radios = radios
+	return nil
+}
+
+func (c *fakeAudioClient) RemoveRadio(frequency unit.Frequency) error {
+	radios, _, err := types.RemoveRadio(c.radios, frequency, types.DefaultFrequencyTolerance)
+	if err != nil {
+		return err
+	}
+	c.radios = radios
+	return nil
+}
+
+func (c *fakeAudioClient) Suspend() {
+	c.isSuspended = true
+}
+
+func (c *fakeAudioClient) Resume() {
+	c.isSuspended = false
+}
+
+func (c *fakeAudioClient) SetTransmitPermitted(isPermitted bool) {
+	c.isPermitted = isPermitted
+}
+
+func (c *fakeAudioClient) ReceiverStates() []audio.ReceiverState {
+	return c.states
+}
+
+func TestIsTransmitting(t *testing.T) {
+	t.Parallel()
+	pilot, wso, other := types.NewGUID(), types.NewGUID(), types.NewGUID()
+	c := &client{
+		dataClient: &fakeDataClient{peers: map[string][]types.GUID{
+			"Eagle 1-1":  {pilot, wso},
+			"Viper 1-1":  {types.NewGUID()},
+			"Hornet 1-1": {other},
+		}},
+		audioClient: &fakeAudioClient{states: []audio.ReceiverState{
+			{IsReceiving: true, Origin: wso},
+			{IsReceiving: false},
+		}},
+	}
+	assert.True(t, c.IsTransmitting("Eagle 1-1"))
+	assert.False(t, c.IsTransmitting("Viper 1-1"))
+	assert.False(t, c.IsTransmitting("Hornet 1-1"))
+	assert.False(t, c.IsTransmitting("Tomcat 1-1"))
+}
+
+func TestResolveSender(t *testing.T) {
+	t.Parallel()
+	guid := types.NewGUID()
+	c := &client{dataClient: &fakeDataClient{peers: map[string][]types.GUID{"Eagle 1-1": {guid}}}}
+	sender, ok := c.ResolveSender(guid)
+	require.True(t, ok)
+	assert.Equal(t, "Eagle 1-1", sender.Name)
+	_, ok = c.ResolveSender(types.NewGUID())
+	assert.False(t, ok)
+}
+
+func TestUpdateTransmitPermission(t *testing.T) {
+	t.Parallel()
+	audioClient := &fakeAudioClient{isPermitted: true}
+	c := &client{audioClient: audioClient}
+	var events []bool
+	c.SetTransmitPermissionCallback(func(isPermitted bool) {
+		events = append(events, isPermitted)
+	})
+	c.updateTransmitPermission(false)
+	assert.False(t, audioClient.isPermitted)
+	c.updateTransmitPermission(true)
+	assert.True(t, audioClient.isPermitted)
+	assert.Equal(t, []bool{false, true}, events)
+}
+
+func TestAddRadio(t *testing.T) {
+	t.Parallel()
+	working := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	added := types.Radio{Frequency: 133000000, Modulation: types.ModulationAM}
+
+	t.Run("added", func(t *testing.T) {
+		t.Parallel()
+		audioClient := &fakeAudioClient{radios: []types.Radio{working}}
+		c := &client{dataClient: &fakeDataClient{}, audioClient: audioClient}
+		require.NoError(t, c.AddRadio(added))
+		assert.Equal(t, []types.Radio{working, added}, audioClient.radios)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		audioClient := &fakeAudioClient{radios: []types.Radio{working}}
+		c := &client{dataClient: &fakeDataClient{}, audioClient: audioClient}
+		require.Error(t, c.AddRadio(types.Radio{Frequency: 251000200, Modulation: types.ModulationAM}))
+		assert.Zero(t, audioClient.addCalls, "an invalid radio should be rejected before either client is changed")
+	})
+
+	t.Run("rejected by data client", func(t *testing.T) {
+		t.Parallel()
+		audioClient := &fakeAudioClient{radios: []types.Radio{working}}
+		c := &client{dataClient: &fakeDataClient{addRadioErr: errors.New("rejected")}, audioClient: audioClient}
+		err := c.AddRadio(added)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, data.ErrNotAnnounced)
+		assert.Equal(t, []types.Radio{working}, audioClient.radios, "the radio should be removed from the audio client")
+	})
+
+	t.Run("not announced", func(t *testing.T) {
+		t.Parallel()
+		audioClient := &fakeAudioClient{radios: []types.Radio{working}}
+		dataClient := &fakeDataClient{addRadioErr: fmt.Errorf("failed to announce added radio: %w", data.ErrNotAnnounced)}
+		c := &client{dataClient: dataClient, audioClient: audioClient}
+		err := c.AddRadio(added)
+		require.ErrorIs(t, err, data.ErrNotAnnounced)
+		assert.Contains(t, err.Error(), "added radio, but")
+		assert.Equal(t, []types.Radio{working, added}, audioClient.radios, "the radio should still be added")
+	})
+}
+
+func TestSuspend(t *testing.T) {
+	t.Parallel()
+	dataClient := &fakeDataClient{}
+	audioClient := &fakeAudioClient{}
+	c := &client{dataClient: dataClient, audioClient: audioClient}
+
+	require.NoError(t, c.Suspend(false))
+	assert.True(t, audioClient.isSuspended)
+	assert.False(t, dataClient.isRadiosHidden)
+	require.NoError(t, c.Resume())
+	assert.False(t, audioClient.isSuspended)
+
+	require.NoError(t, c.Suspend(true))
+	assert.True(t, audioClient.isSuspended)
+	assert.True(t, dataClient.isRadiosHidden)
+	require.NoError(t, c.Resume())
+	assert.False(t, audioClient.isSuspended)
+	assert.False(t, dataClient.isRadiosHidden)
+}
+
+func TestFrequencyName(t *testing.T) {
+	t.Parallel()
+	c := &client{
+		frequencyNames: map[unit.Frequency]string{
+			251 * unit.Megahertz: "Strike",
+			243 * unit.Megahertz: "Guard",
+		},
+	}
+	assert.Equal(t, "Strike", c.FrequencyName(251*unit.Megahertz))
+	assert.Equal(t, "Guard", c.FrequencyName(243*unit.Megahertz+200*unit.Hertz))
+	assert.Equal(t, "305.75", c.FrequencyName(305.75*unit.Megahertz))
+
+	c.SetFrequencyName(305.75*unit.Megahertz, "CAP")
+	assert.Equal(t, "CAP", c.FrequencyName(305.75*unit.Megahertz))
+	c.SetFrequencyName(251*unit.Megahertz, "")
+	assert.Equal(t, "251.0", c.FrequencyName(251*unit.Megahertz))
+
+	var empty client
+	assert.Equal(t, "133.0", empty.FrequencyName(133*unit.Megahertz))
+	empty.SetFrequencyName(133*unit.Megahertz, "Tower")
+	assert.Equal(t, "Tower", empty.FrequencyName(133*unit.Megahertz))
+}
+
+// failingDataClient is a data client which becomes ready immediately, and fails once released. Methods other than
+// Run, Synced and Stats are not implemented.
+type failingDataClient struct {
+	data.DataClient
+	release <-chan struct{}
+}
+
+func (c *failingDataClient) Run(_ context.Context, _ *sync.WaitGroup, readyCh chan<- any) error {
+	close(readyCh)
+	<-c.release
+	return errors.New("data client failed")
+}
+
+func (c *failingDataClient) Synced() <-chan struct{} {
+	return nil
+}
+
+func (c *failingDataClient) Stats() data.ClientStats {
+	return data.ClientStats{}
+}
+
+// failingAudioClient is an audio client which becomes ready immediately, and fails once released. Methods other than
+// Run and Stats are not implemented.
+type failingAudioClient struct {
+	audio.AudioClient
+	release <-chan struct{}
+}
+
+func (c *failingAudioClient) Run(_ context.Context, _ *sync.WaitGroup, readyCh chan<- any) error {
+	close(readyCh)
+	<-c.release
+	return errors.New("audio client failed")
+}
+
+func (c *failingAudioClient) Stats() audio.ClientStats {
+	return audio.ClientStats{}
+}
+
+func TestRunDoesNotLeakAfterClose(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	c := &client{
+		dataClient:  &failingDataClient{release: release},
+		audioClient: &failingAudioClient{release: release},
+		closeCh:     make(chan struct{}),
+	}
+	close(c.closeCh)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	require.NoError(t, c.Run(ctx, &wg))
+
+	// Both clients fail after Run has returned, so nothing receives their errors.
+	close(release)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "client goroutines should not block after Run returns")
+	}
+}
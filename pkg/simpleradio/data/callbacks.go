@@ -0,0 +1,72 @@
+package data
+
+import "github.com/dharmab/skyeye/pkg/simpleradio/types"
+
+// ServerVersionCallback is a callback function that is called once, when the SRS server's version is first discovered.
+type ServerVersionCallback func(version string)
+
+// SetServerVersionCallback implements [DataClient.SetServerVersionCallback].
+func (c *dataClient) SetServerVersionCallback(callback ServerVersionCallback) {
+	c.serverVersionCallback.Store(&callback)
+}
+
+// AuthenticationLostCallback is a callback function that is called when the SRS server disconnects the client from
+// External AWACS Mode, for example because the passwords were changed. The client does not re-authenticate
+// automatically in this case, since the same password would be rejected again.
+type AuthenticationLostCallback func()
+
+// SetAuthenticationLostCallback implements [DataClient.SetAuthenticationLostCallback].
+func (c *dataClient) SetAuthenticationLostCallback(callback AuthenticationLostCallback) {
+	c.authenticationLostCallback.Store(&callback)
+}
+
+// TransmitPermissionCallback is a callback function that is called when the SRS server's settings change whether the
+// client may transmit. Transmission is assumed to be permitted until the server's settings forbid it.
+type TransmitPermissionCallback func(isPermitted bool)
+
+// SetTransmitPermissionCallback implements [DataClient.SetTransmitPermissionCallback].
+func (c *dataClient) SetTransmitPermissionCallback(callback TransmitPermissionCallback) {
+	c.transmitPermissionCallback.Store(&callback)
+}
+
+// KickedCallback is a callback function that is called when the SRS server explicitly kicks the client, for example
+// because an administrator kicked or banned it. After the callback returns, Run returns an error wrapping [ErrKicked] and the
+// client sends no further messages. The client does not reconnect.
+type KickedCallback func()
+
+// SetKickedCallback implements [DataClient.SetKickedCallback].
+func (c *dataClient) SetKickedCallback(callback KickedCallback) {
+	c.kickedCallback.Store(&callback)
+}
+
+// GUIDCollisionCallback is a callback function that is called when another client reports this client's GUID with a
+// different name or unit ID. It is called once for each colliding client. If
+// [types.ClientConfiguration.RotateGUIDOnCollision] is set, the client then reconnects with a new GUID; otherwise, a
+// caller may respond by closing the client and creating a new one, which is assigned a new GUID.
+type GUIDCollisionCallback func(other types.ClientInfo)
+
+// SetGUIDCollisionCallback implements [DataClient.SetGUIDCollisionCallback].
+func (c *dataClient) SetGUIDCollisionCallback(callback GUIDCollisionCallback) {
+	c.guidCollisionCallback.Store(&callback)
+}
+
+// GUIDRotatedCallback is a callback function that is called with the client's new GUID after it has reconnected to
+// the SRS server with that GUID to escape a GUID collision. Anything else which identifies itself to the server with
+// the client's GUID, such as the audio client, should switch to the new GUID.
+type GUIDRotatedCallback func(guid types.GUID)
+
+// SetGUIDRotatedCallback implements [DataClient.SetGUIDRotatedCallback].
+func (c *dataClient) SetGUIDRotatedCallback(callback GUIDRotatedCallback) {
+	c.guidRotatedCallback.Store(&callback)
+}
+
+// MessageCallback is a callback function that is called with every message received from the SRS server, after the
+// client has handled it, including message types the client otherwise ignores. It is called from a dedicated
+// goroutine, so a slow callback does not delay the client; if the callback falls behind, messages are dropped. The
+// callback must not modify the message.
+type MessageCallback func(types.Message)
+
+// SetMessageCallback implements [DataClient.SetMessageCallback].
+func (c *dataClient) SetMessageCallback(callback MessageCallback) {
+	c.messageCallback.Store(&callback)
+}
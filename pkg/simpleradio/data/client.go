@@ -4,18 +4,25 @@ package data
 // https://gitlab.com/overlordbot/srs-bot/-/blob/master/OverlordBot.SimpleRadio/Network/DataClient.cs
 
 import (
-	"bufio"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/dharmab/skyeye/pkg/simpleradio/internal/callbacks"
 	"github.com/dharmab/skyeye/pkg/simpleradio/types"
 	"github.com/martinlindhe/unit"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -23,44 +30,297 @@ import (
 type DataClient interface {
 	// Name returns the name of the client as it appears in the SRS client list and in in-game transmissions.
 	Name() string
+	// SetName changes the name of the client and sends an update to the SRS server so that the new name appears in
+	// the SRS client list.
+	SetName(string) error
+	// AddRadio adds a radio and sends a radio update to the SRS server, followed by a sync to discover the peers on the
+	// new radio's frequency. The existing radios are not disturbed. An error is returned if the client already has the
+	// maximum number of radios or a radio on the same frequency. The radio is still added if the update or sync cannot
+	// be sent, in which case the error wraps [ErrNotAnnounced].
+	AddRadio(types.Radio) error
+	// RemoveRadio removes the radios tuned to the given frequency, forgets the peers which no longer share a frequency
+	// with the client, and sends a radio update to the SRS server. An error is returned if no radio is tuned to the
+	// frequency. The radio is still removed if the update cannot be sent, in which case the error wraps
+	// [ErrNotAnnounced].
+	RemoveRadio(unit.Frequency) error
+	// SetRadiosHidden sets whether the client hides its radios from the SRS server, and sends a radio update to the
+	// server. While hidden, the client announces its radios as switched off, so players see it is not on any frequency
+	// and the server does not relay audio to it. The client still tracks the peers on its configured radios.
+	SetRadiosHidden(bool) error
 	// Run starts the SRS data client. It should be called exactly once. The given channel will be closed when the client is ready.
 	Run(context.Context, *sync.WaitGroup, chan<- any) error
-	// Send sends a message to the SRS server.
+	// Send sends a message to the SRS server. If the message cannot be written within the configured write timeout, an
+	// error wrapping [ErrSendTimeout] is returned. The client does not reconnect, so messages are never queued; once
+	// the connection is closed, Send returns an error wrapping [ErrNotConnected].
 	Send(types.Message) error
 	// IsOnFrequency checks if the named unit is on the client's frequency.
 	IsOnFrequency(string) bool
 	// ClientsOnFrequency returns the number of peers on this client's frequency.
 	ClientsOnFrequency() int
+	// TunedCounts returns the number of peers tuned to each of this client's frequencies, like the tuned count SRS
+	// displays for each radio. Every frequency is included, even if no peers are tuned to it. A peer tuned to several
+	// of the client's frequencies is counted once for each.
+	TunedCounts() map[unit.Frequency]int
+	// Peer returns the peer on this client's frequency with the given GUID, if it is known.
+	Peer(types.GUID) (types.ClientInfo, bool)
+	// PeerGUIDs returns the GUIDs of the peers on this client's frequency with the given name. Several clients may share
+	// a name, such as the crew of a multicrew aircraft.
+	PeerGUIDs(string) []types.GUID
+	// PeersOnFrequency returns the peers on this client's frequency, sorted by name. If any coalitions are given, only
+	// peers in those coalitions are returned. Unless the client is configured to listen to all coalitions, peers are
+	// only tracked in the client's own coalition and spectators.
+	PeersOnFrequency(...coalitions.Coalition) []types.ClientInfo
+	// HasAudience returns true if any peer is on this client's frequency. Spectators are included unless excluded by
+	// the client configuration.
+	HasAudience() bool
+	// LastReceived returns the time a message was most recently received from the SRS server. Before any message is
+	// received, it returns the time the client was created.
+	LastReceived() time.Time
+	// IsStale returns true if no message has been received from the SRS server within the given timeout. A connection
+	// may be stale even though it is still open, if the server or network has silently stopped delivering data.
+	IsStale(timeout time.Duration) bool
+	// ServerVersion returns the SRS server's version, or an empty string if it has not yet been discovered.
+	ServerVersion() string
+	// LocalAddr returns the local address of the TCP connection to the SRS server, including the ephemeral source port
+	// chosen when dialing.
+	LocalAddr() net.Addr
+	// Stats returns a snapshot of the client's message counters and peers. It briefly takes the same read lock as
+	// ClientsOnFrequency, so it is cheap enough to poll frequently.
+	Stats() ClientStats
+	// Synced returns a channel which is closed once the client has completed its first sync with the SRS server, after
+	// which the peers on the client's frequencies are known.
+	Synced() <-chan struct{}
+	// SetServerVersionCallback sets the callback function to be called when the SRS server's version is discovered.
+	SetServerVersionCallback(ServerVersionCallback)
+	// SetAuthenticationLostCallback sets the callback function to be called when the server disconnects the client from External AWACS Mode.
+	SetAuthenticationLostCallback(AuthenticationLostCallback)
+	// SetGUIDCollisionCallback sets the callback function to be called when another client reports this client's GUID.
+	SetGUIDCollisionCallback(GUIDCollisionCallback)
+	// SetMessageCallback sets the callback function to be called with every message received from the SRS server.
+	SetMessageCallback(MessageCallback)
+	// SetTransmitPermissionCallback sets the callback function to be called when the server's settings change whether the client may transmit.
+	SetTransmitPermissionCallback(TransmitPermissionCallback)
+	// SetKickedCallback sets the callback function to be called when the server kicks the client.
+	SetKickedCallback(KickedCallback)
+	// SetGUIDRotatedCallback sets the callback function to be called when the client rotates its GUID to escape a GUID collision.
+	SetGUIDRotatedCallback(GUIDRotatedCallback)
+	// Disconnect sends a disconnect message to the SRS server, so that the client is removed from the server's client
+	// list immediately, then closes the client. The client is closed even if the message cannot be sent.
+	Disconnect() error
+	// Close stops the client and closes its TCP connection, without needing to cancel the context passed to Run. It is safe to call more than once.
+	Close() error
 }
 
+// defaultWriteTimeout is the default maximum time to wait for a message to be written to the SRS server.
+const defaultWriteTimeout = 10 * time.Second
+
+// ErrSendTimeout is returned when a message cannot be written to the SRS server within the write timeout. This usually
+// means the server has stalled or the network is congested.
+var ErrSendTimeout = errors.New("timed out sending message to SRS server")
+
+// ErrNotConnected is returned when a message is sent after the connection to the SRS server has been closed.
+var ErrNotConnected = errors.New("not connected to SRS server")
+
+// ErrKicked is returned by Run when the SRS server explicitly disconnects the client, for example because an
+// administrator kicked or banned it, and by Send after that happens. Reconnecting with the same identity is likely to be
+// rejected again, so a caller should not retry automatically when Run returns an error wrapping ErrKicked.
+var ErrKicked = errors.New("kicked by SRS server")
+
+// ErrPossiblyKicked is wrapped by the error returned from Run when the SRS server closes the connection without a
+// message while still accepting new connections. SRS closes the connection this way when it kicks or bans a client,
+// but a transient network failure or a quick server restart looks the same, so the client is not treated as kicked. A
+// caller should usually retry, and may choose to stop retrying if the connection keeps being closed this way.
+var ErrPossiblyKicked = errors.New("connection closed by SRS server, possibly because the client was kicked")
+
+// ErrNotAnnounced is wrapped by the error returned when a change to the client's radios was made locally but could not
+// be announced to the SRS server. The change is kept, and is announced with the next radio update.
+var ErrNotAnnounced = errors.New("radio change was not announced to SRS server")
+
+// messageBufferSize is the number of received messages which may be buffered while earlier messages are handled.
+// Busy servers send a burst of update messages when many players join or retune at once, such as at mission start.
+// Handling a message usually takes microseconds, but stalls while the handler sends a message to the server, such as
+// when re-authenticating. A buffer of this size absorbs a burst of one update per player for a few hundred players
+// during such a stall, so that the reader is not blocked and the TCP receive buffer does not fill. See
+// BenchmarkMessageBuffer.
+const messageBufferSize = 256
+
 type dataClient struct {
-	// connection is the TCP connection to the SRS server.
-	connection *net.TCPConn
+	// connection is the TCP connection to the SRS server. It is replaced when the client rotates its GUID.
+	connection types.DataTransport
+	// connectionLock protects connection.
+	connectionLock sync.RWMutex
+	// openTransport, network and address are used to reconnect when rotating the GUID, and to check whether the SRS
+	// server still accepts connections after it closes this client's connection.
+	openTransport types.DataTransportFactory
+	network       string
+	address       string
 	// clientInfo is the client information for this client. It is what players will see in the SRS client list, and the in-game overlay when this client transmits.
 	clientInfo types.ClientInfo
+	// clientInfoLock protects clientInfo.Name and clientInfo.RadioInfo.Radios, which are the only fields of clientInfo
+	// changed after construction.
+	clientInfoLock sync.RWMutex
+	// isRadiosHidden replaces the radios announced to the SRS server with switched-off radios. It is protected by
+	// clientInfoLock.
+	isRadiosHidden bool
+	// previousNames are the names this client had before being renamed by SetName. The SRS server may still echo them
+	// until it handles the rename, so they are not treated as collisions until the server echoes the current name. It
+	// is protected by clientInfoLock.
+	previousNames []string
 	// externalAWACSModePassword is the password for authenticating as an external AWACS in the SRS server.
 	externalAWACSModePassword string
 	// clients is a map of GUIDs to client info, which the bot will use to filter out other clients that are not in the same coalition and frequency.
 	clients map[types.GUID]types.ClientInfo
+	// peakClientsOnFrequency is the largest number of peers which have been on the client's frequency at once. It is
+	// protected by clientsLock.
+	peakClientsOnFrequency int
 	// clientsLock controls access to the otherClients map.
 	clientsLock sync.RWMutex
+	// frequencyTolerance is the tolerance used to match peers' frequencies to this client's.
+	frequencyTolerance unit.Frequency
+	// excludeSpectators is true if spectators should not be stored in the clients map.
+	excludeSpectators bool
+	// listenAll is true if peers in every coalition should be stored in the clients map.
+	listenAll bool
+	// isSpectator is true if the client joined as a spectator. Like listenAll, peers in every coalition are stored in
+	// the clients map.
+	isSpectator bool
+	// unknownCoalitions selects how peers in unknown coalitions are treated.
+	unknownCoalitions types.UnknownCoalitionPolicy
+	// loggedCoalitions are the unknown coalition IDs which have already been logged. It is only accessed by the Run
+	// goroutine.
+	loggedCoalitions map[coalitions.Coalition]bool
+	// reportedCollisions are the clients which have been reported as using this client's GUID, keyed by name and unit
+	// ID. It is only accessed by the Run goroutine.
+	reportedCollisions map[string]bool
+	// guidCollisionCallback is called when another client reports this client's GUID.
+	guidCollisionCallback atomic.Pointer[GUIDCollisionCallback]
+	// rotateGUIDOnCollision is true if the client should reconnect with a new GUID when another client reports this
+	// client's GUID.
+	rotateGUIDOnCollision bool
+	// isGUIDRotationPending is true if a GUID collision has been detected and the client should rotate its GUID once
+	// the current message is handled. It is only accessed by the Run goroutine.
+	isGUIDRotationPending bool
+	// guidRotatedCallback is called when the client rotates its GUID.
+	guidRotatedCallback atomic.Pointer[GUIDRotatedCallback]
+	// writeTimeout is the maximum time to wait for each message to be written.
+	writeTimeout time.Duration
+	// serverSettings are the most recently received server settings. It is only accessed by the Run goroutine.
+	serverSettings map[string]string
+	// isTransmitForbidden is true if the server settings forbid the client from transmitting. It is only accessed by
+	// the Run goroutine.
+	isTransmitForbidden bool
+	// transmitPermissionCallback is called when the server settings change whether the client may transmit.
+	transmitPermissionCallback atomic.Pointer[TransmitPermissionCallback]
+	// authenticationLostCallback is called when the server disconnects the client from External AWACS Mode.
+	authenticationLostCallback atomic.Pointer[AuthenticationLostCallback]
+	// kickedCallback is called when the server kicks the client.
+	kickedCallback atomic.Pointer[KickedCallback]
+	// messageCallback is called with every received message.
+	messageCallback atomic.Pointer[MessageCallback]
+	// messageCallbackCh queues received messages for messageCallback.
+	messageCallbackCh chan types.Message
+	// serverVersion is the SRS server's version. It is nil until the version is discovered.
+	serverVersion atomic.Pointer[string]
+	// serverVersionCallback is called when the SRS server's version is discovered.
+	serverVersionCallback atomic.Pointer[ServerVersionCallback]
+	// dataTimeout is the maximum time to wait for data from the server before treating the connection as dead.
+	dataTimeout time.Duration
 	// lastReceived is the most recent time data was received. If this exceeds a data timeout, we have likely been disconnected from the server.
-	lastReceived time.Time
+	// It is stored as a [time.Time] rather than Unix nanoseconds so that it keeps its monotonic clock reading, which
+	// makes the liveness check immune to wall-clock changes.
+	lastReceived atomic.Pointer[time.Time]
+	// messagesSent counts the messages sent to the server.
+	messagesSent atomic.Uint64
+	// messagesReceived counts the messages received from the server.
+	messagesReceived atomic.Uint64
+	// sendErrors counts the messages which could not be sent.
+	sendErrors atomic.Uint64
+	// syncedCh is closed once the first sync message has been handled.
+	syncedCh chan struct{}
+	// syncedOnce ensures syncedCh is only closed once.
+	syncedOnce sync.Once
+	// closeCh is closed when Close is called, which stops Run.
+	closeCh chan struct{}
+	// closeOnce ensures Close only takes effect once.
+	closeOnce sync.Once
+	// disconnected is set when the connection is closed.
+	disconnected atomic.Bool
+	// kicked is set when the server kicks the client.
+	kicked atomic.Bool
+	// teardownOnce ensures the connection is only closed once, whether by Close or by Run returning.
+	teardownOnce sync.Once
+	// logger logs data synchronization with the SRS server.
+	logger zerolog.Logger
 }
 
 func NewClient(guid types.GUID, config types.ClientConfiguration) (DataClient, error) {
-	log.Info().Str("protocol", "tcp").Str("address", config.Address).Msg("connecting to SRS server")
-	address, err := net.ResolveTCPAddr("tcp", config.Address)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve SRS server address %v: %w", config.Address, err)
+	if len(config.Radios) > types.MaxRadios {
+		return nil, fmt.Errorf("SRS supports at most %d radios, got %d", types.MaxRadios, len(config.Radios))
+	}
+	if config.JoinAsSpectator && config.Coalition != types.SpectatorCoalition {
+		return nil, fmt.Errorf("joining as a spectator cannot be combined with coalition %d", config.Coalition)
+	}
+	frequencyTolerance := config.FrequencyTolerance
+	if frequencyTolerance == 0 {
+		frequencyTolerance = types.DefaultFrequencyTolerance
+	}
+	if frequencyTolerance < 0 {
+		return nil, fmt.Errorf("frequency tolerance must not be negative, got %v", config.FrequencyTolerance)
+	}
+	if err := config.AddressFamily.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid address family: %w", err)
+	}
+	if err := config.UnknownCoalitions.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid unknown coalition policy: %w", err)
+	}
+	writeTimeout := config.WriteTimeout
+	if writeTimeout == 0 {
+		writeTimeout = defaultWriteTimeout
 	}
-	connection, err := net.DialTCP("tcp", nil, address)
+	if writeTimeout < 0 {
+		return nil, fmt.Errorf("write timeout must not be negative, got %v", writeTimeout)
+	}
+
+	dataTimeout := config.DataTimeout
+	if dataTimeout == 0 {
+		dataTimeout = defaultDataTimeout
+	}
+	if dataTimeout < 0 {
+		return nil, fmt.Errorf("data timeout must not be negative, got %v", dataTimeout)
+	}
+
+	network := config.AddressFamily.Network("tcp")
+	log.Info().Str("protocol", network).Str("address", config.Address).Bool("tls", config.TLS.Enabled).Msg("connecting to SRS server")
+	openTransport := config.DataTransport
+	if openTransport == nil {
+		var tlsConfig *tls.Config
+		if config.TLS.Enabled {
+			var err error
+			tlsConfig, err = newTLSConfig(config.TLS, config.Address)
+			if err != nil {
+				return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+			}
+		}
+		var dialer types.TCPDialer = types.NewDefaultDialer(config.ConnectionTimeout)
+		if config.TCPDialer != nil {
+			dialer = config.TCPDialer
+		}
+		openTransport = func(ctx context.Context, network, address string) (types.DataTransport, error) {
+			return dial(ctx, dialer, network, address, tlsConfig, config.ConnectionTimeout)
+		}
+	} else if config.TLS.Enabled {
+		return nil, errors.New("TLS cannot be used with a custom data transport")
+	}
+	connection, err := openTransport(context.Background(), network, config.Address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to SRS server %v over TCP: %w", config.Address, err)
+		return nil, err
 	}
 
 	client := &dataClient{
-		connection: connection,
+		connection:    connection,
+		openTransport: openTransport,
+		network:       network,
+		address:       config.Address,
 		clientInfo: types.ClientInfo{
 			Name:      config.ClientName,
 			GUID:      guid,
@@ -76,168 +336,449 @@ func NewClient(guid types.GUID, config types.ClientConfiguration) (DataClient, e
 		},
 		externalAWACSModePassword: config.ExternalAWACSModePassword,
 		clients:                   make(map[types.GUID]types.ClientInfo),
+		excludeSpectators:         config.ExcludeSpectators,
+		listenAll:                 config.ListenAll,
+		isSpectator:               config.JoinAsSpectator,
+		rotateGUIDOnCollision:     config.RotateGUIDOnCollision,
+		unknownCoalitions:         config.UnknownCoalitions,
+		frequencyTolerance:        frequencyTolerance,
+		writeTimeout:              writeTimeout,
+		dataTimeout:               dataTimeout,
+		messageCallbackCh:         make(chan types.Message, messageBufferSize),
+		syncedCh:                  make(chan struct{}),
+		closeCh:                   make(chan struct{}),
+		logger:                    config.LogLevels.Logger(types.SubsystemDataSync),
 	}
+	client.markReceived(time.Now())
 	return client, nil
 }
 
+// dial connects to the SRS server over TCP, then performs a TLS handshake if a TLS configuration is given. The
+// handshake is bounded by the connection timeout, unless it is zero.
+func dial(ctx context.Context, dialer types.TCPDialer, network, address string, tlsConfig *tls.Config, connectionTimeout time.Duration) (types.DataTransport, error) {
+	connection, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SRS server %v over TCP: %w", address, err)
+	}
+	if tlsConfig != nil {
+		if connectionTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, connectionTimeout)
+			defer cancel()
+		}
+		tlsConnection, err := handshakeTLS(ctx, connection, tlsConfig)
+		if err != nil {
+			_ = connection.Close()
+			return nil, fmt.Errorf("failed to connect to SRS server %v over TLS: %w", address, err)
+		}
+		connection = tlsConnection
+	}
+	log.Info().Stringer("local", connection.LocalAddr()).Msg("connected to SRS server over TCP")
+	return connection, nil
+}
+
+// conn returns the current TCP connection to the SRS server.
+func (c *dataClient) conn() types.DataTransport {
+	c.connectionLock.RLock()
+	defer c.connectionLock.RUnlock()
+	return c.connection
+}
+
+// Synced implements [DataClient.Synced].
+func (c *dataClient) Synced() <-chan struct{} {
+	return c.syncedCh
+}
+
 // Name implements DataClient.Name.
 func (c *dataClient) Name() string {
+	c.clientInfoLock.RLock()
+	defer c.clientInfoLock.RUnlock()
 	return c.clientInfo.Name
 }
 
+// SetName implements [DataClient.SetName].
+func (c *dataClient) SetName(name string) error {
+	if name == "" {
+		return errors.New("client name must not be empty")
+	}
+	c.clientInfoLock.Lock()
+	if name != c.clientInfo.Name {
+		c.previousNames = append(c.previousNames, c.clientInfo.Name)
+	}
+	c.clientInfo.Name = name
+	c.clientInfoLock.Unlock()
+
+	message := c.newMessageWithClient(types.MessageUpdate)
+	if err := c.Send(message); err != nil {
+		return fmt.Errorf("failed to update client name: %w", err)
+	}
+	return nil
+}
+
 // Run implements DataClient.Run.
 func (c *dataClient) Run(ctx context.Context, wg *sync.WaitGroup, readyCh chan<- any) error {
-	log.Info().Msg("SRS data client starting")
+	c.logger.Info().Msg("SRS data client starting")
 	defer func() {
 		if err := c.close(); err != nil {
-			log.Error().Err(err).Msg("error closing SRS client")
+			c.logger.Error().Err(err).Msg("error closing SRS client")
 		}
 	}()
 
-	messageChan := make(chan types.Message)
-	errorChan := make(chan error)
+	// Stop the control loop if Close is called.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.closeCh:
+			c.logger.Info().Msg("stopping SRS data client because it was closed")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	reader := c.startReader(ctx, wg, c.conn())
+	defer func() {
+		reader.stop()
+	}()
 
+	// Call the message callback from its own goroutine, so a slow callback cannot stall the client.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		reader := bufio.NewReader(c.connection)
-		for {
-			if ctx.Err() != nil {
-				log.Info().Msg("stopping SRS data client due to context cancellation")
-				return
-			}
-			line, err := reader.ReadBytes(byte('\n'))
-			switch err {
-			case nil:
-				var message types.Message
-				jsonErr := json.Unmarshal(line, &message)
-				if jsonErr != nil {
-					log.Warn().Str("text", string(line)).Err(jsonErr).Msg("failed to unmarshal message")
-				} else {
-					messageChan <- message
-				}
-			case io.EOF:
-				log.Trace().Msg("EOF received from SRS server")
-			default:
-				log.Error().Err(err).Msg("error reading from SRS server")
-				errorChan <- err
-				return
-			}
-		}
+		c.dispatchMessages(ctx)
 	}()
 
 	close(readyCh)
-	log.Info().Msg("SRS data client ready")
+	c.logger.Info().Msg("SRS data client ready")
 
-	log.Info().Msg("sending initial sync message")
-	if err := c.sync(); err != nil {
-		return fmt.Errorf("initial sync failed: %w", err)
+	if err := c.join(); err != nil {
+		return err
 	}
 
-	log.Info().Msg("connecting to external AWACS mode")
-	if err := c.connectExternalAWACSMode(); err != nil {
-		return fmt.Errorf("external AWACS mode failed: %w", err)
-	}
+	// Watch for a connection which is open but no longer delivering data.
+	c.markReceived(time.Now())
+	watchdog := time.NewTicker(c.dataTimeout / 4)
+	defer watchdog.Stop()
 
 	for {
 		select {
-		case m := <-messageChan:
-			c.lastReceived = time.Now()
+		case m := <-reader.messages:
+			c.markReceived(time.Now())
+			c.messagesReceived.Add(1)
 			c.handleMessage(m)
-		case <-ctx.Done():
-			log.Info().Msg("stopping SRS data client due to context cancellation")
-			select {
-			case <-messageChan:
-			case <-errorChan:
+			if c.kicked.Load() {
+				return fmt.Errorf("data client error: %w", ErrKicked)
+			}
+			if c.isGUIDRotationPending {
+				c.isGUIDRotationPending = false
+				var err error
+				if reader, err = c.rotateGUID(ctx, wg, reader); err != nil {
+					return fmt.Errorf("failed to rotate GUID: %w", err)
+				}
+			}
+		case <-watchdog.C:
+			if err := c.checkLiveness(); err != nil {
+				return err
 			}
+		case <-ctx.Done():
+			c.logger.Info().Msg("stopping SRS data client due to context cancellation")
 			return nil
-		case err := <-errorChan:
+		case err := <-reader.errors:
+			if errors.Is(err, io.EOF) && c.isPossibleKick(ctx) {
+				return fmt.Errorf("data client error: %w: %w", ErrPossiblyKicked, err)
+			}
 			return fmt.Errorf("data client error: %w", err)
 		}
 	}
 }
 
-// handleMessage routes a given message to the appropriate handler.
+// connectionReader reads messages from a single connection to the SRS server.
+type connectionReader struct {
+	// messages receives the messages read from the connection.
+	messages <-chan types.Message
+	// errors receives the error which stopped the reader, unless it was stopped by stop or context cancellation.
+	errors <-chan error
+	// stop stops the reader. The connection should be closed afterwards to interrupt a blocked read.
+	stop context.CancelFunc
+}
+
+// startReader starts reading messages from the given connection in a new goroutine.
+func (c *dataClient) startReader(ctx context.Context, wg *sync.WaitGroup, connection types.DataTransport) connectionReader {
+	ctx, cancel := context.WithCancel(ctx)
+	messageChan := make(chan types.Message, messageBufferSize)
+	// errorChan is buffered so that the reader can exit even if Run has already returned.
+	errorChan := make(chan error, 1)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := readMessages(ctx, connection, messageChan); err != nil {
+			if ctx.Err() != nil || c.isClosed() {
+				c.logger.Info().Msg("stopping SRS data client due to context cancellation")
+				return
+			}
+			c.logger.Error().Err(err).Msg("error reading from SRS server")
+			errorChan <- err
+		}
+	}()
+	return connectionReader{messages: messageChan, errors: errorChan, stop: cancel}
+}
+
+// join sends this client's information to the SRS server, then authenticates with External AWACS Mode unless the
+// client is a spectator.
+func (c *dataClient) join() error {
+	c.logger.Info().Msg("sending initial sync message")
+	if err := c.sync(); err != nil {
+		return fmt.Errorf("initial sync failed: %w", err)
+	}
+
+	if c.isSpectator {
+		c.logger.Info().Msg("joined as a spectator, not connecting to external AWACS mode")
+		return nil
+	}
+	c.logger.Info().Msg("connecting to external AWACS mode")
+	if err := c.connectExternalAWACSMode(); err != nil {
+		return fmt.Errorf("external AWACS mode failed: %w", err)
+	}
+	return nil
+}
+
+// handleMessage routes a given message to the appropriate handler, then publishes it for the message callback.
 func (c *dataClient) handleMessage(message types.Message) {
+	defer c.publishMessage(message)
+	switch message.Type {
+	case types.MessageSync, types.MessageServerSettings, types.MessageVersionMismatch:
+		// These messages are always composed by the server itself, so their version is the server's version.
+		c.discoverServerVersion(message.Version)
+	}
 	switch message.Type {
 	case types.MessagePing:
-		logMessageAndIgnore(message)
+		c.logMessageAndIgnore(message)
 	case types.MessageServerSettings:
-		logMessageAndIgnore(message)
+		c.logMessageAndIgnore(message)
+		c.updateServerSettings(message.ServerSettings)
 	case types.MessageVersionMismatch:
-		logMessageAndIgnore(message)
+		c.logMessageAndIgnore(message)
 	case types.MessageExternalAWACSModeDisconnect:
-		logMessageAndIgnore(message)
+		c.logger.Warn().Msg("SRS server disconnected this client from external AWACS mode")
+		if callback := callbacks.Load(&c.authenticationLostCallback); callback != nil {
+			callback()
+		}
 	case types.MessageSync:
+		c.updateServerSettings(message.ServerSettings)
 		c.syncClients(message.Clients)
-	case types.MessageUpdate:
-		c.syncClient(message.Client)
-	case types.MessageRadioUpdate:
-		c.syncClient(message.Client)
+		c.syncedOnce.Do(func() {
+			c.logger.Info().Msg("completed first sync with SRS server")
+			close(c.syncedCh)
+		})
+	case types.MessageUpdate, types.MessageRadioUpdate:
+		c.syncClient(message.Type, message.Client)
 	case types.MessageClientDisconnect:
-		c.removeClient(message.Client)
+		if c.isSelfDisconnect(message.Client) {
+			c.handleKick()
+		} else {
+			c.removeClient(message.Client)
+		}
 	case types.MessageExternalAWACSModePassword:
 		if message.Client.Coalition == c.clientInfo.Coalition {
-			log.Debug().Any("remoteClient", message.Client).Msg("received external AWACS mode password message")
+			c.logger.Debug().Any("remoteClient", message.Client).Msg("received external AWACS mode password message")
 			if err := c.updateRadios(); err != nil {
-				log.Error().Err(err).Msg("failed to update radios")
+				c.logger.Error().Err(err).Msg("failed to update radios")
 			}
 		}
 	default:
-		log.Warn().Any("message", message).Msg("received unrecognized message")
+		c.logger.Warn().Any("message", message).Msg("received unrecognized message")
 	}
 }
 
-// logMessageAndIgnore logs a message at DEBUG level.
-func logMessageAndIgnore(message types.Message) {
-	log.Debug().Any("message", message).Msg("received message")
+// publishMessage queues a handled message for the message callback. If the callback has fallen behind and the queue is
+// full, the message is dropped rather than stalling the client.
+func (c *dataClient) publishMessage(message types.Message) {
+	if callback := callbacks.Load(&c.messageCallback); callback == nil {
+		return
+	}
+	select {
+	case c.messageCallbackCh <- message:
+	default:
+		c.logger.Warn().Int("type", int(message.Type)).Msg("message callback is falling behind, dropping message")
+	}
 }
 
-// syncClients calls syncClient for each client in the given slice.
-func (c *dataClient) syncClients(others []types.ClientInfo) {
-	log.Info().Int("count", len(others)).Msg("syncronizing clients")
-	for _, info := range others {
-		c.syncClient(info)
+// dispatchMessages calls the message callback for each message queued by publishMessage.
+func (c *dataClient) dispatchMessages(ctx context.Context) {
+	for {
+		select {
+		case message := <-c.messageCallbackCh:
+			if callback := callbacks.Load(&c.messageCallback); callback != nil {
+				callback(message)
+			}
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
-// syncClient checks if the given client matches this client's coalition and radios, and if so, stores it in the otherClients map. Non-matching clients are removed from the map if previously stored.
-func (c *dataClient) syncClient(other types.ClientInfo) {
-	if other.GUID == c.clientInfo.GUID {
-		// why, of course I know him. he's me!
+// discoverServerVersion records the SRS server's version the first time a non-empty version is given, and calls the
+// server version callback. Later calls have no effect.
+func (c *dataClient) discoverServerVersion(version string) {
+	if version == "" {
 		return
 	}
-
-	if len(other.RadioInfo.Radios) == 0 {
+	if !c.serverVersion.CompareAndSwap(nil, &version) {
 		return
 	}
+	c.logger.Info().Str("version", version).Msg("discovered SRS server version")
+	if callback := callbacks.Load(&c.serverVersionCallback); callback != nil {
+		callback(version)
+	}
+}
+
+// LastReceived implements [DataClient.LastReceived].
+func (c *dataClient) LastReceived() time.Time {
+	if received := c.lastReceived.Load(); received != nil {
+		return *received
+	}
+	return time.Time{}
+}
+
+// markReceived records the time data was received. The time should come from [time.Now] so that it carries a
+// monotonic clock reading.
+func (c *dataClient) markReceived(received time.Time) {
+	c.lastReceived.Store(&received)
+}
+
+// LocalAddr implements [DataClient.LocalAddr].
+func (c *dataClient) LocalAddr() net.Addr {
+	return c.conn().LocalAddr()
+}
+
+// IsStale implements [DataClient.IsStale].
+func (c *dataClient) IsStale(timeout time.Duration) bool {
+	return time.Since(c.LastReceived()) > timeout
+}
+
+// ServerVersion implements [DataClient.ServerVersion].
+func (c *dataClient) ServerVersion() string {
+	if version := c.serverVersion.Load(); version != nil {
+		return *version
+	}
+	return ""
+}
 
-	frequencies := make([]string, 0)
-	for _, radio := range other.RadioInfo.Radios {
-		frequency := unit.Frequency(radio.Frequency) * unit.Hertz
-		if frequency.Megahertz() > 8 {
-			frequencies = append(frequencies, fmt.Sprint(frequency.Megahertz()))
+// logMessageAndIgnore logs a message at DEBUG level.
+func (c *dataClient) logMessageAndIgnore(message types.Message) {
+	c.logger.Debug().Any("message", message).Msg("received message")
+}
+
+// syncClients replaces the stored clients with the matching clients in the given slice. Sync messages contain every
+// client connected to the server, so any previously stored client which is not in the slice is removed. The new map
+// is built before acquiring the lock, so the lock is only held for a single swap.
+func (c *dataClient) syncClients(others []types.ClientInfo) {
+	c.logger.Info().Int("count", len(others)).Msg("syncronizing clients")
+	clients := make(map[types.GUID]types.ClientInfo, len(others))
+	for _, other := range others {
+		c.checkGUIDCollision(other)
+		if c.matches(other) {
+			clients[other.GUID] = other
 		}
 	}
-	log.Debug().
-		Str("name", other.Name).
-		Uint64("unitID", other.RadioInfo.UnitID).
-		Strs("frequencies", frequencies).
-		Msgf("synced with SRS client %q", other.Name)
 
-	isSameCoalition := c.clientInfo.Coalition == other.Coalition || types.IsSpectator(other.Coalition)
-	isOnFrequency := c.clientInfo.RadioInfo.IsOnFrequency(other.RadioInfo)
+	c.clientsLock.Lock()
+	defer c.clientsLock.Unlock()
+	c.clients = clients
+	c.updatePeak()
+}
 
-	// if the other client has a matching radio and is not in an opposing coalition, store it in otherClients. Otherwise, banish it to the shadow realm.
+// syncClient merges the section of the given client carried by the given message type into the stored client with the
+// same GUID, if any. See [types.ClientInfo.Merge]. If the merged client matches this client's coalition and radios, it
+// is stored in the clients map. Non-matching clients are removed from the map if previously stored. A client which is
+// not stored cannot be matched until its radios are known.
+func (c *dataClient) syncClient(messageType types.MessageType, other types.ClientInfo) {
+	c.checkGUIDCollision(other)
 	c.clientsLock.Lock()
 	defer c.clientsLock.Unlock()
-	if isSameCoalition && isOnFrequency {
+	if existing, ok := c.clients[other.GUID]; ok {
+		other = existing.Merge(other, messageType)
+	}
+
+	// if the other client has a matching radio and is not in an opposing coalition, store it in otherClients. Otherwise, banish it to the shadow realm.
+	if c.matches(other) {
 		c.clients[other.GUID] = other
+		c.updatePeak()
 	} else {
 		delete(c.clients, other.GUID)
 	}
 }
 
+// matches returns true if the given client is another client which is on a matching radio and is not in an opposing
+// coalition. Spectators match any coalition, unless spectators are excluded. In listen-all mode, or if this client
+// joined as a spectator, every coalition matches.
+func (c *dataClient) matches(other types.ClientInfo) bool {
+	if other.GUID == c.clientInfo.GUID {
+		// why, of course I know him. he's me!
+		return false
+	}
+
+	if len(other.RadioInfo.Radios) == 0 {
+		return false
+	}
+
+	if event := c.logger.Debug(); event.Enabled() {
+		frequencies := make([]unit.Frequency, 0)
+		for _, radio := range other.RadioInfo.Radios {
+			frequency := types.FrequencyFromHertz(radio.Frequency)
+			if frequency > types.IntercomFrequencyLimit {
+				frequencies = append(frequencies, frequency)
+			}
+		}
+		event.
+			Str("name", other.Name).
+			Uint64("unitID", other.RadioInfo.UnitID).
+			Func(types.LogFrequencies(frequencies)).
+			Msgf("synced with SRS client %q", other.Name)
+	}
+
+	if !types.IsKnownCoalition(other.Coalition) && !c.acceptUnknownCoalition(other.Coalition) {
+		return false
+	}
+	if c.excludeSpectators && types.IsSpectator(other.Coalition) {
+		return false
+	}
+	isSameCoalition := c.listenAll || c.isSpectator || c.clientInfo.Coalition == other.Coalition || types.IsSpectator(other.Coalition)
+	radioInfo := c.radioInfo()
+	isOnFrequency := radioInfo.IsOnFrequencyWithin(other.RadioInfo, c.frequencyTolerance)
+	return isSameCoalition && isOnFrequency
+}
+
+// acceptUnknownCoalition returns true if peers in the given unknown coalition should be treated as spectators, or false
+// if they should be ignored. Each unknown coalition is logged the first time it is seen.
+func (c *dataClient) acceptUnknownCoalition(coalition coalitions.Coalition) bool {
+	if !c.loggedCoalitions[coalition] {
+		if c.loggedCoalitions == nil {
+			c.loggedCoalitions = make(map[coalitions.Coalition]bool)
+		}
+		c.loggedCoalitions[coalition] = true
+		c.logger.Warn().
+			Int("coalition", int(coalition)).
+			Stringer("policy", c.unknownCoalitions).
+			Msg("SRS client has an unknown coalition")
+	}
+	return c.unknownCoalitions != types.UnknownCoalitionExclude
+}
+
+// handleKick stops the client from sending further messages and calls the kicked callback. It is called when the server
+// sends a disconnect message naming this client. A normal disconnect of another client, including one using this
+// client's GUID, is handled by removeClient instead. It is only called by the Run goroutine, which stops afterwards.
+func (c *dataClient) handleKick() {
+	if c.kicked.Swap(true) {
+		return
+	}
+	c.logger.Error().Msg("SRS server kicked this client; not reconnecting")
+	if callback := callbacks.Load(&c.kickedCallback); callback != nil {
+		callback()
+	}
+}
+
 func (c *dataClient) removeClient(info types.ClientInfo) {
 	c.clientsLock.Lock()
 	defer c.clientsLock.Unlock()
@@ -246,16 +787,43 @@ func (c *dataClient) removeClient(info types.ClientInfo) {
 
 // Send implements DataClient.Send.
 func (c *dataClient) Send(message types.Message) error {
+	err := c.send(message)
+	if err != nil {
+		c.sendErrors.Add(1)
+	} else {
+		c.messagesSent.Add(1)
+	}
+	return err
+}
+
+// send writes a single message to the connection.
+func (c *dataClient) send(message types.Message) error {
 	// Sending a message means writing a JSON-serialized message to the TCP connection, followed by a newline.
 	if message.Version == "" {
 		return errors.New("message Version is required")
 	}
+	if c.kicked.Load() {
+		return ErrKicked
+	}
+	if c.disconnected.Load() {
+		return ErrNotConnected
+	}
 	b, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message to JSON: %w", err)
 	}
 	b = append(b, byte('\n'))
-	_, err = c.connection.Write(b)
+	connection := c.conn()
+	if err := connection.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
+	_, err = connection.Write(b)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("%w after %v: %w", ErrSendTimeout, c.writeTimeout, err)
+	}
+	if errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to write message: %w", err)
 	}
@@ -281,7 +849,12 @@ func (c *dataClient) sync() error {
 
 func (c *dataClient) newMessageWithClient(t types.MessageType) types.Message {
 	message := c.newMessage(t)
+	c.clientInfoLock.RLock()
+	defer c.clientInfoLock.RUnlock()
 	message.Client = c.clientInfo
+	if c.isRadiosHidden {
+		message.Client.RadioInfo.Radios = switchedOff(c.clientInfo.RadioInfo.Radios)
+	}
 	return message
 }
 
@@ -304,21 +877,53 @@ func (c *dataClient) connectExternalAWACSMode() error {
 	return nil
 }
 
-// close closes the TCP connection to the SRS server. This is anti-idomatic Go and should be refactored.
-func (c *dataClient) close() error {
-	if err := c.connection.Close(); err != nil {
-		return fmt.Errorf("error closing TCP connection to SRS: %w", err)
+// Disconnect implements [DataClient.Disconnect].
+func (c *dataClient) Disconnect() error {
+	var err error
+	if sendErr := c.Send(c.newMessageWithClient(types.MessageClientDisconnect)); sendErr != nil {
+		err = fmt.Errorf("failed to send disconnect message: %w", sendErr)
+	}
+	return errors.Join(err, c.Close())
+}
+
+// Close implements [DataClient.Close].
+func (c *dataClient) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+	})
+	return c.close()
+}
+
+// isClosed returns true if Close has been called.
+func (c *dataClient) isClosed() bool {
+	select {
+	case <-c.closeCh:
+		return true
+	default:
+		return false
 	}
-	return nil
+}
+
+// close closes the TCP connection to the SRS server. It is called both by Close and when Run returns, so only the first
+// call closes the connection and later calls are no-ops.
+func (c *dataClient) close() (err error) {
+	c.teardownOnce.Do(func() {
+		c.disconnected.Store(true)
+		if closeErr := c.conn().Close(); closeErr != nil {
+			err = fmt.Errorf("error closing TCP connection to SRS: %w", closeErr)
+		}
+	})
+	return
 }
 
 // IsOnFrequency implements [DataClient.IsOnFrequency].
 func (c *dataClient) IsOnFrequency(name string) bool {
+	radioInfo := c.radioInfo()
 	c.clientsLock.RLock()
 	defer c.clientsLock.RUnlock()
 	for _, client := range c.clients {
 		if client.Name == name {
-			if ok := c.clientInfo.RadioInfo.IsOnFrequency(client.RadioInfo); ok {
+			if ok := radioInfo.IsOnFrequencyWithin(client.RadioInfo, c.frequencyTolerance); ok {
 				return true
 			}
 		}
@@ -326,14 +931,80 @@ func (c *dataClient) IsOnFrequency(name string) bool {
 	return false
 }
 
+// Peer implements [DataClient.Peer].
+func (c *dataClient) Peer(guid types.GUID) (types.ClientInfo, bool) {
+	c.clientsLock.RLock()
+	defer c.clientsLock.RUnlock()
+	client, ok := c.clients[guid]
+	return client, ok
+}
+
+// PeerGUIDs implements [DataClient.PeerGUIDs].
+func (c *dataClient) PeerGUIDs(name string) []types.GUID {
+	c.clientsLock.RLock()
+	defer c.clientsLock.RUnlock()
+	guids := make([]types.GUID, 0)
+	for guid, client := range c.clients {
+		if client.Name == name {
+			guids = append(guids, guid)
+		}
+	}
+	return guids
+}
+
 func (c *dataClient) ClientsOnFrequency() int {
 	c.clientsLock.RLock()
 	defer c.clientsLock.RUnlock()
-	count := 0
+	return c.countOnFrequency()
+}
+
+// TunedCounts implements [DataClient.TunedCounts].
+func (c *dataClient) TunedCounts() map[unit.Frequency]int {
+	radios := c.radioInfo().Radios
+	c.clientsLock.RLock()
+	defer c.clientsLock.RUnlock()
+	counts := make(map[unit.Frequency]int, len(radios))
+	for _, radio := range radios {
+		counts[types.FrequencyFromHertz(radio.Frequency)] = 0
+	}
+	for _, client := range c.clients {
+		// Radios on the same frequency with different modulations share a count, so count each peer once per frequency.
+		tuned := make(map[unit.Frequency]bool)
+		for _, radio := range radios {
+			for _, peerRadio := range client.RadioInfo.Radios {
+				if radio.IsSameFrequencyWithin(peerRadio, c.frequencyTolerance) {
+					tuned[types.FrequencyFromHertz(radio.Frequency)] = true
+				}
+			}
+		}
+		for frequency := range tuned {
+			counts[frequency]++
+		}
+	}
+	return counts
+}
+
+// PeersOnFrequency implements [DataClient.PeersOnFrequency].
+func (c *dataClient) PeersOnFrequency(filter ...coalitions.Coalition) []types.ClientInfo {
+	radioInfo := c.radioInfo()
+	c.clientsLock.RLock()
+	defer c.clientsLock.RUnlock()
+	peers := make([]types.ClientInfo, 0)
 	for _, client := range c.clients {
-		if ok := c.clientInfo.RadioInfo.IsOnFrequency(client.RadioInfo); ok {
-			count++
+		if len(filter) > 0 && !slices.Contains(filter, client.Coalition) {
+			continue
+		}
+		if radioInfo.IsOnFrequencyWithin(client.RadioInfo, c.frequencyTolerance) {
+			peers = append(peers, client)
 		}
 	}
-	return count
+	slices.SortFunc(peers, func(a, b types.ClientInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return peers
+}
+
+// HasAudience implements [DataClient.HasAudience].
+func (c *dataClient) HasAudience() bool {
+	return c.ClientsOnFrequency() > 0
 }
@@ -0,0 +1,1150 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns a data client on the blue coalition with a single radio on 251.0AM.
+func newTestClient() *dataClient {
+	return &dataClient{
+		clientInfo: types.ClientInfo{
+			Name:      "GCI Sky Eye [BOT]",
+			GUID:      types.NewGUID(),
+			Coalition: coalitions.Blue,
+			RadioInfo: types.RadioInfo{
+				Radios: []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}},
+			},
+		},
+		clients:            make(map[types.GUID]types.ClientInfo),
+		frequencyTolerance: types.DefaultFrequencyTolerance,
+		syncedCh:           make(chan struct{}),
+	}
+}
+
+// newTestPeer returns a client with the given name, coalition and frequency.
+func newTestPeer(name string, coalition coalitions.Coalition, frequency float64) types.ClientInfo {
+	return types.ClientInfo{
+		Name:      name,
+		GUID:      types.NewGUID(),
+		Coalition: coalition,
+		RadioInfo: types.RadioInfo{
+			Radios: []types.Radio{{Frequency: frequency, Modulation: types.ModulationAM}},
+		},
+	}
+}
+
+func TestSyncClients(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	stale := newTestPeer("Stale 1-1", coalitions.Blue, 251000000)
+	c.syncClient(types.MessageRadioUpdate, stale)
+	require.Equal(t, 1, c.ClientsOnFrequency())
+
+	c.syncClients([]types.ClientInfo{
+		newTestPeer("Hornet 1-1", coalitions.Blue, 251000000),
+		newTestPeer("Viper 1-1", coalitions.Blue, 251000000),
+		newTestPeer("Flanker 1-1", coalitions.Red, 251000000),
+		newTestPeer("Eagle 1-1", coalitions.Blue, 133000000),
+		c.clientInfo,
+	})
+
+	assert.Equal(t, 2, c.ClientsOnFrequency())
+	assert.True(t, c.IsOnFrequency("Hornet 1-1"))
+	assert.True(t, c.IsOnFrequency("Viper 1-1"))
+	assert.False(t, c.IsOnFrequency("Flanker 1-1"))
+	assert.False(t, c.IsOnFrequency("Eagle 1-1"))
+	assert.False(t, c.IsOnFrequency("Stale 1-1"))
+}
+
+func TestHasAudience(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	assert.False(t, c.HasAudience())
+
+	c.syncClients([]types.ClientInfo{newTestPeer("Flanker 1-1", coalitions.Red, 251000000)})
+	assert.False(t, c.HasAudience())
+
+	observer := newTestPeer("Observer", coalitions.Neutrals, 251000000)
+	c.syncClient(types.MessageRadioUpdate, observer)
+	assert.True(t, c.HasAudience())
+	c.excludeSpectators = true
+	c.syncClient(types.MessageRadioUpdate, observer)
+	assert.False(t, c.HasAudience())
+
+	c.syncClient(types.MessageRadioUpdate, newTestPeer("Hornet 1-1", coalitions.Blue, 251000000))
+	assert.True(t, c.HasAudience())
+}
+
+func TestExcludeSpectators(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		excludeSpectators bool
+		expected          int
+	}{
+		{excludeSpectators: false, expected: 2},
+		{excludeSpectators: true, expected: 1},
+	}
+	for _, test := range testCases {
+		t.Run(fmt.Sprintf("excludeSpectators=%v", test.excludeSpectators), func(t *testing.T) {
+			t.Parallel()
+			c := newTestClient()
+			c.excludeSpectators = test.excludeSpectators
+			c.syncClients([]types.ClientInfo{
+				newTestPeer("Hornet 1-1", coalitions.Blue, 251000000),
+				newTestPeer("Observer", coalitions.Neutrals, 251000000),
+			})
+			assert.Equal(t, test.expected, c.ClientsOnFrequency())
+			assert.Equal(t, !test.excludeSpectators, c.IsOnFrequency("Observer"))
+
+			c.syncClient(types.MessageRadioUpdate, newTestPeer("Spectator", coalitions.Neutrals, 251000000))
+			assert.Equal(t, !test.excludeSpectators, c.IsOnFrequency("Spectator"))
+		})
+	}
+}
+
+func TestJoinAsSpectator(t *testing.T) {
+	t.Parallel()
+	_, err := NewClient(types.NewGUID(), types.ClientConfiguration{Coalition: coalitions.Blue, JoinAsSpectator: true})
+	require.Error(t, err)
+
+	c := newTestClient()
+	c.clientInfo.Coalition = types.SpectatorCoalition
+	c.isSpectator = true
+	c.syncClients([]types.ClientInfo{
+		newTestPeer("Hornet 1-1", coalitions.Blue, 251000000),
+		newTestPeer("Flanker 1-1", coalitions.Red, 251000000),
+		newTestPeer("Observer", coalitions.Neutrals, 251000000),
+		newTestPeer("Fulcrum 1-1", coalitions.Red, 133000000),
+	})
+	assert.Equal(t, 3, c.ClientsOnFrequency(), "a spectator should track peers in every coalition on its frequency")
+	assert.True(t, c.IsOnFrequency("Flanker 1-1"))
+	assert.False(t, c.IsOnFrequency("Fulcrum 1-1"))
+}
+
+func TestUnknownCoalitions(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		policy            types.UnknownCoalitionPolicy
+		excludeSpectators bool
+		expected          bool
+	}{
+		{policy: types.UnknownCoalitionSpectator, expected: true},
+		{policy: types.UnknownCoalitionSpectator, excludeSpectators: true, expected: false},
+		{policy: types.UnknownCoalitionExclude, expected: false},
+	}
+	for _, test := range testCases {
+		t.Run(fmt.Sprintf("%v/excludeSpectators=%v", test.policy, test.excludeSpectators), func(t *testing.T) {
+			t.Parallel()
+			c := newTestClient()
+			c.unknownCoalitions = test.policy
+			c.excludeSpectators = test.excludeSpectators
+			c.syncClients([]types.ClientInfo{
+				newTestPeer("Hornet 1-1", coalitions.Blue, 251000000),
+				newTestPeer("Negative", -1, 251000000),
+				newTestPeer("Future", 7, 251000000),
+			})
+			assert.True(t, c.IsOnFrequency("Hornet 1-1"))
+			assert.Equal(t, test.expected, c.IsOnFrequency("Negative"))
+			assert.Equal(t, test.expected, c.IsOnFrequency("Future"))
+			assert.Len(t, c.loggedCoalitions, 2)
+
+			c.syncClient(types.MessageRadioUpdate, newTestPeer("Future 2", 7, 251000000))
+			assert.Equal(t, test.expected, c.IsOnFrequency("Future 2"))
+			assert.Len(t, c.loggedCoalitions, 2)
+		})
+	}
+}
+
+func TestTunedCounts(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	c.clientInfo.RadioInfo.Radios = []types.Radio{
+		{Frequency: 251000000, Modulation: types.ModulationAM},
+		{Frequency: 243000000, Modulation: types.ModulationAM},
+		{Frequency: 133000000, Modulation: types.ModulationAM},
+	}
+	assert.Equal(t, map[unit.Frequency]int{251 * unit.Megahertz: 0, 243 * unit.Megahertz: 0, 133 * unit.Megahertz: 0}, c.TunedCounts())
+
+	dualRadio := newTestPeer("Viper 1-1", coalitions.Blue, 251000000)
+	dualRadio.RadioInfo.Radios = append(dualRadio.RadioInfo.Radios, types.Radio{Frequency: 243000000, Modulation: types.ModulationAM})
+	c.syncClients([]types.ClientInfo{
+		newTestPeer("Hornet 1-1", coalitions.Blue, 251000000),
+		newTestPeer("Hornet 1-2", coalitions.Blue, 251000000),
+		newTestPeer("Eagle 1-1", coalitions.Blue, 305000000),
+		dualRadio,
+	})
+	assert.Equal(t, map[unit.Frequency]int{251 * unit.Megahertz: 3, 243 * unit.Megahertz: 1, 133 * unit.Megahertz: 0}, c.TunedCounts())
+}
+
+func TestGUIDCollision(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	c.clientInfo.RadioInfo.UnitID = 100000002
+	var collisions []types.ClientInfo
+	c.SetGUIDCollisionCallback(func(other types.ClientInfo) {
+		collisions = append(collisions, other)
+	})
+
+	// The server echoing this client's own information back is not a collision.
+	c.syncClients([]types.ClientInfo{c.clientInfo})
+	c.syncClient(types.MessageUpdate, types.ClientInfo{GUID: c.clientInfo.GUID})
+	assert.Empty(t, collisions)
+
+	impostor := newTestPeer("Hornet 1-1", coalitions.Blue, 251000000)
+	impostor.GUID = c.clientInfo.GUID
+	c.syncClient(types.MessageRadioUpdate, impostor)
+	c.syncClients([]types.ClientInfo{impostor})
+	require.Len(t, collisions, 1)
+	assert.Equal(t, "Hornet 1-1", collisions[0].Name)
+	assert.Zero(t, c.ClientsOnFrequency())
+
+	otherUnit := c.clientInfo
+	otherUnit.RadioInfo.UnitID = 4
+	c.syncClient(types.MessageRadioUpdate, otherUnit)
+	assert.Len(t, collisions, 2)
+}
+
+func TestGUIDCollisionDuringRename(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	go func() {
+		_, _ = io.Copy(io.Discard, serverConn)
+	}()
+	c := newTestClient()
+	c.connection = clientConn
+	c.writeTimeout = time.Second
+	c.closeCh = make(chan struct{})
+	var collisions []types.ClientInfo
+	c.SetGUIDCollisionCallback(func(other types.ClientInfo) {
+		collisions = append(collisions, other)
+	})
+
+	// Until the server handles the rename, it echoes the previous name.
+	previous := c.clientInfo
+	require.NoError(t, c.SetName("GCI Renamed [BOT]"))
+	c.syncClient(types.MessageUpdate, previous)
+	assert.Empty(t, collisions)
+
+	// Once the server echoes the new name, the previous name belongs to another client.
+	renamed := previous
+	renamed.Name = "GCI Renamed [BOT]"
+	c.syncClient(types.MessageUpdate, renamed)
+	c.syncClient(types.MessageUpdate, previous)
+	require.Len(t, collisions, 1)
+	assert.Equal(t, previous.Name, collisions[0].Name)
+}
+
+func TestRotateGUIDOnCollision(t *testing.T) {
+	t.Parallel()
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer listener.Close()
+	guid := types.NewGUID()
+	// syncs receives the GUID in the first message sent on each connection.
+	syncs := make(chan types.GUID, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				messages := make(chan types.Message, 8)
+				go func() {
+					_ = readMessages(context.Background(), conn, messages)
+					close(messages)
+				}()
+				first, ok := <-messages
+				if !ok {
+					return
+				}
+				syncs <- first.Client.GUID
+				if first.Client.GUID == guid {
+					// Report another client using this client's GUID.
+					impostor := newTestPeer("Hornet 1-1", coalitions.Blue, 251000000)
+					impostor.GUID = guid
+					sync := types.Message{Version: "2.1.0.10", Type: types.MessageSync, Clients: []types.ClientInfo{impostor}}
+					b, err := json.Marshal(sync)
+					if err != nil {
+						return
+					}
+					_, _ = conn.Write(append(b, '\n'))
+				}
+				for range messages {
+				}
+			}()
+		}
+	}()
+
+	client, err := NewClient(guid, types.ClientConfiguration{Address: listener.Addr().String(), RotateGUIDOnCollision: true})
+	require.NoError(t, err)
+	rotated := make(chan types.GUID, 1)
+	client.SetGUIDRotatedCallback(func(guid types.GUID) {
+		rotated <- guid
+	})
+	previous := client.LocalAddr()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	readyCh := make(chan any)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = client.Run(ctx, &wg, readyCh)
+	}()
+	<-readyCh
+
+	assert.Equal(t, guid, <-syncs)
+	select {
+	case newGUID := <-rotated:
+		assert.NotEqual(t, guid, newGUID)
+		assert.Equal(t, newGUID, <-syncs, "the client should join on the new connection with the new GUID")
+		assert.Equal(t, newGUID, client.(*dataClient).newMessageWithClient(types.MessageSync).Client.GUID)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "client did not rotate its GUID")
+	}
+	assert.NotEqual(t, previous.String(), client.LocalAddr().String())
+
+	require.NoError(t, client.Close())
+	wg.Wait()
+}
+
+func TestPeersOnFrequency(t *testing.T) {
+	t.Parallel()
+	names := func(peers []types.ClientInfo) []string {
+		result := make([]string, 0, len(peers))
+		for _, peer := range peers {
+			result = append(result, peer.Name)
+		}
+		return result
+	}
+	testCases := []struct {
+		listenAll bool
+		filter    []coalitions.Coalition
+		expected  []string
+	}{
+		{listenAll: false, expected: []string{"Hornet 1-1", "Observer"}},
+		{listenAll: false, filter: []coalitions.Coalition{coalitions.Red}, expected: []string{}},
+		{listenAll: true, expected: []string{"Flanker 1-1", "Hornet 1-1", "Observer"}},
+		{listenAll: true, filter: []coalitions.Coalition{coalitions.Red}, expected: []string{"Flanker 1-1"}},
+		{listenAll: true, filter: []coalitions.Coalition{coalitions.Blue, coalitions.Red}, expected: []string{"Flanker 1-1", "Hornet 1-1"}},
+	}
+	for _, test := range testCases {
+		t.Run(fmt.Sprintf("listenAll=%v,filter=%v", test.listenAll, test.filter), func(t *testing.T) {
+			t.Parallel()
+			c := newTestClient()
+			c.listenAll = test.listenAll
+			c.syncClients([]types.ClientInfo{
+				newTestPeer("Observer", coalitions.Neutrals, 251000000),
+				newTestPeer("Hornet 1-1", coalitions.Blue, 251000000),
+				newTestPeer("Flanker 1-1", coalitions.Red, 251000000),
+				newTestPeer("Viper 1-1", coalitions.Blue, 133000000),
+			})
+			assert.Equal(t, test.expected, names(c.PeersOnFrequency(test.filter...)))
+		})
+	}
+}
+
+func TestMetadataUpdatePreservesRadios(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	peer := newTestPeer("Hornet 1-1", coalitions.Blue, 251000000)
+	c.handleMessage(types.Message{Type: types.MessageRadioUpdate, Client: peer})
+	require.True(t, c.IsOnFrequency("Hornet 1-1"))
+
+	// A position-only update omits the client's radios.
+	position := &types.Position{Latitude: 41.5, Longitude: 42.5, Altitude: 3000}
+	c.handleMessage(types.Message{Type: types.MessageUpdate, Client: types.ClientInfo{
+		GUID:      peer.GUID,
+		Name:      peer.Name,
+		Coalition: peer.Coalition,
+		Position:  position,
+	}})
+	require.True(t, c.IsOnFrequency("Hornet 1-1"), "metadata update should not wipe radio info")
+	stored := c.clients[peer.GUID]
+	assert.Equal(t, peer.RadioInfo, stored.RadioInfo)
+	assert.Equal(t, position, stored.Position)
+
+	// A coalition change in a metadata update is still applied.
+	c.handleMessage(types.Message{Type: types.MessageUpdate, Client: types.ClientInfo{
+		GUID:      peer.GUID,
+		Name:      peer.Name,
+		Coalition: coalitions.Red,
+	}})
+	assert.False(t, c.IsOnFrequency("Hornet 1-1"))
+
+	// Metadata updates for unknown clients are ignored until their radios are known.
+	stranger := newTestPeer("Viper 1-1", coalitions.Blue, 251000000)
+	c.handleMessage(types.Message{Type: types.MessageUpdate, Client: types.ClientInfo{GUID: stranger.GUID, Name: stranger.Name, Coalition: coalitions.Blue}})
+	assert.False(t, c.IsOnFrequency("Viper 1-1"))
+	c.handleMessage(types.Message{Type: types.MessageRadioUpdate, Client: stranger})
+	assert.True(t, c.IsOnFrequency("Viper 1-1"))
+}
+
+func TestRadioUpdateWithoutRadios(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	peer := newTestPeer("Hornet 1-1", coalitions.Blue, 251000000)
+	c.handleMessage(types.Message{Type: types.MessageRadioUpdate, Client: peer})
+	require.True(t, c.IsOnFrequency("Hornet 1-1"))
+
+	// A peer which turns off all of its radios leaves the frequency.
+	peer.RadioInfo.Radios = nil
+	c.handleMessage(types.Message{Type: types.MessageRadioUpdate, Client: peer})
+	assert.False(t, c.IsOnFrequency("Hornet 1-1"))
+	assert.Zero(t, c.ClientsOnFrequency())
+}
+
+func TestPeerGUIDs(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	pilot := newTestPeer("Eagle 1-1", coalitions.Blue, 251000000)
+	wso := newTestPeer("Eagle 1-1", coalitions.Blue, 251000000)
+	c.syncClients([]types.ClientInfo{pilot, wso, newTestPeer("Viper 1-1", coalitions.Blue, 251000000)})
+	assert.ElementsMatch(t, []types.GUID{pilot.GUID, wso.GUID}, c.PeerGUIDs("Eagle 1-1"))
+	assert.Empty(t, c.PeerGUIDs("Hornet 1-1"))
+
+	peer, ok := c.Peer(wso.GUID)
+	require.True(t, ok)
+	assert.Equal(t, wso, peer)
+	_, ok = c.Peer(types.NewGUID())
+	assert.False(t, ok)
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	c.syncClients([]types.ClientInfo{
+		newTestPeer("Hornet 1-1", coalitions.Blue, 251000000),
+		newTestPeer("Viper 1-1", coalitions.Blue, 305000000),
+	})
+	c.disconnected.Store(true)
+	require.ErrorIs(t, c.Send(c.newMessage(types.MessageUpdate)), ErrNotConnected)
+
+	stats := c.Stats()
+	assert.Zero(t, stats.MessagesSent)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Equal(t, 1, stats.ClientsOnFrequency)
+	assert.Equal(t, 1, stats.PeakClientsOnFrequency)
+
+	// The peak is kept after peers leave the frequency.
+	c.syncClients(nil)
+	stats = c.Stats()
+	assert.Zero(t, stats.ClientsOnFrequency)
+	assert.Equal(t, 1, stats.PeakClientsOnFrequency)
+}
+
+func TestSynced(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	c.handleMessage(types.Message{Type: types.MessageRadioUpdate, Client: newTestPeer("Hornet 1-1", coalitions.Blue, 251000000)})
+	select {
+	case <-c.Synced():
+		require.Fail(t, "synced before the first sync message")
+	default:
+	}
+
+	c.handleMessage(types.Message{Type: types.MessageSync})
+	c.handleMessage(types.Message{Type: types.MessageSync})
+	select {
+	case <-c.Synced():
+	default:
+		require.Fail(t, "not synced after the first sync message")
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	var calls []string
+	c.SetServerVersionCallback(func(version string) {
+		calls = append(calls, version)
+	})
+	assert.Empty(t, c.ServerVersion())
+
+	c.handleMessage(types.Message{Version: "2.1.0.5", Type: types.MessageUpdate, Client: newTestPeer("Hornet 1-1", coalitions.Blue, 251000000)})
+	assert.Empty(t, c.ServerVersion(), "only server messages should set the version")
+
+	c.handleMessage(types.Message{Type: types.MessageServerSettings})
+	assert.Empty(t, c.ServerVersion())
+
+	c.handleMessage(types.Message{Version: "2.1.0.10", Type: types.MessageSync})
+	assert.Equal(t, "2.1.0.10", c.ServerVersion())
+
+	c.handleMessage(types.Message{Version: "2.1.0.11", Type: types.MessageServerSettings})
+	assert.Equal(t, "2.1.0.10", c.ServerVersion())
+	assert.Equal(t, []string{"2.1.0.10"}, calls)
+}
+
+func TestMessageCallback(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	c.messageCallbackCh = make(chan types.Message, 1)
+
+	// Without a callback, nothing is queued.
+	c.handleMessage(types.Message{Type: types.MessagePing})
+	assert.Empty(t, c.messageCallbackCh)
+
+	received := make(chan types.Message)
+	unblock := make(chan struct{})
+	c.SetMessageCallback(func(message types.Message) {
+		received <- message
+		<-unblock
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.dispatchMessages(ctx)
+
+	// The callback is called after the built-in handling, including for unrecognized message types.
+	peer := newTestPeer("Hornet 1-1", coalitions.Blue, 251000000)
+	c.handleMessage(types.Message{Type: types.MessageUpdate, Client: peer})
+	message := <-received
+	assert.Equal(t, types.MessageUpdate, message.Type)
+	assert.Equal(t, 1, c.ClientsOnFrequency())
+
+	unknown := types.MessageType(99)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The callback is blocked. The first message fills the queue, and the rest are dropped without blocking.
+		for range 5 {
+			c.handleMessage(types.Message{Type: unknown})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "handling messages blocked on a slow callback")
+	}
+	close(unblock)
+	assert.Equal(t, unknown, (<-received).Type)
+}
+
+func TestIsStale(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	c.markReceived(time.Now().Add(-2 * time.Minute))
+	assert.True(t, c.IsStale(time.Minute))
+
+	received := time.Now().Add(-30 * time.Second)
+	c.markReceived(received)
+	// Unlike Equal, == also compares monotonic clock readings, so this checks that storing the time kept its monotonic
+	// reading and IsStale measures liveness on the monotonic clock.
+	assert.True(t, received == c.LastReceived(), "liveness should use the monotonic clock")
+	assert.False(t, received.Round(0) == c.LastReceived())
+	assert.False(t, c.IsStale(time.Minute))
+	assert.True(t, c.IsStale(10*time.Second))
+}
+
+func TestFrequencyTolerance(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		tolerance unit.Frequency
+		expected  bool
+	}{
+		{tolerance: types.DefaultFrequencyTolerance, expected: false},
+		{tolerance: 2 * unit.Kilohertz, expected: true},
+	}
+	for _, test := range testCases {
+		t.Run(fmt.Sprint(test.tolerance), func(t *testing.T) {
+			t.Parallel()
+			c := newTestClient()
+			c.frequencyTolerance = test.tolerance
+			c.syncClients([]types.ClientInfo{newTestPeer("Hornet 1-1", coalitions.Blue, 251001500)})
+			assert.Equal(t, test.expected, c.IsOnFrequency("Hornet 1-1"))
+		})
+	}
+
+	_, err := NewClient(types.NewGUID(), types.ClientConfiguration{FrequencyTolerance: -1 * unit.Hertz})
+	require.ErrorContains(t, err, "frequency tolerance must not be negative")
+}
+
+func BenchmarkSyncClients(b *testing.B) {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	defer zerolog.SetGlobalLevel(level)
+
+	c := newTestClient()
+	others := make([]types.ClientInfo, 0, 500)
+	for i := range 500 {
+		var coalition coalitions.Coalition = coalitions.Blue
+		if i%2 == 0 {
+			coalition = coalitions.Red
+		}
+		others = append(others, newTestPeer(fmt.Sprintf("Player %d", i), coalition, 251000000))
+	}
+	b.ResetTimer()
+	for range b.N {
+		c.syncClients(others)
+	}
+}
+
+func TestLocalAddr(t *testing.T) {
+	t.Parallel()
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer listener.Close()
+	remote := make(chan net.Addr, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			remote <- conn.RemoteAddr()
+		}
+	}()
+
+	client, err := NewClient(types.NewGUID(), types.ClientConfiguration{Address: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+	local, ok := client.LocalAddr().(*net.TCPAddr)
+	require.True(t, ok)
+	assert.Positive(t, local.Port, "local address should include the ephemeral source port")
+	assert.Equal(t, (<-remote).String(), local.String())
+}
+
+func TestCloseWhileRunning(t *testing.T) {
+	t.Parallel()
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			_, _ = io.Copy(io.Discard, conn)
+		}
+	}()
+
+	client, err := NewClient(types.NewGUID(), types.ClientConfiguration{Address: listener.Addr().String()})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	readyCh := make(chan any)
+	runErr := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runErr <- client.Run(ctx, &wg, readyCh)
+	}()
+	<-readyCh
+
+	// Close concurrently from several goroutines and let Run tear down at the same time.
+	var closers sync.WaitGroup
+	closeErrs := make(chan error, 4)
+	for range 4 {
+		closers.Add(1)
+		go func() {
+			defer closers.Done()
+			closeErrs <- client.Close()
+		}()
+	}
+	closers.Wait()
+	close(closeErrs)
+	for err := range closeErrs {
+		require.NoError(t, err)
+	}
+
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Run did not return after Close")
+	}
+	wg.Wait()
+	require.NoError(t, client.Close())
+}
+
+// pipeDialer is a [types.TCPDialer] which connects to an in-memory pipe.
+type pipeDialer struct {
+	conn    net.Conn
+	network string
+	address string
+}
+
+func (d *pipeDialer) DialContext(_ context.Context, network, address string) (net.Conn, error) {
+	d.network = network
+	d.address = address
+	return d.conn, nil
+}
+
+func TestSendTimeout(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	c := newTestClient()
+	c.connection = clientConn
+	c.writeTimeout = 50 * time.Millisecond
+
+	// Nothing reads from the other end of the pipe, so the write stalls.
+	start := time.Now()
+	err := c.Send(c.newMessage(types.MessagePing))
+	require.ErrorIs(t, err, ErrSendTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// The deadline is reset for each message.
+	go func() {
+		_, _ = io.Copy(io.Discard, serverConn)
+	}()
+	require.NoError(t, c.Send(c.newMessage(types.MessagePing)))
+}
+
+func TestDisconnect(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	c := newTestClient()
+	c.connection = clientConn
+	c.writeTimeout = time.Second
+	c.closeCh = make(chan struct{})
+
+	messages := make(chan types.Message, 1)
+	go func() {
+		_ = readMessages(context.Background(), serverConn, messages)
+	}()
+	require.NoError(t, c.Disconnect())
+	message := <-messages
+	assert.Equal(t, types.MessageClientDisconnect, message.Type)
+	assert.Equal(t, c.clientInfo.GUID, message.Client.GUID)
+	require.ErrorIs(t, c.Send(c.newMessage(types.MessagePing)), ErrNotConnected)
+}
+
+func TestKicked(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	kicks := 0
+	c.SetKickedCallback(func() {
+		kicks++
+	})
+	peer := newTestPeer("Hornet 1-1", coalitions.Blue, 251000000)
+	c.syncClient(types.MessageRadioUpdate, peer)
+
+	// Another client disconnecting is a normal disconnect.
+	c.handleMessage(types.Message{Type: types.MessageClientDisconnect, Client: peer})
+	assert.False(t, c.HasAudience())
+	assert.False(t, c.kicked.Load())
+	assert.Zero(t, kicks)
+
+	// A disconnect naming this client is a kick.
+	c.handleMessage(types.Message{Type: types.MessageClientDisconnect, Client: c.clientInfo})
+	c.handleMessage(types.Message{Type: types.MessageClientDisconnect, Client: c.clientInfo})
+	assert.True(t, c.kicked.Load())
+	assert.Equal(t, 1, kicks)
+	require.ErrorIs(t, c.Send(c.newMessage(types.MessagePing)), ErrKicked)
+}
+
+func TestImpostorDisconnectIsNotKick(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	c.clientInfo.RadioInfo.UnitID = 100000002
+	kicks := 0
+	c.SetKickedCallback(func() {
+		kicks++
+	})
+
+	// A client using this client's GUID leaving is not a kick, whether or not it was seen before it left.
+	impostor := newTestPeer("Hornet 1-1", coalitions.Blue, 251000000)
+	impostor.GUID = c.clientInfo.GUID
+	c.handleMessage(types.Message{Type: types.MessageClientDisconnect, Client: impostor})
+	assert.False(t, c.kicked.Load())
+
+	// Once a colliding client has been seen, a disconnect naming only this client's GUID is ambiguous, so it is not a
+	// kick either.
+	c.syncClient(types.MessageRadioUpdate, impostor)
+	c.handleMessage(types.Message{Type: types.MessageClientDisconnect, Client: types.ClientInfo{GUID: c.clientInfo.GUID}})
+	assert.False(t, c.kicked.Load())
+	assert.Zero(t, kicks)
+}
+
+// runUntilClosedByServer runs a client against a server which closes the connection after receiving the client's first
+// message. If isShutdown is true, the server stops accepting connections before closing the connection, as an SRS
+// server does when it shuts down; otherwise it keeps accepting connections, as it does when it kicks a client or
+// restarts quickly. The error returned by Run is returned.
+func runUntilClosedByServer(t *testing.T, isShutdown bool) error {
+	t.Helper()
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = bufio.NewReader(conn).ReadBytes('\n')
+		if isShutdown {
+			_ = listener.Close()
+		}
+		_ = conn.Close()
+		// Accept and immediately close the probe connection, if any.
+		if probe, err := listener.Accept(); err == nil {
+			_ = probe.Close()
+		}
+	}()
+
+	client, err := NewClient(types.NewGUID(), types.ClientConfiguration{Address: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	readyCh := make(chan any)
+	runErr := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runErr <- client.Run(context.Background(), &wg, readyCh)
+	}()
+	<-readyCh
+
+	select {
+	case err = <-runErr:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "Run did not return after the server closed the connection")
+	}
+	require.NoError(t, client.Close())
+	wg.Wait()
+	return err
+}
+
+func TestRunReturnsWhenConnectionClosedByServer(t *testing.T) {
+	t.Parallel()
+	t.Run("kick", func(t *testing.T) {
+		t.Parallel()
+		err := runUntilClosedByServer(t, false)
+		require.ErrorIs(t, err, ErrPossiblyKicked)
+		require.ErrorIs(t, err, io.EOF)
+		// Without an explicit disconnect message, the client cannot tell a kick from a transient failure.
+		require.NotErrorIs(t, err, ErrKicked)
+	})
+	t.Run("shutdown", func(t *testing.T) {
+		t.Parallel()
+		err := runUntilClosedByServer(t, true)
+		require.ErrorIs(t, err, io.EOF)
+		require.NotErrorIs(t, err, ErrKicked)
+		require.NotErrorIs(t, err, ErrPossiblyKicked)
+	})
+}
+
+func TestRunReturnsWhenKicked(t *testing.T) {
+	t.Parallel()
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer listener.Close()
+	guid := types.NewGUID()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		kick := types.Message{Version: "2.1.0.10", Type: types.MessageClientDisconnect, Client: types.ClientInfo{GUID: guid}}
+		b, err := json.Marshal(kick)
+		if err != nil {
+			return
+		}
+		_, _ = conn.Write(append(b, '\n'))
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	client, err := NewClient(guid, types.ClientConfiguration{Address: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	readyCh := make(chan any)
+	runErr := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runErr <- client.Run(context.Background(), &wg, readyCh)
+	}()
+	<-readyCh
+
+	select {
+	case err := <-runErr:
+		require.ErrorIs(t, err, ErrKicked)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Run did not return after being kicked")
+	}
+	require.Error(t, client.Send(types.Message{Version: "2.1.0.2", Type: types.MessagePing}))
+	require.NoError(t, client.Close())
+	wg.Wait()
+}
+
+func TestSetName(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	c := newTestClient()
+	c.connection = clientConn
+	c.writeTimeout = 5 * time.Second
+
+	messages := make(chan types.Message, 1)
+	go func() {
+		_ = readMessages(context.Background(), serverConn, messages)
+	}()
+
+	require.Error(t, c.SetName(""))
+	assert.Equal(t, "GCI Sky Eye [BOT]", c.Name())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			_ = c.Name()
+		}
+	}()
+	require.NoError(t, c.SetName("GCI Magic [BOT]"))
+	wg.Wait()
+	assert.Equal(t, "GCI Magic [BOT]", c.Name())
+
+	select {
+	case message := <-messages:
+		assert.Equal(t, types.MessageUpdate, message.Type)
+		assert.Equal(t, "GCI Magic [BOT]", message.Client.Name)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for update message")
+	}
+}
+
+func TestAddRemoveRadio(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	c := newTestClient()
+	c.connection = clientConn
+	c.writeTimeout = 5 * time.Second
+
+	messages := make(chan types.Message, 3)
+	go func() {
+		_ = readMessages(context.Background(), serverConn, messages)
+	}()
+	receive := func() types.Message {
+		t.Helper()
+		select {
+		case message := <-messages:
+			return message
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for message")
+			return types.Message{}
+		}
+	}
+
+	working := types.Radio{Frequency: 251000000, Modulation: types.ModulationAM}
+	added := types.Radio{Frequency: 133000000, Modulation: types.ModulationAM}
+	require.NoError(t, c.AddRadio(added))
+	message := receive()
+	assert.Equal(t, types.MessageRadioUpdate, message.Type)
+	assert.Equal(t, []types.Radio{working, added}, message.Client.RadioInfo.Radios)
+	assert.Equal(t, types.MessageSync, receive().Type, "adding a radio should sync to discover its peers")
+	err := c.AddRadio(types.Radio{Frequency: 133000200, Modulation: types.ModulationAM})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotAnnounced, "a rejected radio should not be reported as added")
+
+	onWorking := newTestPeer("Mobius 1", coalitions.Blue, 251000000)
+	onAdded := newTestPeer("Yellow 13", coalitions.Blue, 133000000)
+	c.syncClients([]types.ClientInfo{onWorking, onAdded})
+	require.Equal(t, 2, c.ClientsOnFrequency())
+
+	require.NoError(t, c.RemoveRadio(133*unit.Megahertz))
+	message = receive()
+	assert.Equal(t, types.MessageRadioUpdate, message.Type)
+	assert.Equal(t, []types.Radio{working}, message.Client.RadioInfo.Radios)
+	assert.Equal(t, 1, c.ClientsOnFrequency())
+	_, ok := c.Peer(onAdded.GUID)
+	assert.False(t, ok, "peers only on the removed radio should be forgotten")
+	_, ok = c.Peer(onWorking.GUID)
+	assert.True(t, ok)
+	require.Error(t, c.RemoveRadio(133*unit.Megahertz))
+}
+
+func TestAddRadioNotAnnounced(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	c := newTestClient()
+	c.connection = clientConn
+	c.writeTimeout = 5 * time.Second
+	require.NoError(t, clientConn.Close())
+	added := types.Radio{Frequency: 133000000, Modulation: types.ModulationAM}
+	err := c.AddRadio(added)
+	require.ErrorIs(t, err, ErrNotAnnounced)
+	assert.Contains(t, c.radioInfo().Radios, added, "the radio should be added even if it cannot be announced")
+
+	err = c.RemoveRadio(133 * unit.Megahertz)
+	require.ErrorIs(t, err, ErrNotAnnounced)
+	assert.NotContains(t, c.radioInfo().Radios, added, "the radio should be removed even if it cannot be announced")
+}
+
+func TestSetRadiosHidden(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	c := newTestClient()
+	c.connection = clientConn
+	c.writeTimeout = 5 * time.Second
+
+	messages := make(chan types.Message, 2)
+	go func() {
+		_ = readMessages(context.Background(), serverConn, messages)
+	}()
+	receive := func() types.Message {
+		t.Helper()
+		select {
+		case message := <-messages:
+			return message
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for message")
+			return types.Message{}
+		}
+	}
+
+	peer := newTestPeer("Mobius 1", coalitions.Blue, 251000000)
+	c.syncClients([]types.ClientInfo{peer})
+
+	require.NoError(t, c.SetRadiosHidden(true))
+	message := receive()
+	assert.Equal(t, types.MessageRadioUpdate, message.Type)
+	assert.Equal(t, []types.Radio{{Frequency: 1, Modulation: types.ModulationDisabled}}, message.Client.RadioInfo.Radios)
+	assert.Equal(t, 1, c.ClientsOnFrequency(), "peers on the hidden radios should still be tracked")
+
+	require.NoError(t, c.SetRadiosHidden(false))
+	message = receive()
+	assert.Equal(t, []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}}, message.Client.RadioInfo.Radios)
+}
+
+// bufferTransport is a data transport which is not a network connection. Writes are buffered, and reads block until
+// the transport is closed.
+type bufferTransport struct {
+	bytes.Buffer
+	closeCh chan struct{}
+}
+
+func (t *bufferTransport) Read([]byte) (int, error) {
+	<-t.closeCh
+	return 0, io.EOF
+}
+
+func (t *bufferTransport) Close() error {
+	close(t.closeCh)
+	return nil
+}
+
+func (*bufferTransport) LocalAddr() net.Addr {
+	return &net.UnixAddr{Name: "buffer", Net: "unix"}
+}
+
+func (*bufferTransport) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func TestCustomTransport(t *testing.T) {
+	t.Parallel()
+	transport := &bufferTransport{closeCh: make(chan struct{})}
+	var dialed string
+	client, err := NewClient(types.NewGUID(), types.ClientConfiguration{
+		Address:    "srs.example.com:5002",
+		ClientName: "GCI Sky Eye [BOT]",
+		DataTransport: func(_ context.Context, network, address string) (types.DataTransport, error) {
+			dialed = network + " " + address
+			return transport, nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "tcp srs.example.com:5002", dialed)
+	c := client.(*dataClient)
+
+	require.NoError(t, c.sync())
+	var message types.Message
+	require.NoError(t, json.Unmarshal(transport.Bytes(), &message))
+	assert.Equal(t, types.MessageSync, message.Type)
+	assert.Equal(t, "GCI Sky Eye [BOT]", message.Client.Name)
+	assert.Equal(t, "buffer", c.LocalAddr().String())
+	require.NoError(t, c.close())
+
+	_, err = NewClient(types.NewGUID(), types.ClientConfiguration{
+		DataTransport: func(context.Context, string, string) (types.DataTransport, error) {
+			return transport, nil
+		},
+		TLS: types.TLSConfiguration{Enabled: true},
+	})
+	require.Error(t, err, "TLS cannot be applied to a custom transport")
+}
+
+func TestSendAfterClose(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	c := newTestClient()
+	c.connection = clientConn
+	c.closeCh = make(chan struct{})
+	c.writeTimeout = time.Second
+
+	require.NoError(t, c.Close())
+	err := c.Send(c.newMessage(types.MessagePing))
+	require.ErrorIs(t, err, ErrNotConnected)
+}
+
+func TestSyncMaxRadios(t *testing.T) {
+	t.Parallel()
+	radios := make([]types.Radio, 0, types.MaxRadios)
+	for i := range types.MaxRadios {
+		radios = append(radios, types.Radio{Frequency: float64(250000000 + i*1000000), Modulation: types.ModulationAM})
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	dialer := &pipeDialer{conn: clientConn}
+	client, err := NewClient(types.NewGUID(), types.ClientConfiguration{Address: "srs.example.com:5002", TCPDialer: dialer, Radios: radios})
+	require.NoError(t, err)
+	defer client.Close()
+
+	messages := make(chan types.Message, 1)
+	go func() {
+		_ = readMessages(context.Background(), serverConn, messages)
+	}()
+	require.NoError(t, client.(*dataClient).sync())
+	select {
+	case message := <-messages:
+		assert.Equal(t, types.MessageSync, message.Type)
+		assert.Equal(t, radios, message.Client.RadioInfo.Radios)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for sync message")
+	}
+
+	_, err = NewClient(types.NewGUID(), types.ClientConfiguration{Address: "srs.example.com:5002", TCPDialer: dialer, Radios: append(radios, radios[0])})
+	require.ErrorContains(t, err, "at most")
+}
+
+func TestCustomDialer(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	dialer := &pipeDialer{conn: clientConn}
+
+	client, err := NewClient(types.NewGUID(), types.ClientConfiguration{Address: "srs.example.com:5002", TCPDialer: dialer})
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, "tcp", dialer.network)
+	assert.Equal(t, "srs.example.com:5002", dialer.address)
+}
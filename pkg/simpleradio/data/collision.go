@@ -0,0 +1,109 @@
+package data
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/internal/callbacks"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+)
+
+// isGUIDCollision returns true if the given client reports this client's GUID but is a different client. The SRS
+// server echoes this client's own information back to it, so a client with the same GUID is only a collision if its
+// name or unit ID differs.
+func (c *dataClient) isGUIDCollision(other types.ClientInfo) bool {
+	if other.GUID != c.clientInfo.GUID {
+		return false
+	}
+	if other.Name != "" && !c.isOwnName(other.Name) {
+		return true
+	}
+	unitID := c.clientInfo.RadioInfo.UnitID
+	return other.RadioInfo.UnitID != 0 && unitID != 0 && other.RadioInfo.UnitID != unitID
+}
+
+// isOwnName returns true if the given name is this client's current name, or a name it had before a rename which the
+// SRS server has not yet handled. Once the server echoes the current name, the previous names are forgotten.
+func (c *dataClient) isOwnName(name string) bool {
+	c.clientInfoLock.Lock()
+	defer c.clientInfoLock.Unlock()
+	if name == c.clientInfo.Name {
+		c.previousNames = nil
+		return true
+	}
+	return slices.Contains(c.previousNames, name)
+}
+
+// checkGUIDCollision logs a warning and calls the GUID collision callback the first time each colliding client is
+// seen. If GUID rotation is enabled, the client then rotates its GUID once the current message is handled.
+func (c *dataClient) checkGUIDCollision(other types.ClientInfo) {
+	if !c.isGUIDCollision(other) {
+		return
+	}
+	key := other.Name + "/" + strconv.FormatUint(other.RadioInfo.UnitID, 10)
+	if c.reportedCollisions[key] {
+		return
+	}
+	if c.reportedCollisions == nil {
+		c.reportedCollisions = make(map[string]bool)
+	}
+	c.reportedCollisions[key] = true
+	c.logger.Error().
+		Str("guid", string(other.GUID)).
+		Str("name", other.Name).
+		Uint64("unitID", other.RadioInfo.UnitID).
+		Msg("another SRS client is using this client's GUID; the server may confuse the two clients, and this client may be invisible to its peers")
+	if callback := callbacks.Load(&c.guidCollisionCallback); callback != nil {
+		callback(other)
+	}
+	if c.rotateGUIDOnCollision {
+		c.isGUIDRotationPending = true
+	}
+}
+
+// rotateGUID reconnects to the SRS server with a new GUID, so that this client is no longer confused with a client
+// using its GUID, then calls the GUID rotated callback. The current reader is stopped and a reader for the new
+// connection is returned. If the new connection cannot be established, the client keeps its current connection and
+// GUID, and the current reader is returned. It is only called by the Run goroutine.
+func (c *dataClient) rotateGUID(ctx context.Context, wg *sync.WaitGroup, current connectionReader) (connectionReader, error) {
+	connection, err := c.openTransport(ctx, c.network, c.address)
+	if err != nil {
+		c.logger.Error().Err(err).Msg("failed to reconnect to SRS server to rotate GUID; keeping the colliding GUID")
+		return current, nil
+	}
+
+	c.connectionLock.Lock()
+	if c.disconnected.Load() {
+		c.connectionLock.Unlock()
+		_ = connection.Close()
+		return current, ErrNotConnected
+	}
+	previous := c.connection
+	c.connection = connection
+	c.connectionLock.Unlock()
+
+	// Stop the current reader before closing its connection, so that it exits without reporting an error.
+	current.stop()
+	if err := previous.Close(); err != nil {
+		c.logger.Warn().Err(err).Msg("error closing previous TCP connection to SRS")
+	}
+
+	guid := types.NewGUID()
+	c.clientInfoLock.Lock()
+	previousGUID := c.clientInfo.GUID
+	c.clientInfo.GUID = guid
+	c.clientInfoLock.Unlock()
+	c.reportedCollisions = nil
+	c.logger.Warn().Str("previous", string(previousGUID)).Str("guid", string(guid)).Msg("reconnected to SRS server with a new GUID")
+
+	reader := c.startReader(ctx, wg, connection)
+	if err := c.join(); err != nil {
+		return reader, err
+	}
+	if callback := callbacks.Load(&c.guidRotatedCallback); callback != nil {
+		callback(guid)
+	}
+	return reader, nil
+}
@@ -0,0 +1,49 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+)
+
+// kickProbeTimeout is the maximum time to wait for the SRS server to accept a connection when checking whether a
+// closed connection may have been a kick.
+const kickProbeTimeout = 5 * time.Second
+
+// isSelfDisconnect returns true if a disconnect message names this client. Another client using this client's GUID
+// sends a disconnect with this client's GUID when it leaves, so a disconnect is only treated as this client's own if it
+// does not collide and no colliding client has been seen. It is only called by the Run goroutine.
+func (c *dataClient) isSelfDisconnect(other types.ClientInfo) bool {
+	if other.GUID != c.clientInfo.GUID {
+		return false
+	}
+	if c.isGUIDCollision(other) {
+		return false
+	}
+	if len(c.reportedCollisions) > 0 {
+		c.logger.Warn().Msg("ignoring disconnect naming this client's GUID, since another client has been using it")
+		return false
+	}
+	return true
+}
+
+// isPossibleKick returns true if a connection closed by the SRS server may have been a kick. SRS kicks and bans a client
+// by closing its TCP connection without sending a message first, which looks the same on the wire as the server
+// shutting down. A shutdown is ruled out by checking whether the server still accepts new connections, but a transient
+// network failure or a server which restarted quickly cannot be ruled out. The probe connection is closed without
+// sending a message, so the server never adds a client for it.
+func (c *dataClient) isPossibleKick(ctx context.Context) bool {
+	if c.openTransport == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, kickProbeTimeout)
+	defer cancel()
+	connection, err := c.openTransport(ctx, c.network, c.address)
+	if err != nil {
+		c.logger.Debug().Err(err).Msg("SRS server is not accepting connections, so the connection was not closed by a kick")
+		return false
+	}
+	_ = connection.Close()
+	return true
+}
@@ -0,0 +1,95 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+)
+
+// radioInfo returns this client's radio information. When radios are added or removed, the radios slice is replaced
+// rather than modified, so the returned value remains safe to use after clientInfoLock is released.
+func (c *dataClient) radioInfo() types.RadioInfo {
+	c.clientInfoLock.RLock()
+	defer c.clientInfoLock.RUnlock()
+	return c.clientInfo.RadioInfo
+}
+
+// AddRadio implements [DataClient.AddRadio].
+func (c *dataClient) AddRadio(radio types.Radio) error {
+	c.clientInfoLock.Lock()
+	radios, err := types.AddRadio(c.clientInfo.RadioInfo.Radios, radio, c.frequencyTolerance)
+	if err == nil {
+		c.clientInfo.RadioInfo.Radios = radios
+	}
+	c.clientInfoLock.Unlock()
+	if err != nil {
+		return err
+	}
+	c.logger.Info().Func(types.LogFrequency(types.FrequencyFromHertz(radio.Frequency))).Msg("added radio")
+
+	if err := c.updateRadios(); err != nil {
+		return fmt.Errorf("failed to announce added radio: %w: %w", ErrNotAnnounced, err)
+	}
+	// Peers are only stored while they share a frequency with this client, so peers already on the new frequency are
+	// unknown until the next sync.
+	if err := c.sync(); err != nil {
+		return fmt.Errorf("failed to sync after adding radio: %w: %w", ErrNotAnnounced, err)
+	}
+	return nil
+}
+
+// RemoveRadio implements [DataClient.RemoveRadio].
+func (c *dataClient) RemoveRadio(frequency unit.Frequency) error {
+	c.clientInfoLock.Lock()
+	radios, _, err := types.RemoveRadio(c.clientInfo.RadioInfo.Radios, frequency, c.frequencyTolerance)
+	if err == nil {
+		c.clientInfo.RadioInfo.Radios = radios
+	}
+	c.clientInfoLock.Unlock()
+	if err != nil {
+		return err
+	}
+	c.logger.Info().Func(types.LogFrequency(frequency)).Msg("removed radio")
+	c.removePeersOffFrequency()
+
+	if err := c.updateRadios(); err != nil {
+		return fmt.Errorf("failed to announce removed radio: %w: %w", ErrNotAnnounced, err)
+	}
+	return nil
+}
+
+// removePeersOffFrequency forgets peers which no longer share a frequency with this client.
+func (c *dataClient) removePeersOffFrequency() {
+	radioInfo := c.radioInfo()
+	c.clientsLock.Lock()
+	defer c.clientsLock.Unlock()
+	for guid, client := range c.clients {
+		if !radioInfo.IsOnFrequencyWithin(client.RadioInfo, c.frequencyTolerance) {
+			delete(c.clients, guid)
+		}
+	}
+}
+
+// SetRadiosHidden implements [DataClient.SetRadiosHidden].
+func (c *dataClient) SetRadiosHidden(isHidden bool) error {
+	c.clientInfoLock.Lock()
+	c.isRadiosHidden = isHidden
+	c.clientInfoLock.Unlock()
+	c.logger.Info().Bool("hidden", isHidden).Msg("changed radio visibility")
+
+	if err := c.updateRadios(); err != nil {
+		return fmt.Errorf("failed to announce radio visibility: %w", err)
+	}
+	return nil
+}
+
+// switchedOff returns a switched-off radio in place of each of the given radios. SRS represents an unused radio slot
+// this way, so the client keeps announcing the same number of radio slots while hidden.
+func switchedOff(radios []types.Radio) []types.Radio {
+	off := make([]types.Radio, len(radios))
+	for i := range off {
+		off[i] = types.Radio{Frequency: 1, Modulation: types.ModulationDisabled}
+	}
+	return off
+}
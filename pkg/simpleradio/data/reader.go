@@ -0,0 +1,47 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/rs/zerolog/log"
+)
+
+// readMessages reads newline-delimited JSON messages from the given reader and publishes them to the given channel.
+// Messages split across multiple reads are buffered until the terminating newline is received. Malformed lines are
+// logged and skipped; because each message occupies exactly one line, a malformed line does not affect the lines
+// after it. If the reader reaches EOF, any final message without a trailing newline is still published.
+//
+// readMessages returns nil when the context is canceled, or an error when the reader fails or is closed.
+func readMessages(ctx context.Context, r io.Reader, out chan<- types.Message) error {
+	reader := bufio.NewReader(r)
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+		line, err := reader.ReadBytes(byte('\n'))
+		if len(bytes.TrimSpace(line)) > 0 {
+			var message types.Message
+			if jsonErr := json.Unmarshal(line, &message); jsonErr != nil {
+				log.Warn().Str("text", string(line)).Err(jsonErr).Msg("failed to unmarshal message")
+			} else {
+				select {
+				case out <- message:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("connection closed by SRS server: %w", err)
+		} else if err != nil {
+			return fmt.Errorf("error reading from SRS server: %w", err)
+		}
+	}
+}
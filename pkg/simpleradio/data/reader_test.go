@@ -0,0 +1,198 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll runs readMessages over the given chunks, written one at a time with a short pause between each, and returns
+// the messages that were read and the error returned by readMessages.
+func readAll(t *testing.T, chunks ...string) ([]types.Message, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r, w := io.Pipe()
+	go func() {
+		for _, chunk := range chunks {
+			_, _ = w.Write([]byte(chunk))
+			time.Sleep(10 * time.Millisecond)
+		}
+		_ = w.Close()
+	}()
+
+	out := make(chan types.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- readMessages(ctx, r, out)
+	}()
+
+	messages := make([]types.Message, 0)
+	for {
+		select {
+		case m := <-out:
+			messages = append(messages, m)
+		case err := <-errCh:
+			return messages, err
+		case <-ctx.Done():
+			require.FailNow(t, "timed out reading messages")
+		}
+	}
+}
+
+func TestReadMessagesWholeLines(t *testing.T) {
+	t.Parallel()
+	messages, err := readAll(t,
+		`{"Version":"2.1.0.2","MsgType":1}`+"\n",
+		`{"Version":"2.1.0.2","MsgType":2}`+"\n",
+	)
+	require.ErrorIs(t, err, io.EOF)
+	require.Len(t, messages, 2)
+	assert.Equal(t, types.MessagePing, messages[0].Type)
+	assert.Equal(t, types.MessageSync, messages[1].Type)
+}
+
+func TestReadMessagesChunked(t *testing.T) {
+	t.Parallel()
+	messages, err := readAll(t,
+		`{"Version":"2.1`,
+		`.0.2","Client":{"Name":"Hor`,
+		`net 1-1"},"MsgType":0}`,
+		"\n"+`{"Version":"2.1.0.2","MsgType":1}`+"\n",
+	)
+	require.ErrorIs(t, err, io.EOF)
+	require.Len(t, messages, 2)
+	assert.Equal(t, types.MessageUpdate, messages[0].Type)
+	assert.Equal(t, "Hornet 1-1", messages[0].Client.Name)
+	assert.Equal(t, types.MessagePing, messages[1].Type)
+}
+
+func TestReadMessagesMissingFinalNewline(t *testing.T) {
+	t.Parallel()
+	messages, err := readAll(t,
+		`{"Version":"2.1.0.2","MsgType":1}`+"\n",
+		`{"Version":"2.1.0.2","MsgType":5}`,
+	)
+	require.ErrorIs(t, err, io.EOF)
+	require.Len(t, messages, 2)
+	assert.Equal(t, types.MessageClientDisconnect, messages[1].Type)
+}
+
+func TestReadMessagesSkipsMalformedLines(t *testing.T) {
+	t.Parallel()
+	messages, err := readAll(t,
+		`{"Version":"2.1.0.2","MsgType":1}`+"\n",
+		`{"Version":"2.1.0.2","MsgT`+"\n",
+		"\n",
+		"not json at all\n",
+		`{"Version":"2.1.0.2","MsgType":2}`+"\n",
+	)
+	require.ErrorIs(t, err, io.EOF)
+	require.Len(t, messages, 2)
+	assert.Equal(t, types.MessagePing, messages[0].Type)
+	assert.Equal(t, types.MessageSync, messages[1].Type)
+}
+
+func TestReadMessagesContextCanceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	r, w := io.Pipe()
+	defer w.Close()
+	out := make(chan types.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- readMessages(ctx, r, out)
+	}()
+	go func() {
+		_, _ = w.Write([]byte(`{"Version":"2.1.0.2","MsgType":1}` + "\n"))
+	}()
+	// Nobody reads from out, so readMessages is blocked publishing the message until the context is canceled.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "readMessages did not return after context cancellation")
+	}
+}
+
+// BenchmarkMessageBuffer measures how long the SRS server is blocked writing a burst of messages to the client, for
+// several sizes of the buffer between the reader and the message handler. The burst is a sync message followed by one
+// radio update for each of 250 players, as when many players join or retune at once. Handling the sync message stalls
+// for a few milliseconds, as when the handler sends a message to a slow server. The write-ns/op metric is the time
+// taken to write the burst, which falls to the time taken to parse it once the buffer holds the whole burst.
+func BenchmarkMessageBuffer(b *testing.B) {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	defer zerolog.SetGlobalLevel(level)
+
+	const players = 250
+	const stall = 5 * time.Millisecond
+	clients := make([]types.ClientInfo, 0, players)
+	for i := range players {
+		clients = append(clients, newTestPeer(fmt.Sprintf("Player %d", i), coalitions.Blue, 251000000))
+	}
+	var burst [][]byte
+	sync, err := json.Marshal(types.Message{Type: types.MessageSync, Clients: clients})
+	if err != nil {
+		b.Fatal(err)
+	}
+	burst = append(burst, append(sync, '\n'))
+	for _, client := range clients {
+		client.RadioInfo.Radios[0].Frequency = 305000000
+		update, err := json.Marshal(types.Message{Type: types.MessageRadioUpdate, Client: client})
+		if err != nil {
+			b.Fatal(err)
+		}
+		burst = append(burst, append(update, '\n'))
+	}
+
+	for _, size := range []int{1, 16, 64, 128, messageBufferSize, 1024} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			var writing time.Duration
+			for range b.N {
+				c := newTestClient()
+				r, w := io.Pipe()
+				messages := make(chan types.Message, size)
+				go func() {
+					_ = readMessages(context.Background(), r, messages)
+					close(messages)
+				}()
+				handled := make(chan struct{})
+				go func() {
+					defer close(handled)
+					for message := range messages {
+						if message.Type == types.MessageSync {
+							time.Sleep(stall)
+							c.syncClients(message.Clients)
+						} else {
+							c.syncClient(message.Type, message.Client)
+						}
+					}
+				}()
+
+				start := time.Now()
+				for _, line := range burst {
+					if _, err := w.Write(line); err != nil {
+						b.Fatal(err)
+					}
+				}
+				writing += time.Since(start)
+				_ = w.Close()
+				<-handled
+			}
+			b.ReportMetric(float64(writing.Nanoseconds())/float64(b.N), "write-ns/op")
+		})
+	}
+}
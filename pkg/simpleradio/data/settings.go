@@ -0,0 +1,90 @@
+package data
+
+import (
+	"strings"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/internal/callbacks"
+)
+
+// Server setting keys which affect authentication. See ServerSettingsKeys in the SRS source code.
+const (
+	// settingCoalitionAudioSecurity restricts each client to hearing only its own coalition. Clients in External AWACS
+	// Mode must authenticate to be assigned a coalition.
+	settingCoalitionAudioSecurity = "COALITION_AUDIO_SECURITY"
+	// settingExternalAWACSMode enables External AWACS Mode.
+	settingExternalAWACSMode = "EXTERNAL_AWACS_MODE"
+	// settingSpectatorsAudioDisabled stops the server from relaying spectators' transmissions.
+	settingSpectatorsAudioDisabled = "SPECTATORS_AUDIO_DISABLED"
+)
+
+// authenticationSettings are the server settings which require the client to authenticate when they are enabled.
+var authenticationSettings = []string{settingCoalitionAudioSecurity, settingExternalAWACSMode}
+
+// isSettingEnabled returns true if the given boolean server setting is enabled.
+func isSettingEnabled(settings map[string]string, key string) bool {
+	return strings.EqualFold(settings[key], "true")
+}
+
+// updateServerSettings records the given server settings. If a setting which requires authentication was enabled
+// after the client connected, the client re-authenticates, since otherwise the server would silently stop relaying
+// its transmissions.
+func (c *dataClient) updateServerSettings(settings map[string]string) {
+	if settings == nil {
+		return
+	}
+	previous := c.serverSettings
+	c.serverSettings = settings
+	c.updateTransmitPermission(settings)
+	if previous == nil {
+		// The client authenticates when it first connects, so there is nothing to do for the initial settings.
+		return
+	}
+	if c.isSpectator {
+		// Spectators do not authenticate, since authenticating would assign the client a coalition.
+		return
+	}
+	for _, key := range authenticationSettings {
+		if isSettingEnabled(settings, key) && !isSettingEnabled(previous, key) {
+			c.logger.Warn().Str("setting", key).Msg("SRS server enabled a setting which requires authentication, re-authenticating")
+			if err := c.connectExternalAWACSMode(); err != nil {
+				c.logger.Error().Err(err).Msg("failed to re-authenticate")
+			}
+			return
+		}
+	}
+}
+
+// isTransmitPermitted returns false if the given server settings forbid this client from transmitting. The client
+// connects in External AWACS Mode, so it may not transmit if the server explicitly disables External AWACS Mode. A
+// spectator does not use External AWACS Mode, but may not transmit if the server disables spectator audio. If the
+// relevant setting is absent, transmission is assumed to be permitted.
+func isTransmitPermitted(settings map[string]string, isSpectator bool) bool {
+	if isSpectator {
+		return !isSettingEnabled(settings, settingSpectatorsAudioDisabled)
+	}
+	value, ok := settings[settingExternalAWACSMode]
+	return !ok || strings.EqualFold(value, "true")
+}
+
+// updateTransmitPermission calls the transmit permission callback if the given server settings change whether this
+// client may transmit.
+func (c *dataClient) updateTransmitPermission(settings map[string]string) {
+	isPermitted := isTransmitPermitted(settings, c.isSpectator)
+	if isPermitted == !c.isTransmitForbidden {
+		return
+	}
+	c.isTransmitForbidden = !isPermitted
+	switch {
+	case c.isSpectator && isPermitted:
+		c.logger.Info().Msg("SRS server permits spectator audio, resuming transmission")
+	case c.isSpectator:
+		c.logger.Warn().Msg("SRS server has disabled spectator audio, so this client cannot transmit; listening only")
+	case isPermitted:
+		c.logger.Info().Msg("SRS server permits External AWACS Mode, resuming transmission")
+	default:
+		c.logger.Warn().Msg("SRS server has disabled External AWACS Mode, so this client cannot transmit; listening only")
+	}
+	if callback := callbacks.Load(&c.transmitPermissionCallback); callback != nil {
+		callback(isPermitted)
+	}
+}
@@ -0,0 +1,133 @@
+package data
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateServerSettings(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name                 string
+		settings             []map[string]string
+		expectAuthentication bool
+	}{
+		{
+			name:     "initial settings",
+			settings: []map[string]string{{settingExternalAWACSMode: "True"}},
+		},
+		{
+			name: "unchanged",
+			settings: []map[string]string{
+				{settingExternalAWACSMode: "True"},
+				{settingExternalAWACSMode: "True"},
+			},
+		},
+		{
+			name: "missing settings",
+			settings: []map[string]string{
+				{settingExternalAWACSMode: "False"},
+				nil,
+			},
+		},
+		{
+			name: "coalition security enabled",
+			settings: []map[string]string{
+				{settingExternalAWACSMode: "True", settingCoalitionAudioSecurity: "False"},
+				{settingExternalAWACSMode: "True", settingCoalitionAudioSecurity: "True"},
+			},
+			expectAuthentication: true,
+		},
+		{
+			name: "external AWACS mode enabled",
+			settings: []map[string]string{
+				{settingExternalAWACSMode: "False"},
+				{settingExternalAWACSMode: "True"},
+			},
+			expectAuthentication: true,
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			clientConn, serverConn := net.Pipe()
+			defer serverConn.Close()
+			c := newTestClient()
+			c.connection = clientConn
+			c.writeTimeout = 100 * time.Millisecond
+			c.externalAWACSModePassword = "hunter2"
+
+			messages := make(chan types.Message, 1)
+			go func() {
+				_ = readMessages(context.Background(), serverConn, messages)
+			}()
+
+			for _, settings := range test.settings {
+				c.handleMessage(types.Message{Version: "2.1.0.10", Type: types.MessageServerSettings, ServerSettings: settings})
+			}
+			if test.expectAuthentication {
+				message := <-messages
+				assert.Equal(t, types.MessageExternalAWACSModePassword, message.Type)
+				assert.Equal(t, "hunter2", message.ExternalAWACSModePassword)
+			} else {
+				assert.Empty(t, messages)
+			}
+		})
+	}
+}
+
+func TestAuthenticationLost(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	called := false
+	c.SetAuthenticationLostCallback(func() {
+		called = true
+	})
+	c.handleMessage(types.Message{Type: types.MessageExternalAWACSModeDisconnect})
+	require.True(t, called)
+}
+
+func TestTransmitPermission(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	var events []bool
+	c.SetTransmitPermissionCallback(func(isPermitted bool) {
+		events = append(events, isPermitted)
+	})
+
+	// Transmission is permitted until the server's settings forbid it.
+	c.updateTransmitPermission(map[string]string{settingCoalitionAudioSecurity: "True"})
+	c.updateTransmitPermission(map[string]string{settingExternalAWACSMode: "True"})
+	assert.Empty(t, events)
+
+	c.updateServerSettings(map[string]string{settingExternalAWACSMode: "False"})
+	c.updateServerSettings(map[string]string{settingExternalAWACSMode: "false"})
+	assert.Equal(t, []bool{false}, events)
+
+	c.updateTransmitPermission(map[string]string{settingExternalAWACSMode: "True"})
+	assert.Equal(t, []bool{false, true}, events)
+}
+
+func TestSpectatorTransmitPermission(t *testing.T) {
+	t.Parallel()
+	c := newTestClient()
+	c.isSpectator = true
+	var events []bool
+	c.SetTransmitPermissionCallback(func(isPermitted bool) {
+		events = append(events, isPermitted)
+	})
+
+	// External AWACS Mode does not apply to spectators, but spectator audio does.
+	c.updateServerSettings(map[string]string{settingExternalAWACSMode: "False", settingSpectatorsAudioDisabled: "False"})
+	assert.Empty(t, events)
+	c.updateServerSettings(map[string]string{settingExternalAWACSMode: "True", settingSpectatorsAudioDisabled: "True"})
+	assert.Equal(t, []bool{false}, events)
+	c.updateServerSettings(map[string]string{settingExternalAWACSMode: "True"})
+	assert.Equal(t, []bool{false, true}, events)
+}
@@ -0,0 +1,54 @@
+package data
+
+import (
+	"time"
+)
+
+// ClientStats is a point-in-time snapshot of the data client's counters, for operators who poll statistics into their
+// own monitoring system.
+type ClientStats struct {
+	// MessagesSent is the number of messages sent to the SRS server.
+	MessagesSent uint64
+	// MessagesReceived is the number of messages received from the SRS server.
+	MessagesReceived uint64
+	// Errors is the number of messages which could not be sent.
+	Errors uint64
+	// LastReceived is the time a message was most recently received from the SRS server.
+	LastReceived time.Time
+	// ClientsOnFrequency is the number of peers on the client's frequency.
+	ClientsOnFrequency int
+	// PeakClientsOnFrequency is the largest number of peers which have been on the client's frequency at once.
+	PeakClientsOnFrequency int
+}
+
+// Stats implements [DataClient.Stats].
+func (c *dataClient) Stats() ClientStats {
+	c.clientsLock.RLock()
+	defer c.clientsLock.RUnlock()
+	return ClientStats{
+		MessagesSent:           c.messagesSent.Load(),
+		MessagesReceived:       c.messagesReceived.Load(),
+		Errors:                 c.sendErrors.Load(),
+		LastReceived:           c.LastReceived(),
+		ClientsOnFrequency:     c.countOnFrequency(),
+		PeakClientsOnFrequency: c.peakClientsOnFrequency,
+	}
+}
+
+// countOnFrequency returns the number of peers on the client's frequency. The caller must hold clientsLock.
+func (c *dataClient) countOnFrequency() int {
+	radioInfo := c.radioInfo()
+	count := 0
+	for _, client := range c.clients {
+		if radioInfo.IsOnFrequencyWithin(client.RadioInfo, c.frequencyTolerance) {
+			count++
+		}
+	}
+	return count
+}
+
+// updatePeak records the number of peers on the client's frequency if it is a new peak. The caller must hold
+// clientsLock for writing.
+func (c *dataClient) updatePeak() {
+	c.peakClientsOnFrequency = max(c.peakClientsOnFrequency, c.countOnFrequency())
+}
@@ -0,0 +1,56 @@
+package data
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+)
+
+// newTLSConfig builds the TLS configuration for connecting to the SRS server at the given address.
+func newTLSConfig(config types.TLSConfiguration, address string) (*tls.Config, error) {
+	serverName := config.ServerName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SRS server address %v: %w", address, err)
+		}
+		serverName = host
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify, //nolint:gosec // opt-in for testing
+	}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file %v: %w", config.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %v", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// handshakeTLS performs a TLS client handshake over the given connection. Certificate verification failures are
+// reported distinctly from other handshake errors, since they usually indicate a configuration problem.
+func handshakeTLS(ctx context.Context, connection net.Conn, tlsConfig *tls.Config) (*tls.Conn, error) {
+	tlsConnection := tls.Client(connection, tlsConfig)
+	if err := tlsConnection.HandshakeContext(ctx); err != nil {
+		var verificationErr *tls.CertificateVerificationError
+		if errors.As(err, &verificationErr) {
+			return nil, fmt.Errorf("failed to verify SRS server TLS certificate: %w", err)
+		}
+		return nil, fmt.Errorf("TLS handshake with SRS server failed: %w", err)
+	}
+	return tlsConnection, nil
+}
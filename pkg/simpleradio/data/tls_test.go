@@ -0,0 +1,67 @@
+package data
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	address := server.Listener.Addr().String()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+
+	testCases := []struct {
+		name          string
+		config        types.TLSConfiguration
+		expectedError string
+	}{
+		{
+			name:          "untrusted certificate",
+			config:        types.TLSConfiguration{Enabled: true},
+			expectedError: "failed to verify SRS server TLS certificate",
+		},
+		{
+			name:   "trusted CA",
+			config: types.TLSConfiguration{Enabled: true, CAFile: caFile},
+		},
+		{
+			name:          "wrong server name",
+			config:        types.TLSConfiguration{Enabled: true, CAFile: caFile, ServerName: "srs.invalid"},
+			expectedError: "failed to verify SRS server TLS certificate",
+		},
+		{
+			name:   "skip verification",
+			config: types.TLSConfiguration{Enabled: true, InsecureSkipVerify: true},
+		},
+		{
+			name:          "missing CA file",
+			config:        types.TLSConfiguration{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+			expectedError: "invalid TLS configuration",
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			client, err := NewClient(types.NewGUID(), types.ClientConfiguration{Address: address, TLS: test.config})
+			if test.expectedError != "" {
+				require.ErrorContains(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, client.Close())
+		})
+	}
+}
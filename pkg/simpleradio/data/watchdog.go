@@ -0,0 +1,34 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultDataTimeout is the default maximum time to wait for data from the SRS server before treating the connection
+// as dead. It is long enough to tolerate a slow server under heavy load.
+const defaultDataTimeout = 2 * time.Minute
+
+// ErrDataTimeout is returned by Run when no data has been received from the SRS server within the data timeout, even
+// after probing the server. This usually means the server or network has failed without closing the connection.
+var ErrDataTimeout = errors.New("no data received from SRS server")
+
+// checkLiveness is called periodically by Run. The SRS server only sends data when something changes, so a quiet
+// connection is not necessarily dead. When half the data timeout has passed without data, the client requests a sync,
+// which a healthy server always answers. If the full timeout passes without data, an error wrapping [ErrDataTimeout] is
+// returned.
+func (c *dataClient) checkLiveness() error {
+	quiet := time.Since(c.LastReceived())
+	if quiet > c.dataTimeout {
+		c.logger.Error().Stringer("quiet", quiet).Msg("SRS server stopped sending data")
+		return fmt.Errorf("%w in %v", ErrDataTimeout, quiet.Round(time.Second))
+	}
+	if quiet > c.dataTimeout/2 {
+		c.logger.Debug().Stringer("quiet", quiet).Msg("probing quiet SRS server with a sync message")
+		if err := c.sync(); err != nil {
+			c.logger.Warn().Err(err).Msg("failed to probe SRS server")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLiveness(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	c := newTestClient()
+	c.connection = clientConn
+	c.writeTimeout = 5 * time.Second
+	c.dataTimeout = time.Minute
+
+	messages := make(chan types.Message, 1)
+	go func() {
+		_ = readMessages(context.Background(), serverConn, messages)
+	}()
+
+	// A connection which recently received data is left alone.
+	c.markReceived(time.Now().Add(-10 * time.Second))
+	require.NoError(t, c.checkLiveness())
+	assert.Empty(t, messages)
+
+	// A quiet connection is probed with a sync message.
+	c.markReceived(time.Now().Add(-40 * time.Second))
+	require.NoError(t, c.checkLiveness())
+	select {
+	case message := <-messages:
+		assert.Equal(t, types.MessageSync, message.Type)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for sync message")
+	}
+
+	// A connection which stays quiet is treated as dead.
+	c.markReceived(time.Now().Add(-2 * time.Minute))
+	require.ErrorIs(t, c.checkLiveness(), ErrDataTimeout)
+}
@@ -19,12 +19,13 @@ func TestParseFrequency(t *testing.T) {
 		{"", RadioFrequency{}, false},
 		{"0", RadioFrequency{}, false},
 		{"-1", RadioFrequency{}, false},
-		{"30FM", RadioFrequency{30 * unit.Megahertz, types.ModulationFM}, true},
-		{"30.0FM", RadioFrequency{30 * unit.Megahertz, types.ModulationFM}, true},
-		{"251.0", RadioFrequency{251 * unit.Megahertz, types.ModulationAM}, true},
-		{"251.0AM", RadioFrequency{251 * unit.Megahertz, types.ModulationAM}, true},
-		{"251.1AM", RadioFrequency{251.1 * unit.Megahertz, types.ModulationAM}, true},
-		{"251.1 AM", RadioFrequency{251.1 * unit.Megahertz, types.ModulationAM}, true},
+		{"30FM", RadioFrequency{Frequency: 30 * unit.Megahertz, Modulation: types.ModulationFM}, true},
+		{"30.0FM", RadioFrequency{Frequency: 30 * unit.Megahertz, Modulation: types.ModulationFM}, true},
+		{"251.0", RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: types.ModulationAM}, true},
+		{"251.0AM", RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: types.ModulationAM}, true},
+		{"251.1AM", RadioFrequency{Frequency: 251.1 * unit.Megahertz, Modulation: types.ModulationAM}, true},
+		{"251.1 AM", RadioFrequency{Frequency: 251.1 * unit.Megahertz, Modulation: types.ModulationAM}, true},
+		{"305.0SATCOM", RadioFrequency{Frequency: 305 * unit.Megahertz, Modulation: types.ModulationSATCOM}, true},
 		{"eekum bokum", RadioFrequency{}, false},
 		{"AM", RadioFrequency{}, false},
 		{"FM", RadioFrequency{}, false},
@@ -0,0 +1,14 @@
+// Package callbacks contains helpers for the callbacks registered on SRS clients.
+package callbacks
+
+import "sync/atomic"
+
+// Load returns the callback stored in the given pointer, or the zero value if no callback has been stored. Callers
+// should still check the result for nil, since a nil callback may have been stored.
+func Load[T any](p *atomic.Pointer[T]) T {
+	if callback := p.Load(); callback != nil {
+		return *callback
+	}
+	var zero T
+	return zero
+}
@@ -0,0 +1,25 @@
+package callbacks
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	var p atomic.Pointer[func() int]
+	assert.Nil(t, Load(&p), "nothing stored")
+
+	var callback func() int
+	p.Store(&callback)
+	assert.Nil(t, Load(&p), "nil callback stored")
+
+	callback = func() int { return 1 }
+	p.Store(&callback)
+	loaded := Load(&p)
+	if assert.NotNil(t, loaded) {
+		assert.Equal(t, 1, loaded())
+	}
+}
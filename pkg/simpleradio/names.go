@@ -0,0 +1,54 @@
+package simpleradio
+
+import (
+	"math"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+)
+
+// SetFrequencyName implements [Client.SetFrequencyName].
+func (c *client) SetFrequencyName(frequency unit.Frequency, name string) {
+	c.frequencyNamesLock.Lock()
+	defer c.frequencyNamesLock.Unlock()
+	if name == "" {
+		delete(c.frequencyNames, frequency)
+		return
+	}
+	if c.frequencyNames == nil {
+		c.frequencyNames = make(map[unit.Frequency]string)
+	}
+	c.frequencyNames[frequency] = name
+}
+
+// tolerance returns the maximum difference between two frequencies for them to be considered the same.
+func (c *client) tolerance() unit.Frequency {
+	if c.frequencyTolerance == 0 {
+		return types.DefaultFrequencyTolerance
+	}
+	return c.frequencyTolerance
+}
+
+// FrequencyName implements [Client.FrequencyName].
+func (c *client) FrequencyName(frequency unit.Frequency) string {
+	tolerance := c.tolerance()
+	c.frequencyNamesLock.RLock()
+	defer c.frequencyNamesLock.RUnlock()
+	if name, ok := c.frequencyNames[frequency]; ok {
+		return name
+	}
+	// Prefer the closest named frequency within the tolerance.
+	closest := ""
+	closestDifference := math.Inf(1)
+	for named, name := range c.frequencyNames {
+		difference := math.Abs(named.Hertz() - frequency.Hertz())
+		if difference <= tolerance.Hertz() && difference < closestDifference {
+			closest = name
+			closestDifference = difference
+		}
+	}
+	if closest != "" {
+		return closest
+	}
+	return types.FormatFrequency(frequency)
+}
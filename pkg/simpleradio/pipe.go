@@ -0,0 +1,191 @@
+package simpleradio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultPipeRestartDelay is the default time to wait before restarting the pipe command after it exits.
+const defaultPipeRestartDelay = 5 * time.Second
+
+// pipeStopTimeout is how long the pipe command is given to exit after its standard input is closed, before it is
+// killed.
+const pipeStopTimeout = 5 * time.Second
+
+// pipeBufferSize is the largest number of bytes of received audio written to the pipe command at once.
+const pipeBufferSize = 4096
+
+// pipe pipes received audio into an external command, restarting the command if it exits.
+type pipe struct {
+	// command is the command to run and its arguments.
+	command []string
+	// frequency is the frequency whose received audio is piped.
+	frequency unit.Frequency
+	// restartDelay is how long to wait before restarting the command after it exits.
+	restartDelay time.Duration
+	// current is the running process, or nil if the command is not running.
+	current atomic.Pointer[pipeProcess]
+	// logger logs the command's lifecycle.
+	logger zerolog.Logger
+}
+
+// pipeProcess is a single run of the pipe command.
+type pipeProcess struct {
+	// cmd is the running command.
+	cmd *exec.Cmd
+	// stdin is the command's standard input.
+	stdin io.WriteCloser
+	// done is closed once the command has exited.
+	done chan struct{}
+	// err is the error the command exited with. It is only valid once done is closed.
+	err error
+}
+
+// newPipe returns a pipe for the given configuration, or nil if no command is configured.
+func newPipe(config types.PipeConfiguration, radios []types.Radio) (*pipe, error) {
+	if len(config.Command) == 0 {
+		return nil, nil
+	}
+	if _, err := exec.LookPath(config.Command[0]); err != nil {
+		return nil, fmt.Errorf("pipe command not found: %w", err)
+	}
+	if config.RestartDelay < 0 {
+		return nil, fmt.Errorf("pipe restart delay must not be negative, got %v", config.RestartDelay)
+	}
+	frequency := config.Frequency
+	if frequency == 0 {
+		if len(radios) == 0 {
+			return nil, errors.New("pipe frequency is required when no radios are configured")
+		}
+		frequency = types.FrequencyFromHertz(radios[0].Frequency)
+	}
+	restartDelay := config.RestartDelay
+	if restartDelay == 0 {
+		restartDelay = defaultPipeRestartDelay
+	}
+	return &pipe{
+		command:      config.Command,
+		frequency:    frequency,
+		restartDelay: restartDelay,
+		logger:       log.With().Str("command", config.Command[0]).Logger(),
+	}, nil
+}
+
+// run pipes the given stream of received audio into the command until the stream ends or stopCh is closed, then
+// stops the command. The command is started immediately and restarted after the restart delay whenever it exits.
+// Audio received while the command is not running is discarded.
+func (p *pipe) run(stream io.ReadCloser, stopCh <-chan struct{}) {
+	copyDone := make(chan struct{})
+	go func() {
+		defer close(copyDone)
+		p.copy(stream)
+	}()
+	defer func() {
+		// Unblock the copier if the stream is still open.
+		_ = stream.Close()
+		<-copyDone
+	}()
+
+	for {
+		process, err := p.start()
+		if err != nil {
+			p.logger.Error().Err(err).Msg("failed to start pipe command")
+		} else {
+			p.logger.Info().Func(types.LogFrequency(p.frequency)).Msg("started pipe command")
+			p.current.Store(process)
+			select {
+			case <-process.done:
+				p.current.Store(nil)
+				p.logger.Warn().Err(process.err).Stringer("restartDelay", p.restartDelay).Msg("pipe command exited")
+			case <-copyDone:
+				p.current.Store(nil)
+				p.stop(process)
+				return
+			case <-stopCh:
+				p.current.Store(nil)
+				p.stop(process)
+				return
+			}
+		}
+		select {
+		case <-time.After(p.restartDelay):
+		case <-copyDone:
+			return
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// copy writes the stream to the running command until the stream ends.
+func (p *pipe) copy(stream io.Reader) {
+	buf := make([]byte, pipeBufferSize)
+	for {
+		n, err := stream.Read(buf)
+		if n > 0 {
+			if process := p.current.Load(); process != nil {
+				if _, writeErr := process.stdin.Write(buf[:n]); writeErr != nil {
+					p.logger.Debug().Err(writeErr).Msg("failed to write received audio to pipe command")
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// start starts the command.
+func (p *pipe) start() (*pipeProcess, error) {
+	cmd := exec.Command(p.command[0], p.command[1:]...) //nolint:gosec // the command is configured by the operator
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open standard input: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+	process := &pipeProcess{cmd: cmd, stdin: stdin, done: make(chan struct{})}
+	go func() {
+		process.err = cmd.Wait()
+		close(process.done)
+	}()
+	return process, nil
+}
+
+// stop closes the command's standard input so that it can finish writing its output, and kills it if it does not
+// exit within the stop timeout.
+func (p *pipe) stop(process *pipeProcess) {
+	_ = process.stdin.Close()
+	select {
+	case <-process.done:
+	case <-time.After(pipeStopTimeout):
+		p.logger.Warn().Msg("pipe command did not exit after its input was closed, killing it")
+		_ = process.cmd.Process.Kill()
+		<-process.done
+	}
+	p.logger.Info().Msg("stopped pipe command")
+}
+
+// runPipe pipes received audio into the configured command until the context is canceled or the client is closed.
+func (c *client) runPipe(ctx context.Context) {
+	stopCh := make(chan struct{})
+	go func() {
+		defer close(stopCh)
+		select {
+		case <-ctx.Done():
+		case <-c.closeCh:
+		}
+	}()
+	c.pipe.run(c.audioClient.ReceiveReader(c.pipe.frequency), stopCh)
+}
@@ -0,0 +1,92 @@
+package simpleradio
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPipe(t *testing.T) {
+	t.Parallel()
+	radios := []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}}
+
+	p, err := newPipe(types.PipeConfiguration{}, radios)
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	p, err = newPipe(types.PipeConfiguration{Command: []string{"cat"}}, radios)
+	require.NoError(t, err)
+	assert.Equal(t, 251*unit.Megahertz, p.frequency)
+	assert.Equal(t, defaultPipeRestartDelay, p.restartDelay)
+
+	_, err = newPipe(types.PipeConfiguration{Command: []string{"skyeye-no-such-command"}}, radios)
+	require.Error(t, err)
+	_, err = newPipe(types.PipeConfiguration{Command: []string{"cat"}, RestartDelay: -time.Second}, radios)
+	require.Error(t, err)
+	_, err = newPipe(types.PipeConfiguration{Command: []string{"cat"}}, nil)
+	require.Error(t, err)
+}
+
+func TestPipe(t *testing.T) {
+	t.Parallel()
+	output := filepath.Join(t.TempDir(), "received.pcm")
+	p, err := newPipe(types.PipeConfiguration{Command: []string{"sh", "-c", "cat > " + output}}, []types.Radio{{Frequency: 251000000}})
+	require.NoError(t, err)
+
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.run(reader, make(chan struct{}))
+	}()
+	require.Eventually(t, func() bool { return p.current.Load() != nil }, 5*time.Second, 10*time.Millisecond)
+
+	received := bytes.Repeat([]byte{1, 2, 3, 4}, 1000)
+	_, err = writer.Write(received)
+	require.NoError(t, err)
+
+	// Ending the stream closes the command's input once the received audio has been written, so the command finishes
+	// writing its output before exiting.
+	require.NoError(t, writer.Close())
+	<-done
+	b, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, received, b)
+}
+
+func TestPipeRestart(t *testing.T) {
+	t.Parallel()
+	output := filepath.Join(t.TempDir(), "starts")
+	p, err := newPipe(
+		types.PipeConfiguration{Command: []string{"sh", "-c", "echo >> " + output}, RestartDelay: 10 * time.Millisecond},
+		[]types.Radio{{Frequency: 251000000}},
+	)
+	require.NoError(t, err)
+
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.run(reader, make(chan struct{}))
+	}()
+	require.Eventually(t, func() bool {
+		b, _ := os.ReadFile(output)
+		return bytes.Count(b, []byte("\n")) >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The pipe stops once the received audio stream ends.
+	require.NoError(t, writer.Close())
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "pipe did not stop after the stream ended")
+	}
+}
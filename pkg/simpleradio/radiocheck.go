@@ -0,0 +1,41 @@
+package simpleradio
+
+import (
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+)
+
+// RadioCheckResult is a snapshot of the client's setup and reachability, for operators to confirm before a mission.
+type RadioCheckResult struct {
+	// Radios are the client's configured radios.
+	Radios []types.Radio
+	// Coalition is the coalition the client declared to the SRS server.
+	Coalition coalitions.Coalition
+	// Listeners are the peers on the client's frequencies, who would hear a transmission.
+	Listeners []types.ClientInfo
+	// ServerVersion is the SRS server's version, or an empty string if it has not yet been discovered.
+	ServerVersion string
+	// LastPing is the time the SRS server last echoed a ping over the audio connection.
+	LastPing time.Time
+	// LastReceived is the time a message was last received over the data connection. The server only sends data when
+	// something changes, so a quiet data connection is not necessarily unhealthy.
+	LastReceived time.Time
+	// IsLinkHealthy is true if the SRS server has recently echoed a ping, meaning transmissions can reach it.
+	IsLinkHealthy bool
+}
+
+// RadioCheck implements [Client.RadioCheck].
+func (c *client) RadioCheck() RadioCheckResult {
+	lastPing := c.audioClient.LastPing()
+	return RadioCheckResult{
+		Radios:        c.audioClient.Radios(),
+		Coalition:     c.coalition,
+		Listeners:     c.dataClient.PeersOnFrequency(),
+		ServerVersion: c.dataClient.ServerVersion(),
+		LastPing:      lastPing,
+		LastReceived:  c.dataClient.LastReceived(),
+		IsLinkHealthy: time.Since(lastPing) <= pingTimeout,
+	}
+}
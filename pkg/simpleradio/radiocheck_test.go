@@ -0,0 +1,43 @@
+package simpleradio_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/dharmab/skyeye/pkg/simpleradio/simtest"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRadioCheck(t *testing.T) {
+	t.Parallel()
+	radios := []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}}
+	client, _, err := simtest.NewClient(types.ClientConfiguration{
+		ClientName: "GCI Sky Eye [BOT]",
+		Coalition:  coalitions.Blue,
+		Radios:     radios,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	go func() {
+		_ = client.Run(ctx, &wg)
+	}()
+	defer client.Close()
+
+	require.Eventually(t, func() bool {
+		return client.RadioCheck().ServerVersion == simtest.Version
+	}, 5*time.Second, 10*time.Millisecond)
+	result := client.RadioCheck()
+	assert.Equal(t, radios, result.Radios)
+	assert.EqualValues(t, coalitions.Blue, result.Coalition)
+	assert.Empty(t, result.Listeners)
+	assert.True(t, result.IsLinkHealthy)
+	assert.False(t, result.LastReceived.IsZero())
+}
@@ -0,0 +1,30 @@
+package simtest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+)
+
+// NewLoopbackClients returns two clients connected to the same simulated SRS server, so that audio transmitted by
+// either client is received by the other. This exercises the full encode, network and decode path without a real
+// server, for integration tests. The shared simulator is also returned; audio injected through it is transmitted on the
+// radios of both clients.
+func NewLoopbackClients(a, b types.ClientConfiguration) (simpleradio.Client, simpleradio.Client, *Simulator, error) {
+	simulator := NewSimulator(append(slices.Clone(a.Radios), b.Radios...))
+	first, err := simpleradio.NewClient(simulator.Configure(a))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to construct first loopback client: %w", err)
+	}
+	second, err := simpleradio.NewClient(simulator.Configure(b))
+	if err != nil {
+		return nil, nil, nil, errors.Join(
+			fmt.Errorf("failed to construct second loopback client: %w", err),
+			first.Close(),
+		)
+	}
+	return first, second, simulator, nil
+}
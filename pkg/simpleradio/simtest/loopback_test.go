@@ -0,0 +1,88 @@
+package simtest
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio"
+	"github.com/dharmab/skyeye/pkg/simpleradio/audio"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chirp returns the given duration of a linear frequency sweep from 300Hz to 3kHz at 16kHz. Unlike a steady tone, a
+// sweep only correlates strongly with itself at a single alignment.
+func chirp(duration time.Duration) audio.Audio {
+	const start, end = 300.0, 3000.0
+	sample := make(audio.Audio, int(duration.Seconds()*16000))
+	rate := (end - start) / duration.Seconds()
+	for i := range sample {
+		t := float64(i) / 16000
+		sample[i] = 0.5 * float32(math.Sin(2*math.Pi*(start*t+rate*t*t/2)))
+	}
+	return sample
+}
+
+// maxCorrelation returns the highest normalized cross-correlation between the sent and received audio, for delays of
+// the received audio of up to maxLag samples.
+func maxCorrelation(sent, received audio.Audio, maxLag int) float64 {
+	best := 0.0
+	for lag := 0; lag <= maxLag; lag++ {
+		var dot, sentEnergy, receivedEnergy float64
+		for i := 0; i < len(sent) && i+lag < len(received); i++ {
+			s, r := float64(sent[i]), float64(received[i+lag])
+			dot += s * r
+			sentEnergy += s * s
+			receivedEnergy += r * r
+		}
+		if sentEnergy == 0 || receivedEnergy == 0 {
+			continue
+		}
+		best = max(best, dot/math.Sqrt(sentEnergy*receivedEnergy))
+	}
+	return best
+}
+
+func TestLoopbackClients(t *testing.T) {
+	t.Parallel()
+	radios := []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}}
+	transmitter, receiver, simulator, err := NewLoopbackClients(
+		types.ClientConfiguration{ClientName: "Transmitter", Radios: radios},
+		types.ClientConfiguration{ClientName: "Receiver", Radios: radios},
+	)
+	require.NoError(t, err)
+	require.NotNil(t, simulator)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, client := range []simpleradio.Client{transmitter, receiver} {
+		go func() {
+			_ = client.Run(ctx, &wg)
+		}()
+		defer client.Close()
+	}
+
+	sent := chirp(1500 * time.Millisecond)
+	transmitter.Transmit(sent)
+	select {
+	case received := <-receiver.Receive():
+		require.GreaterOrEqual(t, len(received), len(sent))
+		correlation := maxCorrelation(sent, received, 4000)
+		t.Logf("correlation between sent and received audio: %.3f", correlation)
+		assert.Greater(t, correlation, 0.8, "received audio should be recognizably the transmitted audio")
+		assert.Less(t, maxCorrelation(sent, chirp(3*time.Second), 4000), 0.8, "a different sweep should not correlate")
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "timed out waiting for the transmission to loop back")
+	}
+
+	select {
+	case <-transmitter.Receive():
+		require.Fail(t, "the transmitter should not receive its own transmission")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,239 @@
+// Package simtest provides an in-memory fake SRS server, so that SRS clients can run without a network, such as in
+// demos and integration tests.
+package simtest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/dharmab/skyeye/pkg/simpleradio"
+	"github.com/dharmab/skyeye/pkg/simpleradio/audio"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/dharmab/skyeye/pkg/simpleradio/voice"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/hraban/opus.v2"
+)
+
+// Version is the SRS version reported by the simulated server.
+const Version = "simulated"
+
+const (
+	// simulatedSampleRate is the sample rate of audio injected by the simulator in Hz.
+	simulatedSampleRate = 16000
+	// simulatedFrameSize is the number of samples in each Opus frame injected by the simulator. This is 40ms of audio,
+	// which is the SRS default.
+	simulatedFrameSize = 640
+	// simulatedUnitID is the unit ID of the simulated transmitter.
+	simulatedUnitID = 100000001
+)
+
+// Simulator is an in-memory fake SRS server. It records the messages and voice packets sent by its clients, and can
+// inject audio to be received by them. If several clients are connected, as by [NewLoopbackClients], each voice packet
+// sent by one client is relayed to the others.
+type Simulator struct {
+	// radios are the radios injected audio is transmitted on.
+	radios []types.Radio
+	// guid is the GUID of the simulated transmitter.
+	guid types.GUID
+	// ready is closed once the first audio connection has been dialed.
+	ready chan struct{}
+	// readyOnce ensures ready is only closed once.
+	readyOnce sync.Once
+	// lock protects the following fields.
+	lock sync.Mutex
+	// audioConnections are the server sides of the clients' audio connections.
+	audioConnections []net.Conn
+	// messages are the messages sent by the client.
+	messages []types.Message
+	// voicePackets are the voice packets sent by the client.
+	voicePackets []voice.VoicePacket
+	// packetNumber is incremented for each injected voice packet.
+	packetNumber uint64
+}
+
+// NewSimulator returns a simulator which transmits injected audio on the given radios.
+func NewSimulator(radios []types.Radio) *Simulator {
+	return &Simulator{
+		radios:       radios,
+		guid:         types.NewGUID(),
+		ready:        make(chan struct{}),
+		packetNumber: 1,
+	}
+}
+
+// NewClient returns a client connected to a new simulator, which transmits injected audio on the client's radios. The
+// client's network configuration is ignored. See [Simulator.Configure].
+func NewClient(config types.ClientConfiguration) (simpleradio.Client, *Simulator, error) {
+	simulator := NewSimulator(config.Radios)
+	client, err := simpleradio.NewClient(simulator.Configure(config))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to construct simulated client: %w", err)
+	}
+	return client, simulator, nil
+}
+
+// Configure returns a copy of the given configuration which connects to the simulator instead of the network. The
+// dialers, address and TLS configuration are ignored.
+func (s *Simulator) Configure(config types.ClientConfiguration) types.ClientConfiguration {
+	log.Warn().Msg("using simulated SRS server")
+	config.DataTransport = s.dataTransport
+	config.AudioTransport = s.audioTransport
+	config.TLS = types.TLSConfiguration{}
+	return config
+}
+
+// dataTransport implements [types.DataTransportFactory] by connecting to the simulator over an in-memory pipe. The
+// network and address are ignored.
+func (s *Simulator) dataTransport(context.Context, string, string) (types.DataTransport, error) {
+	clientConnection, serverConnection := net.Pipe()
+	go s.serveData(serverConnection)
+	return clientConnection, nil
+}
+
+// audioTransport implements [types.AudioTransportFactory] by connecting to the simulator over an in-memory pipe. The
+// network and address are ignored.
+func (s *Simulator) audioTransport(context.Context, string, string) (types.AudioTransport, error) {
+	clientConnection, serverConnection := net.Pipe()
+	s.lock.Lock()
+	s.audioConnections = append(s.audioConnections, serverConnection)
+	s.lock.Unlock()
+	s.readyOnce.Do(func() {
+		close(s.ready)
+	})
+	go s.serveAudio(serverConnection)
+	return clientConnection, nil
+}
+
+// serveData reads messages from the data connection. Sync messages are answered with an empty sync message.
+func (s *Simulator) serveData(connection net.Conn) {
+	reader := bufio.NewReader(connection)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) {
+				log.Error().Err(err).Msg("simulated SRS server failed to read data message")
+			}
+			return
+		}
+		var message types.Message
+		if err := json.Unmarshal(line, &message); err != nil {
+			log.Error().Err(err).Msg("simulated SRS server received malformed data message")
+			continue
+		}
+		log.Info().Int("type", int(message.Type)).Msg("simulated SRS server received data message")
+		s.lock.Lock()
+		s.messages = append(s.messages, message)
+		s.lock.Unlock()
+
+		if message.Type == types.MessageSync {
+			reply, err := json.Marshal(types.Message{Version: Version, Type: types.MessageSync})
+			if err != nil {
+				log.Error().Err(err).Msg("simulated SRS server failed to marshal sync message")
+				continue
+			}
+			if _, err := connection.Write(append(reply, '\n')); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// serveAudio reads packets from an audio connection. Pings are echoed back, and voice packets are recorded and relayed
+// to the other audio connections.
+func (s *Simulator) serveAudio(connection net.Conn) {
+	b := make([]byte, 1500)
+	for {
+		n, err := connection.Read(b)
+		if err != nil {
+			return
+		}
+		switch {
+		case n == types.GUIDLength:
+			if _, err := connection.Write(b[:n]); err != nil {
+				return
+			}
+		case n > types.GUIDLength:
+			packet := slices.Clone(b[:n])
+			vp := voice.NewVoicePacketFrom(packet)
+			log.Info().Uint64("packetID", vp.PacketID).Msg("simulated SRS server received voice packet")
+			s.lock.Lock()
+			s.voicePackets = append(s.voicePackets, vp)
+			others := slices.DeleteFunc(slices.Clone(s.audioConnections), func(c net.Conn) bool {
+				return c == connection
+			})
+			s.lock.Unlock()
+			for _, other := range others {
+				if _, err := other.Write(packet); err != nil {
+					log.Debug().Err(err).Msg("simulated SRS server failed to relay voice packet")
+				}
+			}
+		}
+	}
+}
+
+// connections returns a copy of the server sides of the clients' audio connections.
+func (s *Simulator) connections() []net.Conn {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return slices.Clone(s.audioConnections)
+}
+
+// Messages returns a copy of the data protocol messages sent by the clients.
+func (s *Simulator) Messages() []types.Message {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return slices.Clone(s.messages)
+}
+
+// VoicePackets returns a copy of the voice packets transmitted by the clients.
+func (s *Simulator) VoicePackets() []voice.VoicePacket {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return slices.Clone(s.voicePackets)
+}
+
+// Inject transmits the given F32LE PCM audio at 16kHz to the clients, as if another client had transmitted it on
+// all of the clients' radios. The clients only publish transmissions of at least one second.
+func (s *Simulator) Inject(sample audio.Audio) error {
+	<-s.ready
+	encoder, err := opus.NewEncoder(simulatedSampleRate, 1, opus.AppVoIP)
+	if err != nil {
+		return fmt.Errorf("failed to create Opus encoder: %w", err)
+	}
+	frequencies := make([]voice.Frequency, 0, len(s.radios))
+	for _, radio := range s.radios {
+		frequencies = append(frequencies, voice.Frequency{
+			Frequency:  radio.Frequency,
+			Modulation: byte(radio.Modulation),
+		})
+	}
+	for i := 0; i < len(sample); i += simulatedFrameSize {
+		frame := make([]float32, simulatedFrameSize)
+		copy(frame, sample[i:min(i+simulatedFrameSize, len(sample))])
+		audioBytes := make([]byte, 1024)
+		n, err := encoder.Encode(pcm.F32toS16LE(frame), audioBytes)
+		if err != nil {
+			return fmt.Errorf("failed to encode Opus audio: %w", err)
+		}
+		s.lock.Lock()
+		packetNumber := s.packetNumber
+		s.packetNumber++
+		s.lock.Unlock()
+		vp := voice.NewVoicePacket(audioBytes[:n], frequencies, simulatedUnitID, packetNumber, 0, []byte(s.guid), []byte(s.guid))
+		packet := vp.Encode()
+		for _, connection := range s.connections() {
+			if _, err := connection.Write(packet); err != nil {
+				return fmt.Errorf("failed to inject voice packet: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package simtest
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/audio"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tone returns the given duration of a 440Hz sine wave at 16kHz.
+func tone(duration time.Duration) audio.Audio {
+	sample := make(audio.Audio, int(duration.Seconds()*16000))
+	for i := range sample {
+		sample[i] = 0.5 * float32(math.Sin(2*math.Pi*440*float64(i)/16000))
+	}
+	return sample
+}
+
+func TestSimulatedClient(t *testing.T) {
+	t.Parallel()
+	client, simulator, err := NewClient(types.ClientConfiguration{
+		ClientName: "GCI Sky Eye [BOT]",
+		Radios:     []types.Radio{{Frequency: 251000000, Modulation: types.ModulationAM}},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	go func() {
+		_ = client.Run(ctx, &wg)
+	}()
+	defer client.Close()
+
+	require.Eventually(t, func() bool {
+		for _, message := range simulator.Messages() {
+			if message.Type == types.MessageSync && message.Client.Name == "GCI Sky Eye [BOT]" {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond, "client should sync with the simulated server")
+	require.Eventually(t, func() bool {
+		return client.ServerVersion() == Version
+	}, 5*time.Second, 10*time.Millisecond)
+
+	client.Transmit(tone(200 * time.Millisecond))
+	require.Eventually(t, func() bool {
+		return len(simulator.VoicePackets()) == 5
+	}, 5*time.Second, 10*time.Millisecond, "transmitted audio should be captured as voice packets")
+
+	require.NoError(t, simulator.Inject(tone(1500*time.Millisecond)))
+	select {
+	case received := <-client.Receive():
+		assert.Len(t, received, 1520*16)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for injected audio")
+	}
+}
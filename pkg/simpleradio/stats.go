@@ -0,0 +1,48 @@
+package simpleradio
+
+import (
+	"time"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/audio"
+	"github.com/dharmab/skyeye/pkg/simpleradio/data"
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/rs/zerolog/log"
+)
+
+// ClientStats is a point-in-time snapshot of the client's statistics, for operators who poll statistics into their own
+// monitoring system.
+type ClientStats struct {
+	// Audio are the statistics of the audio client.
+	Audio audio.ClientStats
+	// Data are the statistics of the data client.
+	Data data.ClientStats
+}
+
+// Stats implements [Client.Stats].
+func (c *client) Stats() ClientStats {
+	return ClientStats{
+		Audio: c.audioClient.Stats(),
+		Data:  c.dataClient.Stats(),
+	}
+}
+
+// SenderStats implements [Client.SenderStats].
+func (c *client) SenderStats(origin types.GUID) audio.SenderStats {
+	return c.audioClient.SenderStats(origin)
+}
+
+// logSummary logs a summary of the session's statistics, for operators to review after a mission. It only reads
+// counters, so it does not delay teardown.
+func (c *client) logSummary(started time.Time) {
+	stats := c.Stats()
+	log.Info().
+		Stringer("uptime", time.Since(started).Round(time.Second)).
+		Uint64("packetsSent", stats.Audio.PacketsSent).
+		Uint64("packetsReceived", stats.Audio.PacketsReceived).
+		Uint64("decodeErrors", stats.Audio.DecodeErrors).
+		Uint64("networkErrors", stats.Audio.Errors).
+		Uint64("messagesSent", stats.Data.MessagesSent).
+		Uint64("messagesReceived", stats.Data.MessagesReceived).
+		Int("peakClientsOnFrequency", stats.Data.PeakClientsOnFrequency).
+		Msg("SRS client session summary")
+}
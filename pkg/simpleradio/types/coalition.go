@@ -1,6 +1,8 @@
 package types
 
 import (
+	"fmt"
+
 	"github.com/dharmab/skyeye/pkg/coalitions"
 )
 
@@ -8,3 +10,44 @@ import (
 func IsSpectator(c coalitions.Coalition) bool {
 	return (c != coalitions.Red) && (c != coalitions.Blue)
 }
+
+// SpectatorCoalition is the coalition ID SRS assigns to spectators.
+const SpectatorCoalition coalitions.Coalition = 0
+
+// IsKnownCoalition returns true if the given coalition ID is one SRS is known to use: spectators, red, blue or
+// neutrals.
+func IsKnownCoalition(c coalitions.Coalition) bool {
+	return c >= SpectatorCoalition && c <= coalitions.Neutrals
+}
+
+// UnknownCoalitionPolicy selects how the client treats peers whose coalition ID is not one SRS is known to use, such
+// as a value added by a newer server version.
+type UnknownCoalitionPolicy int
+
+const (
+	// UnknownCoalitionSpectator treats peers in unknown coalitions as spectators, as SRS itself does. This is the
+	// default.
+	UnknownCoalitionSpectator UnknownCoalitionPolicy = iota
+	// UnknownCoalitionExclude ignores peers in unknown coalitions.
+	UnknownCoalitionExclude
+)
+
+// String implements [fmt.Stringer].
+func (p UnknownCoalitionPolicy) String() string {
+	switch p {
+	case UnknownCoalitionSpectator:
+		return "spectator"
+	case UnknownCoalitionExclude:
+		return "exclude"
+	default:
+		return "unknown"
+	}
+}
+
+// Validate returns an error if the policy is not one of the defined policies.
+func (p UnknownCoalitionPolicy) Validate() error {
+	if p != UnknownCoalitionSpectator && p != UnknownCoalitionExclude {
+		return fmt.Errorf("unknown coalition policy %d is not defined", p)
+	}
+	return nil
+}
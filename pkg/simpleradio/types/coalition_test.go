@@ -13,3 +13,16 @@ func TestIsSpectator(t *testing.T) {
 	require.False(t, IsSpectator(coalitions.Red))
 	require.False(t, IsSpectator(coalitions.Blue))
 }
+
+func TestIsKnownCoalition(t *testing.T) {
+	t.Parallel()
+	for _, c := range []coalitions.Coalition{0, coalitions.Red, coalitions.Blue, coalitions.Neutrals} {
+		require.True(t, IsKnownCoalition(c), c)
+	}
+	for _, c := range []coalitions.Coalition{-1, 4, 255} {
+		require.False(t, IsKnownCoalition(c), c)
+	}
+	require.NoError(t, UnknownCoalitionSpectator.Validate())
+	require.NoError(t, UnknownCoalitionExclude.Validate())
+	require.Error(t, UnknownCoalitionPolicy(2).Validate())
+}
@@ -1,9 +1,12 @@
 package types
 
 import (
+	"math/rand/v2"
 	"time"
 
 	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/dharmab/skyeye/pkg/pcm"
+	"github.com/martinlindhe/unit"
 )
 
 // ClientConfiguration is configuration used to construct the audio and data clients.
@@ -12,18 +15,171 @@ type ClientConfiguration struct {
 	GUID string
 	// Address is the network address of the SRS server, including port.
 	Address string
-	// ConnectionTimeout is the connection timeout for connecting to the SRS server.
+	// AddressProvider returns the network address of the SRS server, including port. If set, it overrides Address, and
+	// is called each time a client is constructed. The client does not reconnect by itself, so this lets an operator
+	// repoint a process which constructs a new client after losing its connection, without restarting the process.
+	AddressProvider func() string
+	// ConnectionTimeout is the connection timeout for connecting to the SRS server. It only applies to the default
+	// dialers.
 	ConnectionTimeout time.Duration
+	// WriteTimeout is the maximum time to wait for a message to be written to the data connection. If zero, a default
+	// of 10s is used.
+	WriteTimeout time.Duration
+	// DataTimeout is the maximum time to wait for data from the SRS server before treating the data connection as dead.
+	// The client requests a sync when half of this time passes without data, so an idle server does not trip the
+	// timeout. If zero, a default of 2m is used.
+	DataTimeout time.Duration
+	// TCPDialer opens the data client's TCP connection. If nil, the standard library dialer is used.
+	TCPDialer TCPDialer
+	// UDPDialer opens the audio client's UDP connection. If nil, the standard library dialer is used.
+	UDPDialer UDPDialer
+	// DataTransport opens the data client's transport. If set, it is used instead of TCPDialer, so that the data client
+	// can use a transport which is not a TCP connection. It cannot be combined with TLS.
+	DataTransport DataTransportFactory
+	// AudioTransport opens the audio client's transport. If set, it is used instead of UDPDialer, so that the audio
+	// client can use a transport which is not a UDP connection.
+	AudioTransport AudioTransportFactory
+	// AddressFamily forces both connections to use IPv4 or IPv6. On dual-stack hosts the resolver may otherwise pick
+	// different IP versions for the data and audio connections, which can connect to a server that only listens for
+	// audio on one of them. The default is [AddressFamilyAny], which lets the resolver choose.
+	AddressFamily AddressFamily
+	// TLS configures TLS for the data connection.
+	TLS TLSConfiguration
+	// ReceivePipe configures an external command which received audio is piped into.
+	ReceivePipe PipeConfiguration
+	// CaptureTransmissions is true if the client should publish a copy of the audio of each transmission, before it
+	// is encoded, to the channel returned by CaptureTransmissions.
+	CaptureTransmissions bool
 	// ClientName corresponds to [ClientInfo.Name].
 	ClientName string
 	// ExternalAWACSModePassword is the password for External AWACS Mode
 	ExternalAWACSModePassword string
 	// Coalition corresponds to [ClientInfo.Coalition].
 	Coalition coalitions.Coalition
+	// JoinAsSpectator is true if the client should join as a spectator, with its coalition set to [SpectatorCoalition].
+	// Coalition must then be left unset. SRS relays every coalition's audio to spectators, so a spectator tracks peers
+	// in every coalition on its frequencies. It does not authenticate with the External AWACS Mode password, which
+	// would assign it a coalition, and it does not transmit while the SRS server disables spectator audio.
+	JoinAsSpectator bool
 	// Radio is the [Radio] to listen and talk on.
 	Radios []Radio
 	// AllowRecording corresponds to [ClientInfo.AllowRecording].
 	AllowRecording bool
 	// Mute is true if the client should not transmit.
 	Mute bool
+	// SuppressReceiveWhenMuted is true if audio received on a radio should be dropped while transmission on that radio
+	// is muted, either by Mute or by muting the radio's frequency, for full radio silence. By default, muting only
+	// affects transmission and the client keeps receiving. Transmission being forbidden by the SRS server does not
+	// suppress receiving. It cannot be combined with ListenAll, which mutes the client.
+	SuppressReceiveWhenMuted bool
+	// FrequencyTolerance is the maximum difference between two frequencies for them to be considered the same
+	// frequency, when matching peers and received transmissions to the client's radios. It must not be negative. If
+	// zero, [DefaultFrequencyTolerance] is used.
+	FrequencyTolerance unit.Frequency
+	// FrequencyNames are friendly names for frequencies, such as "Strike" or "Guard", for use in spoken responses.
+	// More names may be registered after the client is created.
+	FrequencyNames map[unit.Frequency]string
+	// ListenAll is true if the client should track peers in every coalition, rather than only its own coalition and
+	// spectators. This suits observer and recording tools. A client in this mode never transmits, as if Mute were
+	// true. Note that the SRS server may still only relay audio from the client's own coalition, depending on its
+	// settings; set JoinAsSpectator to hear every coalition.
+	ListenAll bool
+	// ExcludeSpectators is true if spectators should not be counted as peers on the client's frequencies. By default,
+	// spectators are treated as members of every coalition.
+	ExcludeSpectators bool
+	// UnknownCoalitions selects how peers whose coalition ID is not one SRS is known to use are treated. Each unknown
+	// coalition ID is logged once. The default is [UnknownCoalitionSpectator].
+	UnknownCoalitions UnknownCoalitionPolicy
+	// RotateGUIDOnCollision is true if the client should reconnect to the SRS server with a new GUID when another client
+	// reports this client's GUID with a different name or unit ID. Such a collision confuses the server, and can leave
+	// this client invisible to its peers. Both the data and audio connections are reestablished with the new GUID. By
+	// default, the collision is only logged and reported to the GUID collision callback.
+	RotateGUIDOnCollision bool
+	// ClearChannelTimeout is the maximum time to wait for incoming transmissions to end before transmitting anyway.
+	// If zero, the client waits indefinitely for a clear channel.
+	ClearChannelTimeout time.Duration
+	// HotMicThreshold is the duration after which a single continuous transmission is reported as a hot mic. If zero,
+	// hot mic detection is disabled.
+	HotMicThreshold time.Duration
+	// FrameLength is the duration of audio in each Opus frame sent by the client. Supported values are 10ms, 20ms, 40ms
+	// and 60ms. If zero, the SRS default of 40ms is used.
+	FrameLength time.Duration
+	// InputChannels is the number of interleaved channels in audio passed to the client for transmission. SRS voice is
+	// mono, so stereo audio is downmixed to mono before encoding. It must be 1 or 2. If zero, mono is assumed.
+	InputChannels int
+	// InputSampleRate is the sample rate in Hz of audio passed to the client's Transmit methods. Audio at any other rate
+	// than the SRS sample rate of 16kHz is resampled before encoding, so that it plays at the correct speed and pitch.
+	// If zero, 16kHz is assumed.
+	InputSampleRate int
+	// FadeDuration is the duration of the fade-in and fade-out applied to the start and end of each transmission before
+	// it is encoded, which avoids clicks from audio which starts or stops abruptly. A few milliseconds is enough. If
+	// zero, no fade is applied. Streamed transmissions are not faded.
+	FadeDuration time.Duration
+	// DiscontinuousTransmission enables Opus DTX, which encodes silent frames within a transmission as minimal packets
+	// to save bandwidth. A packet is still sent for every frame, so frame pacing and the packet numbering used by
+	// receivers' jitter buffers are unaffected. Receivers decode the silent packets as comfort noise.
+	DiscontinuousTransmission bool
+	// PacingStrategy selects how the client waits between voice packets while transmitting. The default is
+	// [PacingSleep]. Operators on systems with coarse timer granularity may find another strategy sounds better.
+	PacingStrategy PacingStrategy
+	// PingInterval is how often the client pings the SRS server over UDP. The SRS server only sends audio to clients
+	// which have recently pinged it, and echoes each ping back. The client treats the connection as lost if no ping
+	// is echoed for one minute, so the interval must be between 1s and 30s. If zero, the SRS default of 15s is used.
+	PingInterval time.Duration
+	// HandshakeTimeout is the maximum time to wait for the SRS server to echo the audio client's first UDP ping. If it
+	// is exceeded, the client stops with an error, since the server is unreachable over UDP; this usually means the
+	// port is wrong or UDP is blocked by a firewall. The first ping is sent a second after the client starts, so the
+	// timeout should allow for a few lost pings. If zero or negative, the client waits indefinitely.
+	HandshakeTimeout time.Duration
+	// DecoderResetThreshold is the number of consecutive missing voice packets within a received transmission after
+	// which the decoder is reset, rather than continuing to decode with state that no longer matches the sender's. It
+	// must not be negative. If zero, a default of 25 packets, or one second of audio at the SRS default frame length,
+	// is used.
+	DecoderResetThreshold int
+	// UDPReadBufferSize is the size in bytes of the operating system's receive buffer for the audio client's UDP
+	// socket. Under high load, such as many simultaneous transmissions on a busy server, packets which arrive while the
+	// buffer is full are dropped by the operating system before the client can read them. A larger buffer reduces this
+	// packet loss. The operating system may clamp the size to a system limit, such as net.core.rmem_max on Linux; the
+	// effective size is logged. It must not be negative. If zero, the operating system default is used.
+	UDPReadBufferSize int
+	// PreserveReceiversOnReconnect keeps the state of the transmissions being received and waiting to be decoded when
+	// the audio client reconnects. By default, that state is discarded on reconnect, since a sender's packet numbering
+	// may not continue across the reconnection and mixing packets from before and after it garbles the decoded audio.
+	PreserveReceiversOnReconnect bool
+	// ReceiveBufferSize is the number of received transmissions which may be buffered while waiting for the consumer
+	// of the receive channel. When the buffer is full, the client stops decoding further transmissions until the
+	// consumer catches up; received packets are held in upstream buffers in the meantime rather than dropped. If zero,
+	// the receive channel is unbuffered.
+	ReceiveBufferSize int
+	// ReceiveFormat is the PCM format of received audio. If it is the default of F32LE, received audio is published to
+	// the Receive channel as samples. Otherwise, it is encoded in this format and published to the ReceivePCM channel
+	// as raw bytes instead, and the Receive channel receives nothing. Receive streams are not affected.
+	ReceiveFormat pcm.Format
+	// MaxConcurrentDecoders is the maximum number of received transmissions which are decoded at the same time. Each
+	// decoder uses up to one CPU core while decoding, so this caps the CPU spent on busy servers with many simultaneous
+	// transmitters. With more than one decoder, transmissions which finish at about the same time may be published to
+	// the receive channel out of order. It must not be negative. If zero, a single decoder is used.
+	MaxConcurrentDecoders int
+	// DecodeQueueSize is the number of received transmissions which may wait for a decoder. What happens when the
+	// queue is full is selected by DecodeOverflow. It must not be negative. If zero, a default of 1024 is used.
+	DecodeQueueSize int
+	// DecodeOverflow selects what happens to a received transmission when the decode queue is full. The default is
+	// [DecodeOverflowDrop].
+	DecodeOverflow DecodeOverflowPolicy
+	// Random is the source of randomness for the pause between transmissions, which makes the bot sound more natural.
+	// Set it to a seeded source for reproducible pauses in tests. It is only used by the transmitter goroutine. If
+	// nil, the global source is used.
+	Random *rand.Rand
+	// Debug configures options for testing the client under adverse conditions, such as simulated packet loss. They
+	// must never be used in production.
+	Debug DebugConfiguration
+	// StartupGrace is the maximum time to defer transmissions after the client starts, until its first sync with the
+	// SRS server completes. Until then, peers have not been synced, so the client may transmit to no one and report
+	// that no peers are on its frequencies. Transmissions made during the grace period are queued, and sent once the
+	// sync completes or the grace period ends, whichever is first. If zero or negative, transmissions are not
+	// deferred.
+	StartupGrace time.Duration
+	// LogLevels sets the log level of individual subsystems of the client. If nil, every subsystem uses the level of
+	// the global logger.
+	LogLevels LogLevels
 }
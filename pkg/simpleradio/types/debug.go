@@ -0,0 +1,13 @@
+package types
+
+// DebugConfiguration configures options for testing the client's behavior under adverse conditions. These options
+// degrade the client deliberately and must never be used in production.
+type DebugConfiguration struct {
+	// SimulatePacketLoss must be true for the packet loss rates to take effect. It guards against a loss rate being
+	// set accidentally.
+	SimulatePacketLoss bool
+	// ReceiveLossRate is the fraction of received voice packets to drop, from 0 to 1.
+	ReceiveLossRate float64
+	// TransmitLossRate is the fraction of transmitted voice packets to drop, from 0 to 1.
+	TransmitLossRate float64
+}
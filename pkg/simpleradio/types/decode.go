@@ -0,0 +1,29 @@
+package types
+
+// DecodeOverflowPolicy selects what the audio client does with a received transmission when the queue of
+// transmissions waiting to be decoded is full.
+type DecodeOverflowPolicy int
+
+const (
+	// DecodeOverflowDrop drops the transmission and counts it in the client's statistics. This bounds the work and
+	// memory spent on a backlog of transmissions, at the cost of missing some of them. This is the default.
+	DecodeOverflowDrop DecodeOverflowPolicy = iota
+	// DecodeOverflowWait waits for a decoder to take a transmission from the queue. The wait happens on the goroutine
+	// which receives voice packets, so while it waits, no other transmissions are completed, hot mics are not
+	// detected, and radio activity is not updated. Incoming packets back up into the socket buffer and are dropped by
+	// the operating system once it is full, so this only suits clients which must not skip a transmission and can
+	// keep their decoders ahead of the server.
+	DecodeOverflowWait
+)
+
+// String implements [fmt.Stringer].
+func (p DecodeOverflowPolicy) String() string {
+	switch p {
+	case DecodeOverflowDrop:
+		return "drop"
+	case DecodeOverflowWait:
+		return "wait"
+	default:
+		return "unknown"
+	}
+}
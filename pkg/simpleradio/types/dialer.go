@@ -0,0 +1,29 @@
+package types
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// TCPDialer opens the TCP connection used by the SRS data client. [net.Dialer] implements this interface, as do most
+// proxy dialers. The dialed connection is used as the client's [DataTransport], wrapped in TLS if configured.
+type TCPDialer interface {
+	// DialContext connects to the given address on the named network, which is "tcp", "tcp4" or "tcp6" depending
+	// on the configured [AddressFamily].
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// UDPDialer opens the UDP connection used by the SRS audio client. [net.Dialer] implements this interface. The dialed
+// connection is used as the client's [AudioTransport].
+type UDPDialer interface {
+	// DialContext connects to the given address on the named network, which is "udp", "udp4" or "udp6" depending
+	// on the configured [AddressFamily].
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewDefaultDialer returns a dialer from the standard library which implements both [TCPDialer] and [UDPDialer]. The
+// timeout is passed to [net.Dialer.Timeout]; zero means no timeout.
+func NewDefaultDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{Timeout: timeout}
+}
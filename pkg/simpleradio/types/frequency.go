@@ -0,0 +1,46 @@
+package types
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/martinlindhe/unit"
+)
+
+// DefaultFrequencyTolerance is the default maximum difference between two frequencies for them to be considered the
+// same frequency. SRS represents frequencies as floating point numbers of Hz, and clients may round the same frequency differently.
+const DefaultFrequencyTolerance = 500 * unit.Hertz
+
+// FrequencyFromHertz converts a frequency in Hz, as used by the SRS protocol, to a [unit.Frequency].
+func FrequencyFromHertz(hz float64) unit.Frequency {
+	return unit.Frequency(hz) * unit.Hertz
+}
+
+// IsSameFrequency returns true if the given frequencies, in Hz as used by the SRS protocol, differ by no more than the
+// given tolerance.
+func IsSameFrequency(a, b float64, tolerance unit.Frequency) bool {
+	return math.Abs(a-b) <= tolerance.Hertz()
+}
+
+// FormatFrequency formats a frequency in MHz the way it is spoken on the radio: with at least one decimal place,
+// rounded to the nearest kHz, and without further trailing zeros. For example, 243MHz is formatted as "243.0" and
+// 305.750MHz as "305.75".
+func FormatFrequency(frequency unit.Frequency) string {
+	kHz := math.Round(frequency.Kilohertz())
+	s := strconv.FormatFloat(kHz/1000, 'f', 3, 64)
+	s = strings.TrimRight(s, "0")
+	if strings.HasSuffix(s, ".") {
+		s += "0"
+	}
+	return s
+}
+
+// FormatFrequencies formats each of the given frequencies using [FormatFrequency].
+func FormatFrequencies(frequencies []unit.Frequency) []string {
+	formatted := make([]string, 0, len(frequencies))
+	for _, frequency := range frequencies {
+		formatted = append(formatted, FormatFrequency(frequency))
+	}
+	return formatted
+}
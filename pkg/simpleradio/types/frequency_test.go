@@ -0,0 +1,46 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatFrequency(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		frequency unit.Frequency
+		expected  string
+	}{
+		{frequency: 243 * unit.Megahertz, expected: "243.0"},
+		{frequency: 251 * unit.Megahertz, expected: "251.0"},
+		{frequency: 305.75 * unit.Megahertz, expected: "305.75"},
+		{frequency: 133.125 * unit.Megahertz, expected: "133.125"},
+		{frequency: 249.5 * unit.Megahertz, expected: "249.5"},
+		{frequency: 30.05 * unit.Megahertz, expected: "30.05"},
+		{frequency: 249500000 * unit.Hertz, expected: "249.5"},
+		{frequency: 251000400 * unit.Hertz, expected: "251.0"},
+		{frequency: 251999600 * unit.Hertz, expected: "252.0"},
+	}
+	for _, test := range testCases {
+		t.Run(test.expected, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, FormatFrequency(test.frequency))
+		})
+	}
+}
+
+func TestFormatFrequencies(t *testing.T) {
+	t.Parallel()
+	frequencies := []unit.Frequency{251 * unit.Megahertz, 133.125 * unit.Megahertz}
+	assert.Equal(t, []string{"251.0", "133.125"}, FormatFrequencies(frequencies))
+	assert.Empty(t, FormatFrequencies(nil))
+}
+
+func TestFrequencyFromHertz(t *testing.T) {
+	t.Parallel()
+	assert.InDelta(t, 251.0, FrequencyFromHertz(251000000).Megahertz(), 1e-9)
+	assert.InDelta(t, 305.75, FrequencyFromHertz(305750000).Megahertz(), 1e-9)
+	assert.Equal(t, "133.125", FormatFrequency(FrequencyFromHertz(133125000)))
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/martinlindhe/unit"
 )
 
 // ClientInfo is information about the client included in messages.
@@ -37,12 +38,37 @@ type RadioInfo struct {
 
 // IsOnFrequency is true if the other client has a radio with the same frequency, modulation, and encryption settings as this client.
 func (i *RadioInfo) IsOnFrequency(other RadioInfo) bool {
+	return i.IsOnFrequencyWithin(other, DefaultFrequencyTolerance)
+}
+
+// IsOnFrequencyWithin is like [RadioInfo.IsOnFrequency], but matches frequencies within the given tolerance.
+func (i *RadioInfo) IsOnFrequencyWithin(other RadioInfo, tolerance unit.Frequency) bool {
 	for _, thisRadio := range i.Radios {
 		for _, otherRadio := range other.Radios {
-			if thisRadio.IsSameFrequency(otherRadio) {
+			if thisRadio.IsSameFrequencyWithin(otherRadio, tolerance) {
 				return true
 			}
 		}
 	}
 	return false
 }
+
+// Merge returns a copy of this client updated with the section of the given update carried by the given message type.
+// SRS servers send a client's metadata and position in [MessageUpdate] messages, and its radio info in
+// [MessageRadioUpdate] messages. Each message type replaces its whole section, including fields set to their zero
+// values, and the other section keeps its previously known values. Any other message type replaces the whole client.
+func (i ClientInfo) Merge(update ClientInfo, messageType MessageType) ClientInfo {
+	switch messageType {
+	case MessageUpdate:
+		merged := update
+		merged.RadioInfo = i.RadioInfo
+		return merged
+	case MessageRadioUpdate:
+		merged := i
+		merged.GUID = update.GUID
+		merged.RadioInfo = update.RadioInfo
+		return merged
+	default:
+		return update
+	}
+}
@@ -0,0 +1,163 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/coalitions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientInfoMerge(t *testing.T) {
+	t.Parallel()
+	guid := NewGUID()
+	iff := NewIFF()
+	iff.Mode3 = 4321
+	existing := ClientInfo{
+		GUID:           guid,
+		Name:           "Hornet 1-1",
+		Seat:           1,
+		Coalition:      coalitions.Blue,
+		AllowRecording: true,
+		RadioInfo: RadioInfo{
+			Radios:  []Radio{{Frequency: 251000000, Modulation: ModulationAM}},
+			Unit:    "FA-18C_hornet",
+			UnitID:  42,
+			IFF:     iff,
+			Ambient: NewAmbient(),
+		},
+		Position: &Position{Latitude: 41, Longitude: 42, Altitude: 3000},
+	}
+
+	testCases := []struct {
+		name        string
+		update      ClientInfo
+		messageType MessageType
+		expected    func(ClientInfo) ClientInfo
+	}{
+		{
+			name: "metadata update keeps radio info",
+			update: ClientInfo{
+				GUID:           guid,
+				Name:           "Hornet 1-1",
+				Seat:           1,
+				Coalition:      coalitions.Blue,
+				AllowRecording: true,
+				Position:       &Position{Latitude: 43, Longitude: 44, Altitude: 5000},
+			},
+			messageType: MessageUpdate,
+			expected: func(c ClientInfo) ClientInfo {
+				c.Position = &Position{Latitude: 43, Longitude: 44, Altitude: 5000}
+				return c
+			},
+		},
+		{
+			name: "metadata update changes seat to zero",
+			update: ClientInfo{
+				GUID:           guid,
+				Name:           "Hornet 1-1",
+				Coalition:      coalitions.Blue,
+				AllowRecording: true,
+				Position:       &Position{Latitude: 41, Longitude: 42, Altitude: 3000},
+			},
+			messageType: MessageUpdate,
+			expected: func(c ClientInfo) ClientInfo {
+				c.Seat = 0
+				return c
+			},
+		},
+		{
+			name:        "metadata update changes coalition, recording consent and position to zero",
+			update:      ClientInfo{GUID: guid, Name: "Hornet 1-1", Seat: 1},
+			messageType: MessageUpdate,
+			expected: func(c ClientInfo) ClientInfo {
+				c.Coalition = coalitions.Coalition(0)
+				c.AllowRecording = false
+				c.Position = nil
+				return c
+			},
+		},
+		{
+			name: "radio update keeps metadata",
+			update: ClientInfo{
+				GUID: guid,
+				RadioInfo: RadioInfo{
+					Radios:  []Radio{{Frequency: 133000000, Modulation: ModulationAM}},
+					Unit:    "FA-18C_hornet",
+					UnitID:  42,
+					IFF:     iff,
+					Ambient: NewAmbient(),
+				},
+			},
+			messageType: MessageRadioUpdate,
+			expected: func(c ClientInfo) ClientInfo {
+				c.RadioInfo.Radios = []Radio{{Frequency: 133000000, Modulation: ModulationAM}}
+				return c
+			},
+		},
+		{
+			name: "radio update changes unit to zero",
+			update: ClientInfo{
+				GUID: guid,
+				RadioInfo: RadioInfo{
+					Radios:  []Radio{{Frequency: 251000000, Modulation: ModulationAM}},
+					IFF:     iff,
+					Ambient: NewAmbient(),
+				},
+			},
+			messageType: MessageRadioUpdate,
+			expected: func(c ClientInfo) ClientInfo {
+				c.RadioInfo.Unit = ""
+				c.RadioInfo.UnitID = 0
+				return c
+			},
+		},
+		{
+			name: "radio update changes transponder and ambient audio to zero",
+			update: ClientInfo{
+				GUID: guid,
+				RadioInfo: RadioInfo{
+					Radios: []Radio{{Frequency: 251000000, Modulation: ModulationAM}},
+					Unit:   "FA-18C_hornet",
+					UnitID: 42,
+				},
+			},
+			messageType: MessageRadioUpdate,
+			expected: func(c ClientInfo) ClientInfo {
+				c.RadioInfo.IFF = Transponder{}
+				c.RadioInfo.Ambient = Ambient{}
+				return c
+			},
+		},
+		{
+			name: "radio update removes all radios",
+			update: ClientInfo{
+				GUID: guid,
+				RadioInfo: RadioInfo{
+					Unit:    "FA-18C_hornet",
+					UnitID:  42,
+					IFF:     iff,
+					Ambient: NewAmbient(),
+				},
+			},
+			messageType: MessageRadioUpdate,
+			expected: func(c ClientInfo) ClientInfo {
+				c.RadioInfo.Radios = nil
+				return c
+			},
+		},
+		{
+			name:        "other messages replace the whole client",
+			update:      ClientInfo{GUID: guid, Name: "Viper 1-1"},
+			messageType: MessageSync,
+			expected: func(ClientInfo) ClientInfo {
+				return ClientInfo{GUID: guid, Name: "Viper 1-1"}
+			},
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected(existing), existing.Merge(test.update, test.messageType))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"strconv"
+
+	"github.com/martinlindhe/unit"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Subsystem identifies a part of the client whose log verbosity can be controlled independently.
+type Subsystem string
+
+const (
+	// SubsystemDataSync is the data client, which synchronizes client and radio state with the SRS server.
+	SubsystemDataSync Subsystem = "data"
+	// SubsystemAudioReceive is the part of the audio client which receives and decodes transmissions.
+	SubsystemAudioReceive Subsystem = "receive"
+	// SubsystemAudioTransmit is the part of the audio client which encodes and transmits audio.
+	SubsystemAudioTransmit Subsystem = "transmit"
+	// SubsystemPing is the part of the audio client which sends and receives UDP pings.
+	SubsystemPing Subsystem = "ping"
+)
+
+// LogLevels maps subsystems to their minimum log level. Subsystems which are not present use the level of the global
+// logger. Note that a subsystem cannot log below the zerolog global level.
+type LogLevels map[Subsystem]zerolog.Level
+
+// Logger returns a logger for the given subsystem. The logger is derived from the global logger, annotated with the
+// subsystem, and filtered to the subsystem's level if one is configured.
+func (l LogLevels) Logger(subsystem Subsystem) zerolog.Logger {
+	logger := log.With().Str("subsystem", string(subsystem)).Logger()
+	if level, ok := l[subsystem]; ok {
+		logger = logger.Level(level)
+	}
+	return logger
+}
+
+// formatLogFrequency formats a frequency for logging, in MHz with a fixed three decimal places, which is kHz precision.
+// Unlike [FormatFrequency], the precision is fixed so that log lines can be correlated by exact string match.
+func formatLogFrequency(frequency unit.Frequency) string {
+	return strconv.FormatFloat(frequency.Megahertz(), 'f', 3, 64)
+}
+
+// LogFrequency returns a function which adds the given frequency to a log event as a "frequency" field in MHz. Use it
+// with [zerolog.Event.Func] so that every log line formats frequencies the same way.
+func LogFrequency(frequency unit.Frequency) func(*zerolog.Event) {
+	return func(e *zerolog.Event) {
+		e.Str("frequency", formatLogFrequency(frequency))
+	}
+}
+
+// LogFrequencies is like [LogFrequency], but adds a "frequencies" field of several frequencies.
+func LogFrequencies(frequencies []unit.Frequency) func(*zerolog.Event) {
+	return func(e *zerolog.Event) {
+		formatted := make([]string, 0, len(frequencies))
+		for _, frequency := range frequencies {
+			formatted = append(formatted, formatLogFrequency(frequency))
+		}
+		e.Strs("frequencies", formatted)
+	}
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/martinlindhe/unit"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogLevelsLogger(t *testing.T) {
+	t.Parallel()
+	levels := LogLevels{
+		SubsystemAudioReceive: zerolog.TraceLevel,
+		SubsystemDataSync:     zerolog.WarnLevel,
+	}
+	assert.Equal(t, zerolog.TraceLevel, levels.Logger(SubsystemAudioReceive).GetLevel())
+	assert.Equal(t, zerolog.WarnLevel, levels.Logger(SubsystemDataSync).GetLevel())
+	assert.Equal(t, log.Logger.GetLevel(), levels.Logger(SubsystemPing).GetLevel())
+
+	var unset LogLevels
+	assert.Equal(t, log.Logger.GetLevel(), unset.Logger(SubsystemAudioTransmit).GetLevel())
+}
+
+func TestLogFrequency(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		frequency unit.Frequency
+		expected  string
+	}{
+		{frequency: 251 * unit.Megahertz, expected: `{"frequency":"251.000"}`},
+		{frequency: 305.75 * unit.Megahertz, expected: `{"frequency":"305.750"}`},
+		{frequency: 133.0004 * unit.Megahertz, expected: `{"frequency":"133.000"}`},
+		{frequency: 30.025 * unit.Megahertz, expected: `{"frequency":"30.025"}`},
+	}
+	for _, test := range testCases {
+		t.Run(test.expected, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf)
+			logger.Log().Func(LogFrequency(test.frequency)).Send()
+			assert.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestLogFrequencies(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	logger.Log().Func(LogFrequencies([]unit.Frequency{251 * unit.Megahertz, 133.5 * unit.Megahertz})).Send()
+	assert.JSONEq(t, `{"frequencies":["251.000","133.500"]}`, buf.String())
+}
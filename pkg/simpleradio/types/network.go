@@ -0,0 +1,50 @@
+package types
+
+import "fmt"
+
+// AddressFamily selects the IP version used to connect to the SRS server.
+type AddressFamily int
+
+const (
+	// AddressFamilyAny lets the resolver choose the IP version. This is the default.
+	AddressFamilyAny AddressFamily = iota
+	// AddressFamilyIPv4 connects to the SRS server over IPv4 only.
+	AddressFamilyIPv4
+	// AddressFamilyIPv6 connects to the SRS server over IPv6 only.
+	AddressFamilyIPv6
+)
+
+// String implements [fmt.Stringer].
+func (f AddressFamily) String() string {
+	switch f {
+	case AddressFamilyAny:
+		return "any"
+	case AddressFamilyIPv4:
+		return "ipv4"
+	case AddressFamilyIPv6:
+		return "ipv6"
+	default:
+		return "unknown"
+	}
+}
+
+// Validate returns an error if the address family is not one of the defined address families.
+func (f AddressFamily) Validate() error {
+	if f != AddressFamilyAny && f != AddressFamilyIPv4 && f != AddressFamilyIPv6 {
+		return fmt.Errorf("address family %d is not defined", f)
+	}
+	return nil
+}
+
+// Network returns the network name to pass to a dialer for the given base network, which must be "tcp" or "udp". For
+// example, the base network "udp" becomes "udp4" when the address family is [AddressFamilyIPv4].
+func (f AddressFamily) Network(base string) string {
+	switch f {
+	case AddressFamilyIPv4:
+		return base + "4"
+	case AddressFamilyIPv6:
+		return base + "6"
+	default:
+		return base
+	}
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressFamily(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		family AddressFamily
+		tcp    string
+		udp    string
+	}{
+		{family: AddressFamilyAny, tcp: "tcp", udp: "udp"},
+		{family: AddressFamilyIPv4, tcp: "tcp4", udp: "udp4"},
+		{family: AddressFamilyIPv6, tcp: "tcp6", udp: "udp6"},
+	}
+	for _, test := range testCases {
+		t.Run(test.family.String(), func(t *testing.T) {
+			t.Parallel()
+			require.NoError(t, test.family.Validate())
+			assert.Equal(t, test.tcp, test.family.Network("tcp"))
+			assert.Equal(t, test.udp, test.family.Network("udp"))
+		})
+	}
+	require.Error(t, AddressFamily(3).Validate())
+}
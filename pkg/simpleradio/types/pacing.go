@@ -0,0 +1,30 @@
+package types
+
+// PacingStrategy selects how the audio client waits between voice packets while transmitting.
+type PacingStrategy int
+
+const (
+	// PacingSleep sleeps until each packet is due. This is the most efficient strategy, but on systems with coarse
+	// timer granularity the packets may be sent late enough to cause stutter.
+	PacingSleep PacingStrategy = iota
+	// PacingSpin sleeps until shortly before each packet is due, then busy-waits for the remainder. This is the most
+	// precise strategy at the cost of some CPU time.
+	PacingSpin
+	// PacingBurst sends packets in small bursts and sleeps between bursts. This halves the number of sleeps, which
+	// reduces the impact of timer granularity, at the cost of sending some packets up to a frame early.
+	PacingBurst
+)
+
+// String implements [fmt.Stringer].
+func (s PacingStrategy) String() string {
+	switch s {
+	case PacingSleep:
+		return "sleep"
+	case PacingSpin:
+		return "spin"
+	case PacingBurst:
+		return "burst"
+	default:
+		return "unknown"
+	}
+}
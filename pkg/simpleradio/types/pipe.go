@@ -0,0 +1,19 @@
+package types
+
+import (
+	"time"
+
+	"github.com/martinlindhe/unit"
+)
+
+// PipeConfiguration configures an external command, such as ffmpeg, which received audio is piped into for recording
+// or streaming.
+type PipeConfiguration struct {
+	// Command is the command to run and its arguments. Received audio is written to its standard input as 16kHz mono
+	// S16LE PCM, with no silence between transmissions. If empty, no command is run.
+	Command []string
+	// Frequency is the frequency whose received audio is piped. If zero, the first radio's frequency is used.
+	Frequency unit.Frequency
+	// RestartDelay is how long to wait before restarting the command after it exits. If zero, 5 seconds is used.
+	RestartDelay time.Duration
+}
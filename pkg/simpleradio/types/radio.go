@@ -1,7 +1,10 @@
 package types
 
 import (
-	"math"
+	"fmt"
+	"slices"
+
+	"github.com/martinlindhe/unit"
 )
 
 // This file implements types from https://github.com/ciribob/DCS-SimpleRadioStandalone/blob/master/DCS-SR-Common/DCSState/RadioInformation.cs
@@ -19,7 +22,9 @@ const (
 	ModulationDisabled = 3
 	// ModulationIntercom is HAVE QUICK (https://en.wikipedia.org/wiki/Have_Quick, unused).
 	ModulationHAVEQUICK = 4
-	// ModulationSATCOM is satellite voice channels (unused).
+	// ModulationSATCOM is satellite voice channels. SATCOM transmissions have no range or line-of-sight limits. A
+	// SATCOM channel is represented in the same way as any other radio channel: by its frequency, with this
+	// modulation. Clients are on the same SATCOM channel if their frequencies match and both use this modulation.
 	ModulationSATCOM = 5
 	// ModulationMIDS is Multifunction Information Distribution System (datalink digital voice channels)
 	// These are used by F/A-18C for VOC A and VOC B.
@@ -28,6 +33,68 @@ const (
 	ModulationSINCGARS = 7
 )
 
+// IntercomFrequencyLimit is the frequency at or below which SRS does not treat a radio as an ordinary radio. SRS
+// clients use placeholder frequencies in this range for the intercom and for unused radio slots, so other clients are
+// not tuned to them.
+const IntercomFrequencyLimit = 8 * unit.Megahertz
+
+// CheckRadio returns an error describing why the given radio is likely misconfigured, or nil if it looks like an
+// ordinary radio. A misconfigured radio is still accepted by SRS, but no players will be tuned to it, so nothing
+// transmitted on it will be heard and nothing will be received on it.
+func CheckRadio(radio Radio) error {
+	frequency := FrequencyFromHertz(radio.Frequency)
+	switch {
+	case radio.Modulation == ModulationIntercom:
+		return fmt.Errorf("%s MHz uses intercom modulation, which is only heard by the crew of a multicrew aircraft", FormatFrequency(frequency))
+	case radio.Modulation == ModulationDisabled:
+		return fmt.Errorf("%s MHz uses disabled modulation, which SRS treats as a switched-off radio", FormatFrequency(frequency))
+	case frequency <= IntercomFrequencyLimit:
+		return fmt.Errorf(
+			"%s MHz is at or below %s MHz, which SRS uses for the intercom and unused radio slots; check that the frequency is in Hz, not MHz",
+			FormatFrequency(frequency),
+			FormatFrequency(IntercomFrequencyLimit),
+		)
+	}
+	return nil
+}
+
+// MaxRadios is the number of radio slots in an SRS client, including the intercom. SRS sends a client's entire radio
+// list in a single newline-delimited message with no length limit, so this is the only practical limit.
+// See PlayerRadioInfo.radios in the SRS source code.
+const MaxRadios = 11
+
+// AddRadio returns a copy of the given radios with the given radio appended. The given slice is not modified. An error
+// is returned if there are already [MaxRadios] radios, or if one of the radios is already tuned to the same frequency as
+// the new radio within the given tolerance.
+func AddRadio(radios []Radio, radio Radio, tolerance unit.Frequency) ([]Radio, error) {
+	if len(radios) >= MaxRadios {
+		return nil, fmt.Errorf("SRS supports at most %d radios", MaxRadios)
+	}
+	for _, existing := range radios {
+		if existing.IsSameFrequencyWithin(radio, tolerance) {
+			return nil, fmt.Errorf("a radio is already tuned to %s MHz", FormatFrequency(FrequencyFromHertz(radio.Frequency)))
+		}
+	}
+	return append(slices.Clone(radios), radio), nil
+}
+
+// RemoveRadio returns a copy of the given radios without the radios tuned to the given frequency within the given
+// tolerance, and the removed radios. The given slice is not modified. An error is returned if no radio is tuned to the
+// frequency.
+func RemoveRadio(radios []Radio, frequency unit.Frequency, tolerance unit.Frequency) (remaining, removed []Radio, err error) {
+	for _, radio := range radios {
+		if IsSameFrequency(radio.Frequency, frequency.Hertz(), tolerance) {
+			removed = append(removed, radio)
+		} else {
+			remaining = append(remaining, radio)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil, fmt.Errorf("no radio is tuned to %s MHz", FormatFrequency(frequency))
+	}
+	return remaining, removed, nil
+}
+
 // Radio describes one of a client's radios.
 type Radio struct {
 	// Frequency is the transmission frequency in Hz.
@@ -44,10 +111,21 @@ type Radio struct {
 	ShouldRetransmit bool    `json:"retransmit"`
 }
 
-// IsSameFrequency is true if the other radio has the same frequency, modulation, and encryption settings as this radio.
+// IsSATCOM is true if the radio is a SATCOM radio.
+func (r Radio) IsSATCOM() bool {
+	return r.Modulation == ModulationSATCOM
+}
+
+// IsSameFrequency is true if the other radio has the same frequency, modulation, and encryption settings as this radio,
+// within the default frequency tolerance.
 func (r Radio) IsSameFrequency(other Radio) bool {
-	// 1KHz range acceptable
-	doesFrequencyMatch := math.Abs(float64(r.Frequency)-float64(other.Frequency)) <= 500.0
+	return r.IsSameFrequencyWithin(other, DefaultFrequencyTolerance)
+}
+
+// IsSameFrequencyWithin is true if the other radio has the same modulation and encryption settings as this radio, and
+// its frequency differs by no more than the given tolerance.
+func (r Radio) IsSameFrequencyWithin(other Radio, tolerance unit.Frequency) bool {
+	doesFrequencyMatch := IsSameFrequency(r.Frequency, other.Frequency, tolerance)
 	doesModulationMatch := r.Modulation == other.Modulation
 	doesEncryptionMatch := (!r.IsEncrypted && !other.IsEncrypted) || (r.IsEncrypted && other.IsEncrypted && r.EncryptionKey == other.EncryptionKey)
 	return doesFrequencyMatch && doesModulationMatch && doesEncryptionMatch
@@ -0,0 +1,130 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSameFrequency(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		a        float64
+		b        float64
+		expected bool
+	}{
+		{name: "exact", a: 251000000, b: 251000000, expected: true},
+		{name: "float rounding", a: 251000000, b: 250999999.99999997, expected: true},
+		{name: "within tolerance above", a: 251000000, b: 251000400, expected: true},
+		{name: "within tolerance below", a: 251000000, b: 250999600, expected: true},
+		{name: "at tolerance", a: 251000000, b: 251000500, expected: true},
+		{name: "just beyond tolerance", a: 251000000, b: 251000501, expected: false},
+		{name: "adjacent 25kHz channel", a: 251000000, b: 251025000, expected: false},
+		{name: "different band", a: 251000000, b: 133000000, expected: false},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, IsSameFrequency(test.a, test.b, DefaultFrequencyTolerance))
+			assert.Equal(t, test.expected, IsSameFrequency(test.b, test.a, DefaultFrequencyTolerance))
+		})
+	}
+}
+
+func TestRadioIsSameFrequency(t *testing.T) {
+	t.Parallel()
+	radio := Radio{Frequency: 251000000, Modulation: ModulationAM}
+	assert.True(t, radio.IsSameFrequency(Radio{Frequency: 251000300, Modulation: ModulationAM}))
+	assert.False(t, radio.IsSameFrequency(Radio{Frequency: 251000300, Modulation: ModulationFM}))
+	assert.False(t, radio.IsSameFrequency(Radio{Frequency: 251000000, Modulation: ModulationAM, IsEncrypted: true, EncryptionKey: 1}))
+	assert.True(t, Radio{Frequency: 251000000, IsEncrypted: true, EncryptionKey: 1}.IsSameFrequency(Radio{Frequency: 251000100, IsEncrypted: true, EncryptionKey: 1}))
+}
+
+func TestSATCOM(t *testing.T) {
+	t.Parallel()
+	satcom := Radio{Frequency: 305000000, Modulation: ModulationSATCOM}
+	assert.True(t, satcom.IsSATCOM())
+	assert.False(t, Radio{Frequency: 305000000, Modulation: ModulationAM}.IsSATCOM())
+	assert.True(t, satcom.IsSameFrequency(Radio{Frequency: 305000000, Modulation: ModulationSATCOM}))
+	assert.False(t, satcom.IsSameFrequency(Radio{Frequency: 305000000, Modulation: ModulationAM}))
+	assert.False(t, satcom.IsSameFrequency(Radio{Frequency: 306000000, Modulation: ModulationSATCOM}))
+}
+
+func TestRadioInfoIsOnFrequency(t *testing.T) {
+	t.Parallel()
+	info := RadioInfo{Radios: []Radio{{Frequency: 251000000}, {Frequency: 133000000}}}
+	assert.True(t, info.IsOnFrequency(RadioInfo{Radios: []Radio{{Frequency: 30000000}, {Frequency: 132999800}}}))
+	assert.False(t, info.IsOnFrequency(RadioInfo{Radios: []Radio{{Frequency: 30000000}, {Frequency: 132998000}}}))
+	assert.False(t, info.IsOnFrequency(RadioInfo{}))
+}
+
+func TestAddRadio(t *testing.T) {
+	t.Parallel()
+	radios := []Radio{{Frequency: 251000000, Modulation: ModulationAM}}
+	added, err := AddRadio(radios, Radio{Frequency: 133000000, Modulation: ModulationAM}, DefaultFrequencyTolerance)
+	require.NoError(t, err)
+	assert.Equal(t, []Radio{{Frequency: 251000000, Modulation: ModulationAM}, {Frequency: 133000000, Modulation: ModulationAM}}, added)
+	assert.Len(t, radios, 1, "given radios should not be modified")
+
+	_, err = AddRadio(radios, Radio{Frequency: 251000300, Modulation: ModulationAM}, DefaultFrequencyTolerance)
+	require.Error(t, err, "a radio on the same frequency should be rejected")
+	_, err = AddRadio(radios, Radio{Frequency: 251000000, Modulation: ModulationFM}, DefaultFrequencyTolerance)
+	require.NoError(t, err, "a radio with a different modulation is a different channel")
+
+	full := make([]Radio, MaxRadios)
+	for i := range full {
+		full[i] = Radio{Frequency: float64(250000000 + i*1000000)}
+	}
+	_, err = AddRadio(full, Radio{Frequency: 30000000}, DefaultFrequencyTolerance)
+	require.Error(t, err)
+}
+
+func TestRemoveRadio(t *testing.T) {
+	t.Parallel()
+	radios := []Radio{
+		{Frequency: 251000000, Modulation: ModulationAM},
+		{Frequency: 251000000, Modulation: ModulationFM},
+		{Frequency: 133000000, Modulation: ModulationAM},
+	}
+	remaining, removed, err := RemoveRadio(radios, 251*unit.Megahertz, DefaultFrequencyTolerance)
+	require.NoError(t, err)
+	assert.Equal(t, []Radio{{Frequency: 133000000, Modulation: ModulationAM}}, remaining)
+	assert.Equal(t, radios[:2], removed)
+	assert.Len(t, radios, 3, "given radios should not be modified")
+
+	_, _, err = RemoveRadio(radios, 30*unit.Megahertz, DefaultFrequencyTolerance)
+	require.Error(t, err)
+}
+
+func TestCheckRadio(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name  string
+		radio Radio
+		ok    bool
+	}{
+		{"UHF", Radio{Frequency: 251000000, Modulation: ModulationAM}, true},
+		{"VHF FM", Radio{Frequency: 30000000, Modulation: ModulationFM}, true},
+		{"SATCOM", Radio{Frequency: 305000000, Modulation: ModulationSATCOM}, true},
+		{"just above limit", Radio{Frequency: 8001000, Modulation: ModulationAM}, true},
+		{"at limit", Radio{Frequency: 8000000, Modulation: ModulationAM}, false},
+		{"in MHz instead of Hz", Radio{Frequency: 251, Modulation: ModulationAM}, false},
+		{"intercom", Radio{Frequency: 100, Modulation: ModulationIntercom}, false},
+		{"intercom modulation", Radio{Frequency: 251000000, Modulation: ModulationIntercom}, false},
+		{"disabled", Radio{Frequency: 251000000, Modulation: ModulationDisabled}, false},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckRadio(test.radio)
+			if test.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
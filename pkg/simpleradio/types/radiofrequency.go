@@ -0,0 +1,140 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/martinlindhe/unit"
+)
+
+// modulationNames are the names used to write each modulation in frequency strings such as "251.0 AM".
+var modulationNames = map[Modulation]string{
+	ModulationAM:        "AM",
+	ModulationFM:        "FM",
+	ModulationIntercom:  "INTERCOM",
+	ModulationDisabled:  "DISABLED",
+	ModulationHAVEQUICK: "HAVEQUICK",
+	ModulationSATCOM:    "SATCOM",
+	ModulationMIDS:      "MIDS",
+	ModulationSINCGARS:  "SINCGARS",
+}
+
+// String returns the name of the modulation, e.g. "AM".
+func (m Modulation) String() string {
+	if name, ok := modulationNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("Modulation(%d)", byte(m))
+}
+
+// encryptionKeyword separates the modulation from the encryption key in a frequency string.
+const encryptionKeyword = "ENC"
+
+// RadioFrequency is a frequency that can be tuned on a radio: a frequency, its modulation, and optionally an
+// encryption key. Two radios can only hear each other if all of these match.
+type RadioFrequency struct {
+	// Frequency is the carrier frequency.
+	Frequency unit.Frequency
+	// Modulation is the modulation mode.
+	Modulation Modulation
+	// IsEncrypted indicates that transmissions on this frequency are encrypted.
+	IsEncrypted bool
+	// EncryptionKey is the encryption key. It is only meaningful if IsEncrypted is true.
+	EncryptionKey byte
+}
+
+// ParseRadioFrequency parses a string into a RadioFrequency. The string is a positive decimal number of MHz, optionally
+// followed by a modulation, and then optionally by "ENC" and an encryption key. Whitespace between the parts is
+// optional and case is ignored. If the modulation is omitted, it defaults to AM. For example: "251.0", "251.0AM",
+// "30 FM", "305.0 SATCOM" or "251.0 AM ENC 3".
+func ParseRadioFrequency(s string) (RadioFrequency, error) {
+	s = strings.TrimSpace(s)
+	pos := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-'
+	})
+	prefix, suffix := s, ""
+	if pos != -1 {
+		prefix, suffix = s[:pos], s[pos:]
+	}
+
+	mhz, err := strconv.ParseFloat(prefix, 64)
+	if err != nil {
+		return RadioFrequency{}, fmt.Errorf("failed to parse frequency: %w", err)
+	}
+	if math.IsNaN(mhz) || math.IsInf(mhz, 0) || mhz <= 0 {
+		return RadioFrequency{}, errors.New("frequency must be a real postive number")
+	}
+	f := RadioFrequency{
+		Frequency:  unit.Frequency(mhz) * unit.Megahertz,
+		Modulation: ModulationAM,
+	}
+
+	suffix = strings.ToUpper(strings.TrimSpace(suffix))
+	if suffix == "" {
+		return f, nil
+	}
+	suffix, key, hasKey := strings.Cut(suffix, encryptionKeyword)
+	if suffix = strings.TrimSpace(suffix); suffix != "" {
+		f.Modulation, err = parseModulation(suffix)
+		if err != nil {
+			return RadioFrequency{}, err
+		}
+	}
+	if hasKey {
+		k, err := strconv.ParseUint(strings.TrimSpace(key), 10, 8)
+		if err != nil {
+			return RadioFrequency{}, fmt.Errorf("failed to parse encryption key: %w", err)
+		}
+		f.IsEncrypted = true
+		f.EncryptionKey = byte(k)
+	}
+	return f, nil
+}
+
+// parseModulation parses the name of a modulation which can be tuned on a radio.
+func parseModulation(s string) (Modulation, error) {
+	for modulation, name := range modulationNames {
+		if name != s {
+			continue
+		}
+		if modulation == ModulationIntercom || modulation == ModulationDisabled {
+			break
+		}
+		return modulation, nil
+	}
+	return 0, fmt.Errorf("unknown modulation %q", s)
+}
+
+// Radio returns a radio tuned to this frequency.
+func (f RadioFrequency) Radio() Radio {
+	return Radio{
+		Frequency:     f.Frequency.Hertz(),
+		Modulation:    f.Modulation,
+		IsEncrypted:   f.IsEncrypted,
+		EncryptionKey: f.EncryptionKey,
+	}
+}
+
+// IsSameFrequency is true if the other frequency has the same frequency, modulation, and encryption settings, within
+// the default frequency tolerance.
+func (f RadioFrequency) IsSameFrequency(other RadioFrequency) bool {
+	return f.IsSameFrequencyWithin(other, DefaultFrequencyTolerance)
+}
+
+// IsSameFrequencyWithin is true if the other frequency has the same modulation and encryption settings, and the
+// frequencies differ by no more than the given tolerance.
+func (f RadioFrequency) IsSameFrequencyWithin(other RadioFrequency, tolerance unit.Frequency) bool {
+	return f.Radio().IsSameFrequencyWithin(other.Radio(), tolerance)
+}
+
+// String formats the frequency in a form accepted by [ParseRadioFrequency], e.g. "251.0 AM" or "251.0 AM ENC 3".
+func (f RadioFrequency) String() string {
+	s := FormatFrequency(f.Frequency) + " " + f.Modulation.String()
+	if f.IsEncrypted {
+		s += fmt.Sprintf(" %s %d", encryptionKeyword, f.EncryptionKey)
+	}
+	return s
+}
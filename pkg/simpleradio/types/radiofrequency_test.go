@@ -0,0 +1,117 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/martinlindhe/unit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRadioFrequency(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		input    string
+		expected RadioFrequency
+		ok       bool
+	}{
+		{input: "251.0", expected: RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM}, ok: true},
+		{input: " 251.0 AM ", expected: RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM}, ok: true},
+		{input: "30fm", expected: RadioFrequency{Frequency: 30 * unit.Megahertz, Modulation: ModulationFM}, ok: true},
+		{input: "305.0 SATCOM", expected: RadioFrequency{Frequency: 305 * unit.Megahertz, Modulation: ModulationSATCOM}, ok: true},
+		{input: "264.0 MIDS", expected: RadioFrequency{Frequency: 264 * unit.Megahertz, Modulation: ModulationMIDS}, ok: true},
+		{
+			input:    "251.0 AM ENC 3",
+			expected: RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM, IsEncrypted: true, EncryptionKey: 3},
+			ok:       true,
+		},
+		{
+			input:    "251.0AMENC3",
+			expected: RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM, IsEncrypted: true, EncryptionKey: 3},
+			ok:       true,
+		},
+		{
+			input:    "251.0 enc 3",
+			expected: RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM, IsEncrypted: true, EncryptionKey: 3},
+			ok:       true,
+		},
+		{input: "251.0 XM"},
+		{input: "251.0 INTERCOM"},
+		{input: "251.0 AM ENC"},
+		{input: "251.0 AM ENC 256"},
+		{input: "251.0 AM ENC x"},
+		{input: "0 AM"},
+		{input: "AM"},
+		{input: ""},
+	}
+	for _, test := range testCases {
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+			actual, err := ParseRadioFrequency(test.input)
+			if !test.ok {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, test.expected.Frequency.Megahertz(), actual.Frequency.Megahertz(), 0.0001)
+			assert.Equal(t, test.expected.Modulation, actual.Modulation)
+			assert.Equal(t, test.expected.IsEncrypted, actual.IsEncrypted)
+			assert.Equal(t, test.expected.EncryptionKey, actual.EncryptionKey)
+		})
+	}
+}
+
+func TestRadioFrequencyString(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		frequency RadioFrequency
+		expected  string
+	}{
+		{RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM}, "251.0 AM"},
+		{RadioFrequency{Frequency: 30.05 * unit.Megahertz, Modulation: ModulationFM}, "30.05 FM"},
+		{RadioFrequency{Frequency: 305.75 * unit.Megahertz, Modulation: ModulationSATCOM}, "305.75 SATCOM"},
+		{RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM, IsEncrypted: true, EncryptionKey: 3}, "251.0 AM ENC 3"},
+	}
+	for _, test := range testCases {
+		t.Run(test.expected, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, test.frequency.String())
+			parsed, err := ParseRadioFrequency(test.frequency.String())
+			require.NoError(t, err)
+			assert.True(t, parsed.IsSameFrequency(test.frequency))
+		})
+	}
+}
+
+func TestRadioFrequencyIsSameFrequency(t *testing.T) {
+	t.Parallel()
+	base := RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM}
+	testCases := []struct {
+		name     string
+		other    RadioFrequency
+		expected bool
+	}{
+		{"identical", base, true},
+		{"within tolerance", RadioFrequency{Frequency: 251*unit.Megahertz + 100*unit.Hertz, Modulation: ModulationAM}, true},
+		{"outside tolerance", RadioFrequency{Frequency: 251.025 * unit.Megahertz, Modulation: ModulationAM}, false},
+		{"different modulation", RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationFM}, false},
+		{"encrypted", RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM, IsEncrypted: true, EncryptionKey: 1}, false},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, base.IsSameFrequency(test.other))
+			assert.Equal(t, test.expected, test.other.IsSameFrequency(base))
+		})
+	}
+}
+
+func TestRadioFrequencyRadio(t *testing.T) {
+	t.Parallel()
+	f := RadioFrequency{Frequency: 251 * unit.Megahertz, Modulation: ModulationAM, IsEncrypted: true, EncryptionKey: 3}
+	radio := f.Radio()
+	assert.InDelta(t, 251e6, radio.Frequency, 0.5)
+	assert.Equal(t, Modulation(ModulationAM), radio.Modulation)
+	assert.True(t, radio.IsEncrypted)
+	assert.Equal(t, byte(3), radio.EncryptionKey)
+}
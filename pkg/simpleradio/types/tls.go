@@ -0,0 +1,15 @@
+package types
+
+// TLSConfiguration configures TLS for the data connection to the SRS server. TLS is not used for the audio connection.
+type TLSConfiguration struct {
+	// Enabled is true if the data client should connect to the SRS server over TLS.
+	Enabled bool
+	// CAFile is the path to a PEM file of certificate authorities used to verify the server's certificate. If empty,
+	// the system certificate pool is used.
+	CAFile string
+	// ServerName overrides the hostname used to verify the server's certificate. If empty, the host from the server
+	// address is used.
+	ServerName string
+	// InsecureSkipVerify disables verification of the server's certificate. This should only be used for testing.
+	InsecureSkipVerify bool
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+)
+
+// DataTransport carries the SRS data protocol's newline-delimited JSON messages between the data client and the SRS
+// server. Any [net.Conn] implements it, including the [net.TCPConn] opened by the default [TCPDialer] and a TLS
+// connection. Tests and alternative transports only need to implement this small interface.
+type DataTransport interface {
+	io.ReadWriteCloser
+	// LocalAddr returns the local network address, for diagnostics.
+	LocalAddr() net.Addr
+	// SetWriteDeadline sets the deadline for future writes, so that a stalled server cannot block the client forever.
+	// A write which exceeds the deadline returns an error wrapping [os.ErrDeadlineExceeded].
+	SetWriteDeadline(time.Time) error
+}
+
+// DataTransportFactory opens the transport used by the SRS data client. It is given the network, which is "tcp", "tcp4"
+// or "tcp6" depending on the configured [AddressFamily], and the address of the SRS server. It is called again whenever
+// the client reconnects, such as when it rotates its GUID.
+type DataTransportFactory func(ctx context.Context, network, address string) (DataTransport, error)
+
+// AudioTransport carries the SRS audio protocol's datagrams between the audio client and the SRS server. Each Read
+// returns a single packet and each Write sends a single packet. Any [net.Conn] implements it, including the
+// [net.UDPConn] opened by the default [UDPDialer]. If the transport also implements [net.PacketConn], packets from
+// addresses other than RemoteAddr are dropped; otherwise, every packet is assumed to be from the server.
+type AudioTransport interface {
+	io.ReadWriteCloser
+	// LocalAddr returns the local network address, for diagnostics.
+	LocalAddr() net.Addr
+	// RemoteAddr returns the SRS server's network address.
+	RemoteAddr() net.Addr
+}
+
+// AudioTransportFactory opens the transport used by the SRS audio client. It is given the network, which is "udp",
+// "udp4" or "udp6" depending on the configured [AddressFamily], and the address of the SRS server. It is called again
+// whenever the client reconnects.
+type AudioTransportFactory func(ctx context.Context, network, address string) (AudioTransport, error)
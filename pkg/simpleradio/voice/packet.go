@@ -148,7 +148,19 @@ func NewVoicePacket(audioBytes []byte, frequencies []Frequency, unitID uint32, p
 
 // Encode serializes a VoicePacket into a byte array.
 func (vp *VoicePacket) Encode() []byte {
-	b := make([]byte, vp.PacketLength)
+	return vp.EncodeInto(nil)
+}
+
+// EncodeInto serializes a VoicePacket into the given buffer and returns the serialized packet. If the buffer's capacity
+// is too small, a larger buffer is allocated. The returned slice may share memory with the given buffer, so callers
+// which reuse a buffer must not retain the returned slice after the next call.
+func (vp *VoicePacket) EncodeInto(b []byte) []byte {
+	if cap(b) < int(vp.PacketLength) {
+		b = make([]byte, vp.PacketLength)
+	} else {
+		b = b[:vp.PacketLength]
+		clear(b)
+	}
 
 	/* Header Segment */
 	binary.LittleEndian.PutUint16(b[0:2], vp.PacketLength)
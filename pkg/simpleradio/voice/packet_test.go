@@ -0,0 +1,89 @@
+package voice
+
+import (
+	"testing"
+
+	"github.com/dharmab/skyeye/pkg/simpleradio/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPacket() VoicePacket {
+	audio := make([]byte, 120)
+	for i := range audio {
+		audio[i] = byte(i)
+	}
+	guid := []byte(types.NewGUID())
+	return NewVoicePacket(
+		audio,
+		[]Frequency{
+			{Frequency: 251000000, Modulation: 0},
+			{Frequency: 30000000, Modulation: 1},
+		},
+		100000002,
+		42,
+		0,
+		guid,
+		guid,
+	)
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	t.Parallel()
+	packet := newTestPacket()
+	decoded := NewVoicePacketFrom(packet.Encode())
+	assert.Equal(t, packet.PacketLength, decoded.PacketLength)
+	assert.Equal(t, packet.AudioSegmentLength, decoded.AudioSegmentLength)
+	assert.Equal(t, packet.FrequenciesSegmentLength, decoded.FrequenciesSegmentLength)
+	assert.Equal(t, packet.AudioBytes, decoded.AudioBytes)
+	assert.Equal(t, packet.Frequencies, decoded.Frequencies)
+	assert.Equal(t, packet.UnitID, decoded.UnitID)
+	assert.Equal(t, packet.PacketID, decoded.PacketID)
+	assert.Equal(t, packet.Hops, decoded.Hops)
+	assert.Equal(t, packet.RelayGUID, decoded.RelayGUID)
+	assert.Equal(t, packet.OriginGUID, decoded.OriginGUID)
+}
+
+func TestEncodeInto(t *testing.T) {
+	t.Parallel()
+	packet := newTestPacket()
+	expected := packet.Encode()
+
+	// Too small buffer is replaced
+	require.Equal(t, expected, packet.EncodeInto(make([]byte, 0, 10)))
+
+	// Dirty buffer is reused and cleared
+	buf := make([]byte, 1500)
+	for i := range buf {
+		buf[i] = 0xFF
+	}
+	b := packet.EncodeInto(buf)
+	require.Equal(t, expected, b)
+	require.Same(t, &buf[0], &b[0])
+}
+
+func BenchmarkEncode(b *testing.B) {
+	packet := newTestPacket()
+	b.ReportAllocs()
+	for range b.N {
+		_ = packet.Encode()
+	}
+}
+
+func BenchmarkEncodeInto(b *testing.B) {
+	packet := newTestPacket()
+	buf := make([]byte, 0, 1500)
+	b.ReportAllocs()
+	for range b.N {
+		_ = packet.EncodeInto(buf)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	packet := newTestPacket()
+	encoded := packet.Encode()
+	b.ReportAllocs()
+	for range b.N {
+		_ = NewVoicePacketFrom(encoded)
+	}
+}